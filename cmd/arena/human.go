@@ -0,0 +1,48 @@
+package main
+
+import (
+	"math/rand/v2"
+
+	"github.com/rakshitg600/notakto-solo/logic"
+)
+
+// humanStrategy is a scripted stand-in for a casual player: it never kills a
+// board while a safe cell is left, leans towards central cells and otherwise
+// plays without lookahead.
+type humanStrategy struct {
+	// centreBias is the chance of playing the most central safe cell instead
+	// of a random safe one.
+	centreBias float64
+}
+
+func (humanStrategy) Name() string { return "human" }
+
+func (h humanStrategy) ChooseMove(boards []int32, boardSize int32, numberOfBoards int32, rng *rand.Rand) int32 {
+	state, err := logic.NewBoardState(boards, boardSize, numberOfBoards)
+	if err != nil {
+		return -1
+	}
+	moves := state.ValidMoves()
+	if len(moves) == 0 {
+		return -1
+	}
+
+	safe := make([]int32, 0, len(moves))
+	for _, m := range moves {
+		if !state.IsKillingMove(m) {
+			safe = append(safe, m)
+		}
+	}
+	if len(safe) == 0 {
+		return moves[rng.IntN(len(moves))]
+	}
+	// ValidMoves is ordered centre-first, so safe[0] is the most central safe cell.
+	if rng.Float64() < h.centreBias {
+		return safe[0]
+	}
+	return safe[rng.IntN(len(safe))]
+}
+
+func init() {
+	logic.RegisterStrategy(humanStrategy{centreBias: 0.4})
+}
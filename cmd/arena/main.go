@@ -0,0 +1,346 @@
+// Command arena plays AI strategies against each other offline and reports
+// win rates, game lengths and move times for every game setup the API accepts.
+//
+//	go run ./cmd/arena -a ai -b human -games 1000 -json results.json
+//
+// A player is "ai" (the difficulty mix from -config, or the built-in default),
+// "human" (a scripted casual player) or any strategy registered in logic.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand/v2"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rakshitg600/notakto-solo/config"
+	"github.com/rakshitg600/notakto-solo/logic"
+)
+
+// Limits mirror the defaults CreateGameHandler enforces.
+const (
+	minBoards, maxBoards         = 1, 5
+	minBoardSize, maxBoardSize   = 2, 5
+	minDifficulty, maxDifficulty = 1, 5
+)
+
+const aiPlayerName = "ai"
+
+type player struct {
+	name   string
+	choose func(boards []int32, boardSize int32, numberOfBoards int32, difficulty int32, rng *rand.Rand) int32
+}
+
+type moveTimes struct {
+	P50 float64 `json:"p50Micros"`
+	P90 float64 `json:"p90Micros"`
+	P99 float64 `json:"p99Micros"`
+}
+
+type matchResult struct {
+	NumberOfBoards    int32     `json:"numberOfBoards"`
+	BoardSize         int32     `json:"boardSize"`
+	Difficulty        int32     `json:"difficulty,omitempty"`
+	Games             int       `json:"games"`
+	WinRateA          float64   `json:"winRateA"`
+	WinRateB          float64   `json:"winRateB"`
+	FirstMoverWinRate float64   `json:"firstMoverWinRate"`
+	AveragePlies      float64   `json:"averagePlies"`
+	MoveTimeA         moveTimes `json:"moveTimeA"`
+	MoveTimeB         moveTimes `json:"moveTimeB"`
+}
+
+type report struct {
+	PlayerA  string        `json:"playerA"`
+	PlayerB  string        `json:"playerB"`
+	Seed     uint64        `json:"seed"`
+	Strategy string        `json:"strategyVersion"`
+	Results  []matchResult `json:"results"`
+}
+
+type gameResult struct {
+	aWon       bool
+	firstWon   bool
+	plies      int
+	aMoveTimes []time.Duration
+	bMoveTimes []time.Duration
+}
+
+func main() {
+	playerA := flag.String("a", aiPlayerName, "first player: ai, human or a registered strategy name")
+	playerB := flag.String("b", "human", "second player: ai, human or a registered strategy name")
+	games := flag.Int("games", 200, "games per setup; players alternate who moves first")
+	boardsFlag := flag.String("boards", "1-5", "numberOfBoards values, e.g. 1-5 or 1,3")
+	sizesFlag := flag.String("sizes", "2-5", "boardSize values, e.g. 2-5 or 3")
+	difficultiesFlag := flag.String("difficulties", "1-5", "difficulty values used by ai players")
+	configPath := flag.String("config", "", "JSON file in the ai_strategies config format (default: built-in config)")
+	seed := flag.Uint64("seed", 1, "seed for every game's random generator")
+	parallel := flag.Int("parallel", runtime.NumCPU(), "games played concurrently")
+	jsonPath := flag.String("json", "", "also write results as JSON to this file, or - for stdout")
+	flag.Parse()
+
+	if *games < 1 || *parallel < 1 {
+		log.Fatal("-games and -parallel must be positive")
+	}
+	boardCounts, err := parseValues(*boardsFlag, minBoards, maxBoards)
+	if err != nil {
+		log.Fatalf("-boards: %v", err)
+	}
+	boardSizes, err := parseValues(*sizesFlag, minBoardSize, maxBoardSize)
+	if err != nil {
+		log.Fatalf("-sizes: %v", err)
+	}
+	difficulties, err := parseValues(*difficultiesFlag, minDifficulty, maxDifficulty)
+	if err != nil {
+		log.Fatalf("-difficulties: %v", err)
+	}
+
+	strategies, err := loadStrategyConfig(*configPath)
+	if err != nil {
+		log.Fatalf("-config: %v", err)
+	}
+	registry, err := config.NewStrategyRegistry(strategies)
+	if err != nil {
+		log.Fatalf("-config: %v", err)
+	}
+	a, err := newPlayer(*playerA, registry)
+	if err != nil {
+		log.Fatalf("-a: %v", err)
+	}
+	b, err := newPlayer(*playerB, registry)
+	if err != nil {
+		log.Fatalf("-b: %v", err)
+	}
+	// Difficulty only changes how an ai player mixes strategies.
+	if a.name != aiPlayerName && b.name != aiPlayerName {
+		difficulties = []int32{0}
+	}
+
+	out := report{PlayerA: a.name, PlayerB: b.name, Seed: *seed, Strategy: registry.Version()}
+	setup := uint64(0)
+	for _, numberOfBoards := range boardCounts {
+		for _, boardSize := range boardSizes {
+			for _, difficulty := range difficulties {
+				start := time.Now()
+				result := playMatch(a, b, numberOfBoards, boardSize, difficulty, *games, *parallel, *seed, setup)
+				log.Printf("boards=%d size=%d difficulty=%d: %d games in %v", numberOfBoards, boardSize, difficulty, *games, time.Since(start).Round(time.Millisecond))
+				out.Results = append(out.Results, result)
+				setup++
+			}
+		}
+	}
+
+	table := io.Writer(os.Stdout)
+	if *jsonPath == "-" {
+		table = os.Stderr
+	}
+	writeTable(table, out)
+	if *jsonPath != "" {
+		if err := writeJSON(*jsonPath, out); err != nil {
+			log.Fatalf("-json: %v", err)
+		}
+	}
+}
+
+func loadStrategyConfig(path string) (config.AIStrategyConfig, error) {
+	if path == "" {
+		return config.DefaultAIStrategyConfig(), nil
+	}
+	value, err := os.ReadFile(path)
+	if err != nil {
+		return config.AIStrategyConfig{}, err
+	}
+	strategies := config.AIStrategyConfig{}
+	if err := json.Unmarshal(value, &strategies); err != nil {
+		return config.AIStrategyConfig{}, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return strategies, nil
+}
+
+func newPlayer(name string, registry *logic.StrategyRegistry) (player, error) {
+	if name == aiPlayerName {
+		return player{name: name, choose: registry.Move}, nil
+	}
+	s, ok := logic.LookupStrategy(name)
+	if !ok {
+		return player{}, fmt.Errorf("unknown player %q", name)
+	}
+	return player{
+		name: name,
+		choose: func(boards []int32, boardSize int32, numberOfBoards int32, _ int32, rng *rand.Rand) int32 {
+			return s.ChooseMove(boards, boardSize, numberOfBoards, rng)
+		},
+	}, nil
+}
+
+// parseValues reads a list such as "1-3,5" and checks every value is within [lo, hi].
+func parseValues(s string, lo, hi int32) ([]int32, error) {
+	var values []int32
+	seen := map[int32]bool{}
+	for _, part := range strings.Split(s, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		from, err := strconv.ParseInt(first, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q", part)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.ParseInt(last, 10, 32); err != nil {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+		}
+		if from > to || int32(from) < lo || int32(to) > hi {
+			return nil, fmt.Errorf("%q is outside %d-%d", part, lo, hi)
+		}
+		for v := int32(from); v <= int32(to); v++ {
+			if !seen[v] {
+				seen[v] = true
+				values = append(values, v)
+			}
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return values, nil
+}
+
+func playMatch(a, b player, numberOfBoards, boardSize, difficulty int32, games, parallel int, seed uint64, setup uint64) matchResult {
+	results := make([]gameResult, games)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for game := range jobs {
+				rng := rand.New(rand.NewPCG(seed, setup<<32|uint64(game)))
+				results[game] = playGame(a, b, numberOfBoards, boardSize, difficulty, game%2 == 0, rng)
+			}
+		}()
+	}
+	for game := 0; game < games; game++ {
+		jobs <- game
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := matchResult{NumberOfBoards: numberOfBoards, BoardSize: boardSize, Difficulty: difficulty, Games: games}
+	var aWins, firstWins, plies int
+	var aTimes, bTimes []time.Duration
+	for _, r := range results {
+		if r.aWon {
+			aWins++
+		}
+		if r.firstWon {
+			firstWins++
+		}
+		plies += r.plies
+		aTimes = append(aTimes, r.aMoveTimes...)
+		bTimes = append(bTimes, r.bMoveTimes...)
+	}
+	result.WinRateA = float64(aWins) / float64(games)
+	result.WinRateB = 1 - result.WinRateA
+	result.FirstMoverWinRate = float64(firstWins) / float64(games)
+	result.AveragePlies = float64(plies) / float64(games)
+	result.MoveTimeA = percentiles(aTimes)
+	result.MoveTimeB = percentiles(bTimes)
+	return result
+}
+
+// playGame plays one game to the end. The player who kills the last live board loses.
+func playGame(a, b player, numberOfBoards, boardSize, difficulty int32, aFirst bool, rng *rand.Rand) gameResult {
+	state, err := logic.NewBoardState(nil, boardSize, numberOfBoards)
+	if err != nil {
+		log.Fatalf("boards=%d size=%d: %v", numberOfBoards, boardSize, err)
+	}
+	boards := make([]int32, 0, numberOfBoards*boardSize*boardSize)
+	result := gameResult{}
+	aToMove := aFirst
+	for {
+		mover := b
+		if aToMove {
+			mover = a
+		}
+		start := time.Now()
+		move := mover.choose(boards, boardSize, numberOfBoards, difficulty, rng)
+		elapsed := time.Since(start)
+		if aToMove {
+			result.aMoveTimes = append(result.aMoveTimes, elapsed)
+		} else {
+			result.bMoveTimes = append(result.bMoveTimes, elapsed)
+		}
+		if move == -1 {
+			// Unreachable while a board is live; score it as a loss for the mover.
+			break
+		}
+		boards = append(boards, move)
+		state.Apply(move)
+		if state.LiveBoards() == 0 {
+			break
+		}
+		aToMove = !aToMove
+	}
+	result.plies = len(boards)
+	result.aWon = !aToMove
+	result.firstWon = result.aWon == aFirst
+	return result
+}
+
+func percentiles(times []time.Duration) moveTimes {
+	if len(times) == 0 {
+		return moveTimes{}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	at := func(p float64) float64 {
+		i := int(math.Ceil(p*float64(len(times)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return float64(times[i]) / float64(time.Microsecond)
+	}
+	return moveTimes{P50: at(0.50), P90: at(0.90), P99: at(0.99)}
+}
+
+func writeTable(w io.Writer, r report) {
+	fmt.Fprintf(w, "%s (A) vs %s (B), strategy config %s, seed %d\n\n", r.PlayerA, r.PlayerB, r.Strategy, r.Seed)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "boards\tsize\tdifficulty\tgames\tA win%\tB win%\tfirst win%\tavg plies\tA p50/p90/p99 µs\tB p50/p90/p99 µs\t")
+	for _, m := range r.Results {
+		difficulty := "-"
+		if m.Difficulty != 0 {
+			difficulty = strconv.Itoa(int(m.Difficulty))
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%s\t%s\t\n",
+			m.NumberOfBoards, m.BoardSize, difficulty, m.Games,
+			100*m.WinRateA, 100*m.WinRateB, 100*m.FirstMoverWinRate, m.AveragePlies,
+			formatTimes(m.MoveTimeA), formatTimes(m.MoveTimeB))
+	}
+	tw.Flush()
+}
+
+func formatTimes(t moveTimes) string {
+	return fmt.Sprintf("%.1f/%.1f/%.1f", t.P50, t.P90, t.P99)
+}
+
+func writeJSON(path string, r report) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
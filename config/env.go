@@ -32,26 +32,115 @@ func InitEnv() error {
 			return
 		}
 
-		if err := load("FIREBASE_CREDENTIALS_JSON"); err != nil {
+		if err := load("IDENTITY_PROVIDER", "firebase"); err != nil {
 			initErr = err
 			return
 		}
 
+		switch provider, _ := GetEnv("IDENTITY_PROVIDER"); provider {
+		case "firebase":
+			if err := load("FIREBASE_CREDENTIALS_JSON"); err != nil {
+				initErr = err
+				return
+			}
+		case "jwt":
+			if err := load("JWT_ISSUER"); err != nil {
+				initErr = err
+				return
+			}
+			if err := load("JWT_AUDIENCE"); err != nil {
+				initErr = err
+				return
+			}
+			// Empty only when JWT_ALLOW_UNSIGNED is set for the Firebase Auth emulator.
+			if err := load("JWT_JWKS_URL", ""); err != nil {
+				initErr = err
+				return
+			}
+			if err := load("JWT_ALLOW_UNSIGNED", "false"); err != nil {
+				initErr = err
+				return
+			}
+		default:
+			initErr = errors.New("IDENTITY_PROVIDER must be firebase or jwt, got " + provider)
+			return
+		}
+
 		if err := load("VALKEY_URL"); err != nil {
 			initErr = err
 			return
 		}
 
+		if err := load("AUTH_TOKEN_CACHE_SIZE", "10000"); err != nil {
+			initErr = err
+			return
+		}
+
+		if err := load("AUTH_TOKEN_CACHE_SHARED", "false"); err != nil {
+			initErr = err
+			return
+		}
+
+		if err := load("AUTH_TOKEN_REVOCATION_INTERVAL", "5m"); err != nil {
+			initErr = err
+			return
+		}
+
+		if err := load("WALLET_RECONCILE_INTERVAL", "1h"); err != nil {
+			initErr = err
+			return
+		}
+
+		if err := load("WALLET_RECONCILE_BATCH_SIZE", "500"); err != nil {
+			initErr = err
+			return
+		}
+
 		if err := load("NOWPAYMENTS_API_KEY"); err != nil {
 			initErr = err
 			return
 		}
 
+		if err := load("NOWPAYMENTS_BASE_URL", "https://api.nowpayments.io/v1"); err != nil {
+			initErr = err
+			return
+		}
+
 		if err := load("NOWPAYMENTS_IPN_SECRET"); err != nil {
 			initErr = err
 			return
 		}
 
+		if err := load("NOWPAYMENTS_EMAIL", ""); err != nil {
+			initErr = err
+			return
+		}
+
+		if err := load("NOWPAYMENTS_PASSWORD", ""); err != nil {
+			initErr = err
+			return
+		}
+
+		if err := load("PAYMENT_POLL_INTERVAL", "5m"); err != nil {
+			initErr = err
+			return
+		}
+
+		if err := load("PAYMENT_POLL_MIN_AGE", "15m"); err != nil {
+			initErr = err
+			return
+		}
+
+		if err := load("PAYMENT_EXPIRE_AFTER", "24h"); err != nil {
+			initErr = err
+			return
+		}
+
+		if err := load("PAYMENT_POLL_BATCH_SIZE", "100"); err != nil {
+			initErr = err
+			return
+		}
+
 		if err := load("KEEPALIVE_TOKEN"); err != nil {
 			initErr = err
 			return
@@ -0,0 +1,50 @@
+package config
+
+import "github.com/rakshitg600/notakto-solo/logic"
+
+const AIStrategiesKey = "ai_strategies"
+
+type AIStrategyWeight struct {
+	Strategy string  `json:"strategy"`
+	Weight   float64 `json:"weight"`
+}
+
+type AIDifficultyLevel struct {
+	Difficulty int32              `json:"difficulty"`
+	Strategies []AIStrategyWeight `json:"strategies"`
+}
+
+// AIStrategyConfig maps difficulty levels to strategy mixes. Version is
+// recorded on every session created while the config is live.
+type AIStrategyConfig struct {
+	Version string              `json:"version"`
+	Levels  []AIDifficultyLevel `json:"levels"`
+}
+
+// Solver share grows linearly: 0 at difficulty 1, 1 at difficulty 5.
+var defaultAIStrategyConfig = AIStrategyConfig{
+	Version: "default-v1",
+	Levels: []AIDifficultyLevel{
+		{Difficulty: 1, Strategies: []AIStrategyWeight{{Strategy: "random", Weight: 1}}},
+		{Difficulty: 2, Strategies: []AIStrategyWeight{{Strategy: "random", Weight: 0.75}, {Strategy: "solver", Weight: 0.25}}},
+		{Difficulty: 3, Strategies: []AIStrategyWeight{{Strategy: "random", Weight: 0.5}, {Strategy: "solver", Weight: 0.5}}},
+		{Difficulty: 4, Strategies: []AIStrategyWeight{{Strategy: "random", Weight: 0.25}, {Strategy: "solver", Weight: 0.75}}},
+		{Difficulty: 5, Strategies: []AIStrategyWeight{{Strategy: "solver", Weight: 1}}},
+	},
+}
+
+func DefaultAIStrategyConfig() AIStrategyConfig {
+	return defaultAIStrategyConfig
+}
+
+// NewStrategyRegistry builds the registry that plays strategies, failing if a
+// level names an unknown strategy or has no positive weight.
+func NewStrategyRegistry(strategies AIStrategyConfig) (*logic.StrategyRegistry, error) {
+	levels := make(map[int32][]logic.StrategyWeight, len(strategies.Levels))
+	for _, level := range strategies.Levels {
+		for _, s := range level.Strategies {
+			levels[level.Difficulty] = append(levels[level.Difficulty], logic.StrategyWeight{Strategy: s.Strategy, Weight: s.Weight})
+		}
+	}
+	return logic.NewStrategyRegistry(strategies.Version, levels)
+}
@@ -0,0 +1,13 @@
+package contextkey
+
+import "context"
+
+type roleKey struct{}
+
+var Role roleKey = struct{}{}
+
+// RoleFromContext returns the authenticated player's role from ctx if set.
+func RoleFromContext(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(Role).(string)
+	return role, ok
+}
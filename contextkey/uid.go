@@ -6,7 +6,7 @@ type uidKey struct{}
 
 var UID uidKey = struct{}{}
 
-// UIDFromContext returns the Firebase UID from ctx if set.
+// UIDFromContext returns the authenticated UID from ctx if set.
 func UIDFromContext(ctx context.Context) (string, bool) {
 	uid, ok := ctx.Value(UID).(string)
 	return uid, ok
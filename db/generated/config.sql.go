@@ -7,8 +7,131 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const createConfigHistory = `-- name: CreateConfigHistory :exec
+INSERT INTO ConfigHistory (key, version, value, admin_uid)
+VALUES ($1, $2, CAST($3::text AS JSONB), $4)
+`
+
+type CreateConfigHistoryParams struct {
+	Key      string      `json:"key"`
+	Version  int32       `json:"version"`
+	Value    string      `json:"value"`
+	AdminUid pgtype.Text `json:"admin_uid"`
+}
+
+func (q *Queries) CreateConfigHistory(ctx context.Context, arg CreateConfigHistoryParams) error {
+	_, err := q.db.Exec(ctx, createConfigHistory,
+		arg.Key,
+		arg.Version,
+		arg.Value,
+		arg.AdminUid,
+	)
+	return err
+}
+
+const getConfigByKeyWithLock = `-- name: GetConfigByKeyWithLock :one
+SELECT key, value, created_at, updated_at, version
+FROM configs
+WHERE key = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetConfigByKeyWithLock(ctx context.Context, key string) (Config, error) {
+	row := q.db.QueryRow(ctx, getConfigByKeyWithLock, key)
+	var i Config
+	err := row.Scan(
+		&i.Key,
+		&i.Value,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getConfigHistoryByKey = `-- name: GetConfigHistoryByKey :many
+SELECT key, version, value, admin_uid, created_at
+FROM ConfigHistory
+WHERE key = $1
+ORDER BY version DESC
+`
+
+func (q *Queries) GetConfigHistoryByKey(ctx context.Context, key string) ([]Confighistory, error) {
+	rows, err := q.db.Query(ctx, getConfigHistoryByKey, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Confighistory{}
+	for rows.Next() {
+		var i Confighistory
+		if err := rows.Scan(
+			&i.Key,
+			&i.Version,
+			&i.Value,
+			&i.AdminUid,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getConfigHistoryValueByValueVersion = `-- name: GetConfigHistoryValueByValueVersion :one
+SELECT value
+FROM ConfigHistory
+WHERE key = $1 AND value->>'version' = $2::text
+ORDER BY version DESC
+LIMIT 1
+`
+
+type GetConfigHistoryValueByValueVersionParams struct {
+	Key          string `json:"key"`
+	ValueVersion string `json:"value_version"`
+}
+
+// The newest value of @key whose own "version" field is @value_version, for
+// configs such as ai_strategies that label each value they hold.
+func (q *Queries) GetConfigHistoryValueByValueVersion(ctx context.Context, arg GetConfigHistoryValueByValueVersionParams) ([]byte, error) {
+	row := q.db.QueryRow(ctx, getConfigHistoryValueByValueVersion, arg.Key, arg.ValueVersion)
+	var value []byte
+	err := row.Scan(&value)
+	return value, err
+}
+
+const getConfigHistoryVersion = `-- name: GetConfigHistoryVersion :one
+SELECT key, version, value, admin_uid, created_at
+FROM ConfigHistory
+WHERE key = $1 AND version = $2
+`
+
+type GetConfigHistoryVersionParams struct {
+	Key     string `json:"key"`
+	Version int32  `json:"version"`
+}
+
+func (q *Queries) GetConfigHistoryVersion(ctx context.Context, arg GetConfigHistoryVersionParams) (Confighistory, error) {
+	row := q.db.QueryRow(ctx, getConfigHistoryVersion, arg.Key, arg.Version)
+	var i Confighistory
+	err := row.Scan(
+		&i.Key,
+		&i.Version,
+		&i.Value,
+		&i.AdminUid,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const getConfigValueByKey = `-- name: GetConfigValueByKey :one
 SELECT value
 FROM configs
@@ -21,3 +144,79 @@ func (q *Queries) GetConfigValueByKey(ctx context.Context, key string) ([]byte,
 	err := row.Scan(&value)
 	return value, err
 }
+
+const getLatestConfigVersion = `-- name: GetLatestConfigVersion :one
+SELECT COALESCE(MAX(version), 0)::INTEGER AS version
+FROM ConfigHistory
+WHERE key = $1
+`
+
+func (q *Queries) GetLatestConfigVersion(ctx context.Context, key string) (int32, error) {
+	row := q.db.QueryRow(ctx, getLatestConfigVersion, key)
+	var version int32
+	err := row.Scan(&version)
+	return version, err
+}
+
+const listConfigs = `-- name: ListConfigs :many
+SELECT key, value, created_at, updated_at, version
+FROM configs
+ORDER BY key
+`
+
+func (q *Queries) ListConfigs(ctx context.Context) ([]Config, error) {
+	rows, err := q.db.Query(ctx, listConfigs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Config{}
+	for rows.Next() {
+		var i Config
+		if err := rows.Scan(
+			&i.Key,
+			&i.Value,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listenConfigChanged = `-- name: ListenConfigChanged :exec
+LISTEN config_changed
+`
+
+// Subscribes the connection to the config_changed channel, which the
+// configs_notify_changed trigger notifies with the key of the row written.
+func (q *Queries) ListenConfigChanged(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, listenConfigChanged)
+	return err
+}
+
+const upsertConfig = `-- name: UpsertConfig :exec
+INSERT INTO configs (key, value, version, created_at, updated_at)
+VALUES ($1, CAST($2::text AS JSONB), $3, NOW(), NOW())
+ON CONFLICT (key) DO UPDATE
+SET value = EXCLUDED.value,
+    version = EXCLUDED.version,
+    updated_at = NOW()
+`
+
+type UpsertConfigParams struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Version int32  `json:"version"`
+}
+
+func (q *Queries) UpsertConfig(ctx context.Context, arg UpsertConfigParams) error {
+	_, err := q.db.Exec(ctx, upsertConfig, arg.Key, arg.Value, arg.Version)
+	return err
+}
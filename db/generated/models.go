@@ -5,28 +5,101 @@
 package db
 
 import (
+	"database/sql/driver"
+	"fmt"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type WalletTransactionReason string
+
+const (
+	WalletTransactionReasonOpeningBalance WalletTransactionReason = "opening_balance"
+	WalletTransactionReasonSignUp         WalletTransactionReason = "sign_up"
+	WalletTransactionReasonGameReward     WalletTransactionReason = "game_reward"
+	WalletTransactionReasonSkipCost       WalletTransactionReason = "skip_cost"
+	WalletTransactionReasonUndoCost       WalletTransactionReason = "undo_cost"
+	WalletTransactionReasonHintCost       WalletTransactionReason = "hint_cost"
+	WalletTransactionReasonPurchase       WalletTransactionReason = "purchase"
+	WalletTransactionReasonAdminAdjust    WalletTransactionReason = "admin_adjust"
+	WalletTransactionReasonRefund         WalletTransactionReason = "refund"
+)
+
+func (e *WalletTransactionReason) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WalletTransactionReason(s)
+	case string:
+		*e = WalletTransactionReason(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WalletTransactionReason: %T", src)
+	}
+	return nil
+}
+
+type NullWalletTransactionReason struct {
+	WalletTransactionReason WalletTransactionReason `json:"wallet_transaction_reason"`
+	Valid                   bool                    `json:"valid"` // Valid is true if WalletTransactionReason is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWalletTransactionReason) Scan(value interface{}) error {
+	if value == nil {
+		ns.WalletTransactionReason, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WalletTransactionReason.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWalletTransactionReason) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WalletTransactionReason), nil
+}
+
 type Config struct {
 	Key       string    `json:"key"`
 	Value     []byte    `json:"value"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Version   int32     `json:"version"`
+}
+
+type Confighistory struct {
+	Key       string      `json:"key"`
+	Version   int32       `json:"version"`
+	Value     []byte      `json:"value"`
+	AdminUid  pgtype.Text `json:"admin_uid"`
+	CreatedAt time.Time   `json:"created_at"`
 }
 
 type Payment struct {
-	ID          string    `json:"id"`
-	Uid         string    `json:"uid"`
-	PackageID   string    `json:"package_id"`
-	Coins       int32     `json:"coins"`
-	AmountCents int32     `json:"amount_cents"`
-	Status      string    `json:"status"`
-	HostedUrl   string    `json:"hosted_url"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                string             `json:"id"`
+	Uid               string             `json:"uid"`
+	PackageID         string             `json:"package_id"`
+	Coins             int32              `json:"coins"`
+	AmountCents       int32              `json:"amount_cents"`
+	Status            string             `json:"status"`
+	HostedUrl         string             `json:"hosted_url"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+	ConfirmedAt       pgtype.Timestamptz `json:"confirmed_at"`
+	InvoiceID         pgtype.Text        `json:"invoice_id"`
+	ProviderPaymentID pgtype.Text        `json:"provider_payment_id"`
+}
+
+type PaymentRefund struct {
+	PaymentID       string    `json:"payment_id"`
+	Uid             string    `json:"uid"`
+	Coins           int32     `json:"coins"`
+	CoinsClawedBack int64     `json:"coins_clawed_back"`
+	DebtAdded       int64     `json:"debt_added"`
+	ProviderStatus  string    `json:"provider_status"`
+	CreatedAt       time.Time `json:"created_at"`
 }
 
 type Player struct {
@@ -36,15 +109,24 @@ type Player struct {
 	ProfilePic pgtype.Text `json:"profile_pic"`
 }
 
+type Playerrole struct {
+	Uid       string    `json:"uid"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 type Session struct {
-	SessionID      string           `json:"session_id"`
-	Uid            string           `json:"uid"`
-	CreatedAt      pgtype.Timestamp `json:"created_at"`
-	Gameover       pgtype.Bool      `json:"gameover"`
-	Winner         pgtype.Bool      `json:"winner"`
-	BoardSize      pgtype.Int4      `json:"board_size"`
-	NumberOfBoards pgtype.Int4      `json:"number_of_boards"`
-	Difficulty     pgtype.Int4      `json:"difficulty"`
+	SessionID       string           `json:"session_id"`
+	Uid             string           `json:"uid"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+	Gameover        pgtype.Bool      `json:"gameover"`
+	Winner          pgtype.Bool      `json:"winner"`
+	BoardSize       pgtype.Int4      `json:"board_size"`
+	NumberOfBoards  pgtype.Int4      `json:"number_of_boards"`
+	Difficulty      pgtype.Int4      `json:"difficulty"`
+	StrategyVersion pgtype.Text      `json:"strategy_version"`
+	Seed            int64            `json:"seed"`
 }
 
 type Sessionstate struct {
@@ -54,7 +136,52 @@ type Sessionstate struct {
 }
 
 type Wallet struct {
-	Uid   string      `json:"uid"`
-	Coins pgtype.Int4 `json:"coins"`
-	Xp    pgtype.Int4 `json:"xp"`
+	Uid   string `json:"uid"`
+	Coins int64  `json:"coins"`
+	Xp    int64  `json:"xp"`
+	Debt  int64  `json:"debt"`
+}
+
+type Walletadjustment struct {
+	ID        int64     `json:"id"`
+	Uid       string    `json:"uid"`
+	AdminUid  string    `json:"admin_uid"`
+	Coins     int64     `json:"coins"`
+	Xp        int64     `json:"xp"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WalletTransaction struct {
+	ID          int64                   `json:"id"`
+	Uid         string                  `json:"uid"`
+	Reason      WalletTransactionReason `json:"reason"`
+	CoinsDelta  int64                   `json:"coins_delta"`
+	XpDelta     int64                   `json:"xp_delta"`
+	CoinsAfter  int64                   `json:"coins_after"`
+	XpAfter     int64                   `json:"xp_after"`
+	ReferenceID pgtype.Text             `json:"reference_id"`
+	CreatedAt   time.Time               `json:"created_at"`
+	DebtAfter   int64                   `json:"debt_after"`
+}
+
+type WalletReconcileRun struct {
+	ID         int64     `json:"id"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Wallets    int32     `json:"wallets"`
+	Mismatched int32     `json:"mismatched"`
+	Failed     int32     `json:"failed"`
+}
+
+type WalletMismatch struct {
+	ID            int64     `json:"id"`
+	Uid           string    `json:"uid"`
+	CheckName     string    `json:"check_name"`
+	Reference     string    `json:"reference"`
+	ExpectedCoins int64     `json:"expected_coins"`
+	ActualCoins   int64     `json:"actual_coins"`
+	ExpectedXp    int64     `json:"expected_xp"`
+	ActualXp      int64     `json:"actual_xp"`
+	DetectedAt    time.Time `json:"detected_at"`
 }
@@ -7,21 +7,25 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createPayment = `-- name: CreatePayment :exec
-INSERT INTO Payment (id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+INSERT INTO Payment (id, uid, package_id, coins, amount_cents, status, hosted_url, invoice_id, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
 `
 
 type CreatePaymentParams struct {
-	ID          string `json:"id"`
-	Uid         string `json:"uid"`
-	PackageID   string `json:"package_id"`
-	Coins       int32  `json:"coins"`
-	AmountCents int32  `json:"amount_cents"`
-	Status      string `json:"status"`
-	HostedUrl   string `json:"hosted_url"`
+	ID          string      `json:"id"`
+	Uid         string      `json:"uid"`
+	PackageID   string      `json:"package_id"`
+	Coins       int32       `json:"coins"`
+	AmountCents int32       `json:"amount_cents"`
+	Status      string      `json:"status"`
+	HostedUrl   string      `json:"hosted_url"`
+	InvoiceID   pgtype.Text `json:"invoice_id"`
 }
 
 func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) error {
@@ -33,12 +37,56 @@ func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) er
 		arg.AmountCents,
 		arg.Status,
 		arg.HostedUrl,
+		arg.InvoiceID,
+	)
+	return err
+}
+
+const createPaymentRefund = `-- name: CreatePaymentRefund :exec
+INSERT INTO payment_refund (payment_id, uid, coins, coins_clawed_back, debt_added, provider_status)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreatePaymentRefundParams struct {
+	PaymentID       string `json:"payment_id"`
+	Uid             string `json:"uid"`
+	Coins           int32  `json:"coins"`
+	CoinsClawedBack int64  `json:"coins_clawed_back"`
+	DebtAdded       int64  `json:"debt_added"`
+	ProviderStatus  string `json:"provider_status"`
+}
+
+func (q *Queries) CreatePaymentRefund(ctx context.Context, arg CreatePaymentRefundParams) error {
+	_, err := q.db.Exec(ctx, createPaymentRefund,
+		arg.PaymentID,
+		arg.Uid,
+		arg.Coins,
+		arg.CoinsClawedBack,
+		arg.DebtAdded,
+		arg.ProviderStatus,
 	)
 	return err
 }
 
+const getCreditedPaymentCoinsByUid = `-- name: GetCreditedPaymentCoinsByUid :one
+SELECT COALESCE(SUM(coins), 0)::bigint AS coins
+FROM Payment
+WHERE uid = $1
+  AND status IN ('confirmed', 'refunded')
+  AND confirmed_at >= (SELECT MIN(created_at) FROM wallet_transaction WHERE uid = $1)
+`
+
+// Payments confirmed since the player's first ledger row, including ones
+// refunded since; earlier ones are part of the opening balance.
+func (q *Queries) GetCreditedPaymentCoinsByUid(ctx context.Context, uid string) (int64, error) {
+	row := q.db.QueryRow(ctx, getCreditedPaymentCoinsByUid, uid)
+	var coins int64
+	err := row.Scan(&coins)
+	return coins, err
+}
+
 const getPaymentById = `-- name: GetPaymentById :one
-SELECT id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at
+SELECT id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at, confirmed_at, invoice_id, provider_payment_id
 FROM Payment
 WHERE id = $1
 `
@@ -56,12 +104,15 @@ func (q *Queries) GetPaymentById(ctx context.Context, id string) (Payment, error
 		&i.HostedUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ConfirmedAt,
+		&i.InvoiceID,
+		&i.ProviderPaymentID,
 	)
 	return i, err
 }
 
 const getPaymentByIdWithLock = `-- name: GetPaymentByIdWithLock :one
-SELECT id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at
+SELECT id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at, confirmed_at, invoice_id, provider_payment_id
 FROM Payment
 WHERE id = $1
 FOR UPDATE
@@ -80,12 +131,15 @@ func (q *Queries) GetPaymentByIdWithLock(ctx context.Context, id string) (Paymen
 		&i.HostedUrl,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ConfirmedAt,
+		&i.InvoiceID,
+		&i.ProviderPaymentID,
 	)
 	return i, err
 }
 
 const getPaymentsByUid = `-- name: GetPaymentsByUid :many
-SELECT id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at
+SELECT id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at, confirmed_at, invoice_id, provider_payment_id
 FROM Payment
 WHERE uid = $1
 ORDER BY created_at DESC
@@ -110,6 +164,95 @@ func (q *Queries) GetPaymentsByUid(ctx context.Context, uid string) ([]Payment,
 			&i.HostedUrl,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ConfirmedAt,
+			&i.InvoiceID,
+			&i.ProviderPaymentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPaymentRefundsByUid = `-- name: ListPaymentRefundsByUid :many
+SELECT payment_id, uid, coins, coins_clawed_back, debt_added, provider_status, created_at
+FROM payment_refund
+WHERE uid = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListPaymentRefundsByUid(ctx context.Context, uid string) ([]PaymentRefund, error) {
+	rows, err := q.db.Query(ctx, listPaymentRefundsByUid, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PaymentRefund{}
+	for rows.Next() {
+		var i PaymentRefund
+		if err := rows.Scan(
+			&i.PaymentID,
+			&i.Uid,
+			&i.Coins,
+			&i.CoinsClawedBack,
+			&i.DebtAdded,
+			&i.ProviderStatus,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStalePaymentsAfterId = `-- name: ListStalePaymentsAfterId :many
+SELECT id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at, confirmed_at, invoice_id, provider_payment_id
+FROM Payment
+WHERE status IN ('created', 'pending')
+  AND created_at < $1::timestamptz
+  AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListStalePaymentsAfterIdParams struct {
+	CreatedBefore time.Time `json:"created_before"`
+	AfterID       string    `json:"after_id"`
+	PageSize      int32     `json:"page_size"`
+}
+
+// Payments still waiting for the provider that were created before
+// @created_before, paged in id order.
+func (q *Queries) ListStalePaymentsAfterId(ctx context.Context, arg ListStalePaymentsAfterIdParams) ([]Payment, error) {
+	rows, err := q.db.Query(ctx, listStalePaymentsAfterId, arg.CreatedBefore, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Payment{}
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.PackageID,
+			&i.Coins,
+			&i.AmountCents,
+			&i.Status,
+			&i.HostedUrl,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ConfirmedAt,
+			&i.InvoiceID,
+			&i.ProviderPaymentID,
 		); err != nil {
 			return nil, err
 		}
@@ -121,19 +264,41 @@ func (q *Queries) GetPaymentsByUid(ctx context.Context, uid string) ([]Payment,
 	return items, nil
 }
 
-const updatePaymentStatusIfNotConfirmed = `-- name: UpdatePaymentStatusIfNotConfirmed :execrows
+const updatePaymentProviderPaymentId = `-- name: UpdatePaymentProviderPaymentId :exec
+UPDATE Payment
+SET provider_payment_id = $2
+WHERE id = $1 AND status IN ('created', 'pending')
+`
+
+type UpdatePaymentProviderPaymentIdParams struct {
+	ID                string      `json:"id"`
+	ProviderPaymentID pgtype.Text `json:"provider_payment_id"`
+}
+
+// Records the provider's payment only while the payment is still waiting for
+// it, so a settled payment keeps the id it was settled with.
+func (q *Queries) UpdatePaymentProviderPaymentId(ctx context.Context, arg UpdatePaymentProviderPaymentIdParams) error {
+	_, err := q.db.Exec(ctx, updatePaymentProviderPaymentId, arg.ID, arg.ProviderPaymentID)
+	return err
+}
+
+const updatePaymentStatus = `-- name: UpdatePaymentStatus :execrows
 UPDATE Payment
-SET status = $2, updated_at = NOW()
-WHERE id = $1 AND status != 'confirmed'
+SET status = $1,
+    updated_at = NOW(),
+    confirmed_at = CASE WHEN $1::text = 'confirmed' THEN NOW() ELSE confirmed_at END
+WHERE id = $2 AND status = ANY($3::text[])
 `
 
-type UpdatePaymentStatusIfNotConfirmedParams struct {
-	ID     string `json:"id"`
-	Status string `json:"status"`
+type UpdatePaymentStatusParams struct {
+	Status       string   `json:"status"`
+	ID           string   `json:"id"`
+	FromStatuses []string `json:"from_statuses"`
 }
 
-func (q *Queries) UpdatePaymentStatusIfNotConfirmed(ctx context.Context, arg UpdatePaymentStatusIfNotConfirmedParams) (int64, error) {
-	result, err := q.db.Exec(ctx, updatePaymentStatusIfNotConfirmed, arg.ID, arg.Status)
+// Moves the payment to @status, but only from one of @from_statuses.
+func (q *Queries) UpdatePaymentStatus(ctx context.Context, arg UpdatePaymentStatusParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updatePaymentStatus, arg.Status, arg.ID, arg.FromStatuses)
 	if err != nil {
 		return 0, err
 	}
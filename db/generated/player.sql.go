@@ -33,6 +33,22 @@ func (q *Queries) CreatePlayer(ctx context.Context, arg CreatePlayerParams) erro
 	return err
 }
 
+const getPlayerByEmail = `-- name: GetPlayerByEmail :one
+SELECT uid, name, email, profile_pic FROM Player WHERE email = $1
+`
+
+func (q *Queries) GetPlayerByEmail(ctx context.Context, email string) (Player, error) {
+	row := q.db.QueryRow(ctx, getPlayerByEmail, email)
+	var i Player
+	err := row.Scan(
+		&i.Uid,
+		&i.Name,
+		&i.Email,
+		&i.ProfilePic,
+	)
+	return i, err
+}
+
 const getPlayerById = `-- name: GetPlayerById :one
 SELECT uid, name, email, profile_pic FROM Player WHERE uid = $1
 `
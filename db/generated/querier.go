@@ -9,29 +9,75 @@ import (
 )
 
 type Querier interface {
+	CreateConfigHistory(ctx context.Context, arg CreateConfigHistoryParams) error
 	CreateInitialSessionState(ctx context.Context, arg CreateInitialSessionStateParams) error
 	CreatePayment(ctx context.Context, arg CreatePaymentParams) error
+	CreatePaymentRefund(ctx context.Context, arg CreatePaymentRefundParams) error
 	CreatePlayer(ctx context.Context, arg CreatePlayerParams) error
 	CreateSession(ctx context.Context, arg CreateSessionParams) error
 	CreateWallet(ctx context.Context, arg CreateWalletParams) error
+	CreateWalletAdjustment(ctx context.Context, arg CreateWalletAdjustmentParams) (int64, error)
+	CreateWalletMismatch(ctx context.Context, arg CreateWalletMismatchParams) error
+	CreateWalletReconcileRun(ctx context.Context, arg CreateWalletReconcileRunParams) error
+	DeleteWalletMismatches(ctx context.Context) error
+	DeleteWalletMismatchesByUid(ctx context.Context, uid string) error
+	GetConfigByKeyWithLock(ctx context.Context, key string) (Config, error)
+	GetConfigHistoryByKey(ctx context.Context, key string) ([]Confighistory, error)
+	// The newest value of @key whose own "version" field is @value_version, for
+	// configs such as ai_strategies that label each value they hold.
+	GetConfigHistoryValueByValueVersion(ctx context.Context, arg GetConfigHistoryValueByValueVersionParams) ([]byte, error)
+	GetConfigHistoryVersion(ctx context.Context, arg GetConfigHistoryVersionParams) (Confighistory, error)
 	GetConfigValueByKey(ctx context.Context, key string) ([]byte, error)
+	// Payments confirmed since the player's first ledger row, including ones
+	// refunded since; earlier ones are part of the opening balance.
+	GetCreditedPaymentCoinsByUid(ctx context.Context, uid string) (int64, error)
+	GetLatestConfigVersion(ctx context.Context, key string) (int32, error)
 	GetLatestSessionStateByPlayerId(ctx context.Context, uid string) (GetLatestSessionStateByPlayerIdRow, error)
 	GetLatestSessionStateByPlayerIdWithLock(ctx context.Context, uid string) (GetLatestSessionStateByPlayerIdWithLockRow, error)
+	GetLatestWalletReconcileRun(ctx context.Context) (WalletReconcileRun, error)
 	GetPaymentById(ctx context.Context, id string) (Payment, error)
 	GetPaymentByIdWithLock(ctx context.Context, id string) (Payment, error)
 	GetPaymentsByUid(ctx context.Context, uid string) ([]Payment, error)
+	GetPlayerByEmail(ctx context.Context, email string) (Player, error)
 	GetPlayerById(ctx context.Context, uid string) (Player, error)
+	GetPlayerRole(ctx context.Context, uid string) (string, error)
+	GetSessionStateBySessionId(ctx context.Context, sessionID string) (GetSessionStateBySessionIdRow, error)
+	GetSessionsByPlayerId(ctx context.Context, arg GetSessionsByPlayerIdParams) ([]Session, error)
 	GetWalletByPlayerId(ctx context.Context, uid string) (Wallet, error)
 	GetWalletByPlayerIdWithLock(ctx context.Context, uid string) (Wallet, error)
+	GetWalletTransactionTotals(ctx context.Context, uid string) (GetWalletTransactionTotalsRow, error)
+	ListConfigs(ctx context.Context) ([]Config, error)
+	// Finished sessions started since the player's first ledger row, with the
+	// game_reward amounts the ledger recorded for each.
+	ListFinishedSessionRewardsByPlayerId(ctx context.Context, uid string) ([]ListFinishedSessionRewardsByPlayerIdRow, error)
+	ListPaymentRefundsByUid(ctx context.Context, uid string) ([]PaymentRefund, error)
+	// Payments still waiting for the provider that were created before
+	// @created_before, paged in id order.
+	ListStalePaymentsAfterId(ctx context.Context, arg ListStalePaymentsAfterIdParams) ([]Payment, error)
+	ListWalletMismatches(ctx context.Context) ([]WalletMismatch, error)
+	ListWalletTransactions(ctx context.Context, arg ListWalletTransactionsParams) ([]WalletTransaction, error)
+	ListWalletsAfterUid(ctx context.Context, arg ListWalletsAfterUidParams) ([]Wallet, error)
+	// Subscribes the connection to the config_changed channel, which the
+	// configs_notify_changed trigger notifies with the key of the row written.
+	ListenConfigChanged(ctx context.Context) error
 	QuitGameSession(ctx context.Context, sessionID string) error
-	UpdatePaymentStatusIfNotConfirmed(ctx context.Context, arg UpdatePaymentStatusIfNotConfirmedParams) (int64, error)
+	// Takes back coins credited by a refunded payment. Whatever the wallet
+	// cannot cover is added to its debt.
+	RefundWalletCoins(ctx context.Context, arg RefundWalletCoinsParams) error
+	// Records the provider's payment only while the payment is still waiting for
+	// it, so a settled payment keeps the id it was settled with.
+	UpdatePaymentProviderPaymentId(ctx context.Context, arg UpdatePaymentProviderPaymentIdParams) error
+	// Moves the payment to @status, but only from one of @from_statuses.
+	UpdatePaymentStatus(ctx context.Context, arg UpdatePaymentStatusParams) (int64, error)
 	UpdatePlayerName(ctx context.Context, arg UpdatePlayerNameParams) (Player, error)
 	UpdateSessionAfterGameover(ctx context.Context, arg UpdateSessionAfterGameoverParams) error
 	UpdateSessionAfterQuitGame(ctx context.Context, sessionID string) error
 	UpdateSessionState(ctx context.Context, arg UpdateSessionStateParams) error
+	// Coins credited to a wallet in debt repay the debt first.
 	UpdateWalletCoinsAndXpReward(ctx context.Context, arg UpdateWalletCoinsAndXpRewardParams) error
 	UpdateWalletReduceCoins(ctx context.Context, arg UpdateWalletReduceCoinsParams) error
 	UpdateWalletXpReward(ctx context.Context, arg UpdateWalletXpRewardParams) error
+	UpsertConfig(ctx context.Context, arg UpsertConfigParams) error
 }
 
 var _ Querier = (*Queries)(nil)
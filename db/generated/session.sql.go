@@ -12,16 +12,18 @@ import (
 )
 
 const createSession = `-- name: CreateSession :exec
-INSERT INTO session (session_id, uid, created_at, gameover, winner, board_size, number_of_boards, difficulty)
-VALUES ($1, $2, now(), false, NULL, $3, $4, $5)
+INSERT INTO session (session_id, uid, created_at, gameover, winner, board_size, number_of_boards, difficulty, strategy_version, seed)
+VALUES ($1, $2, now(), false, NULL, $3, $4, $5, $6, $7)
 `
 
 type CreateSessionParams struct {
-	SessionID      string      `json:"session_id"`
-	Uid            string      `json:"uid"`
-	BoardSize      pgtype.Int4 `json:"board_size"`
-	NumberOfBoards pgtype.Int4 `json:"number_of_boards"`
-	Difficulty     pgtype.Int4 `json:"difficulty"`
+	SessionID       string      `json:"session_id"`
+	Uid             string      `json:"uid"`
+	BoardSize       pgtype.Int4 `json:"board_size"`
+	NumberOfBoards  pgtype.Int4 `json:"number_of_boards"`
+	Difficulty      pgtype.Int4 `json:"difficulty"`
+	StrategyVersion pgtype.Text `json:"strategy_version"`
+	Seed            int64       `json:"seed"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) error {
@@ -31,6 +33,8 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) er
 		arg.BoardSize,
 		arg.NumberOfBoards,
 		arg.Difficulty,
+		arg.StrategyVersion,
+		arg.Seed,
 	)
 	return err
 }
@@ -45,6 +49,8 @@ SELECT
     s.board_size,
     s.number_of_boards,
     s.difficulty,
+    s.strategy_version,
+    s.seed,
     ss.boards,
     ss.is_ai_move
 FROM session s
@@ -56,16 +62,18 @@ LIMIT 1
 `
 
 type GetLatestSessionStateByPlayerIdRow struct {
-	SessionID      string           `json:"session_id"`
-	Uid            string           `json:"uid"`
-	CreatedAt      pgtype.Timestamp `json:"created_at"`
-	Gameover       pgtype.Bool      `json:"gameover"`
-	Winner         pgtype.Bool      `json:"winner"`
-	BoardSize      pgtype.Int4      `json:"board_size"`
-	NumberOfBoards pgtype.Int4      `json:"number_of_boards"`
-	Difficulty     pgtype.Int4      `json:"difficulty"`
-	Boards         []int32          `json:"boards"`
-	IsAiMove       []bool           `json:"is_ai_move"`
+	SessionID       string           `json:"session_id"`
+	Uid             string           `json:"uid"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+	Gameover        pgtype.Bool      `json:"gameover"`
+	Winner          pgtype.Bool      `json:"winner"`
+	BoardSize       pgtype.Int4      `json:"board_size"`
+	NumberOfBoards  pgtype.Int4      `json:"number_of_boards"`
+	Difficulty      pgtype.Int4      `json:"difficulty"`
+	StrategyVersion pgtype.Text      `json:"strategy_version"`
+	Seed            int64            `json:"seed"`
+	Boards          []int32          `json:"boards"`
+	IsAiMove        []bool           `json:"is_ai_move"`
 }
 
 func (q *Queries) GetLatestSessionStateByPlayerId(ctx context.Context, uid string) (GetLatestSessionStateByPlayerIdRow, error) {
@@ -80,6 +88,8 @@ func (q *Queries) GetLatestSessionStateByPlayerId(ctx context.Context, uid strin
 		&i.BoardSize,
 		&i.NumberOfBoards,
 		&i.Difficulty,
+		&i.StrategyVersion,
+		&i.Seed,
 		&i.Boards,
 		&i.IsAiMove,
 	)
@@ -96,6 +106,8 @@ SELECT
     s.board_size,
     s.number_of_boards,
     s.difficulty,
+    s.strategy_version,
+    s.seed,
     ss.boards,
     ss.is_ai_move
 FROM session s
@@ -108,16 +120,18 @@ FOR UPDATE OF s,ss
 `
 
 type GetLatestSessionStateByPlayerIdWithLockRow struct {
-	SessionID      string           `json:"session_id"`
-	Uid            string           `json:"uid"`
-	CreatedAt      pgtype.Timestamp `json:"created_at"`
-	Gameover       pgtype.Bool      `json:"gameover"`
-	Winner         pgtype.Bool      `json:"winner"`
-	BoardSize      pgtype.Int4      `json:"board_size"`
-	NumberOfBoards pgtype.Int4      `json:"number_of_boards"`
-	Difficulty     pgtype.Int4      `json:"difficulty"`
-	Boards         []int32          `json:"boards"`
-	IsAiMove       []bool           `json:"is_ai_move"`
+	SessionID       string           `json:"session_id"`
+	Uid             string           `json:"uid"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+	Gameover        pgtype.Bool      `json:"gameover"`
+	Winner          pgtype.Bool      `json:"winner"`
+	BoardSize       pgtype.Int4      `json:"board_size"`
+	NumberOfBoards  pgtype.Int4      `json:"number_of_boards"`
+	Difficulty      pgtype.Int4      `json:"difficulty"`
+	StrategyVersion pgtype.Text      `json:"strategy_version"`
+	Seed            int64            `json:"seed"`
+	Boards          []int32          `json:"boards"`
+	IsAiMove        []bool           `json:"is_ai_move"`
 }
 
 func (q *Queries) GetLatestSessionStateByPlayerIdWithLock(ctx context.Context, uid string) (GetLatestSessionStateByPlayerIdWithLockRow, error) {
@@ -132,12 +146,182 @@ func (q *Queries) GetLatestSessionStateByPlayerIdWithLock(ctx context.Context, u
 		&i.BoardSize,
 		&i.NumberOfBoards,
 		&i.Difficulty,
+		&i.StrategyVersion,
+		&i.Seed,
 		&i.Boards,
 		&i.IsAiMove,
 	)
 	return i, err
 }
 
+const getSessionStateBySessionId = `-- name: GetSessionStateBySessionId :one
+SELECT
+    s.session_id,
+    s.uid,
+    s.created_at,
+    s.gameover,
+    s.winner,
+    s.board_size,
+    s.number_of_boards,
+    s.difficulty,
+    s.strategy_version,
+    s.seed,
+    ss.boards,
+    ss.is_ai_move
+FROM session s
+JOIN sessionstate ss
+    ON s.session_id = ss.session_id
+WHERE s.session_id = $1
+`
+
+type GetSessionStateBySessionIdRow struct {
+	SessionID       string           `json:"session_id"`
+	Uid             string           `json:"uid"`
+	CreatedAt       pgtype.Timestamp `json:"created_at"`
+	Gameover        pgtype.Bool      `json:"gameover"`
+	Winner          pgtype.Bool      `json:"winner"`
+	BoardSize       pgtype.Int4      `json:"board_size"`
+	NumberOfBoards  pgtype.Int4      `json:"number_of_boards"`
+	Difficulty      pgtype.Int4      `json:"difficulty"`
+	StrategyVersion pgtype.Text      `json:"strategy_version"`
+	Seed            int64            `json:"seed"`
+	Boards          []int32          `json:"boards"`
+	IsAiMove        []bool           `json:"is_ai_move"`
+}
+
+func (q *Queries) GetSessionStateBySessionId(ctx context.Context, sessionID string) (GetSessionStateBySessionIdRow, error) {
+	row := q.db.QueryRow(ctx, getSessionStateBySessionId, sessionID)
+	var i GetSessionStateBySessionIdRow
+	err := row.Scan(
+		&i.SessionID,
+		&i.Uid,
+		&i.CreatedAt,
+		&i.Gameover,
+		&i.Winner,
+		&i.BoardSize,
+		&i.NumberOfBoards,
+		&i.Difficulty,
+		&i.StrategyVersion,
+		&i.Seed,
+		&i.Boards,
+		&i.IsAiMove,
+	)
+	return i, err
+}
+
+const getSessionsByPlayerId = `-- name: GetSessionsByPlayerId :many
+SELECT session_id, uid, created_at, gameover, winner, board_size, number_of_boards, difficulty, strategy_version, seed
+FROM session
+WHERE uid = $1
+ORDER BY created_at DESC
+LIMIT $2
+`
+
+type GetSessionsByPlayerIdParams struct {
+	Uid   string `json:"uid"`
+	Limit int32  `json:"limit"`
+}
+
+func (q *Queries) GetSessionsByPlayerId(ctx context.Context, arg GetSessionsByPlayerIdParams) ([]Session, error) {
+	rows, err := q.db.Query(ctx, getSessionsByPlayerId, arg.Uid, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.Uid,
+			&i.CreatedAt,
+			&i.Gameover,
+			&i.Winner,
+			&i.BoardSize,
+			&i.NumberOfBoards,
+			&i.Difficulty,
+			&i.StrategyVersion,
+			&i.Seed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFinishedSessionRewardsByPlayerId = `-- name: ListFinishedSessionRewardsByPlayerId :many
+SELECT
+    s.session_id,
+    s.board_size,
+    s.number_of_boards,
+    s.difficulty,
+    s.seed,
+    ss.boards,
+    ss.is_ai_move,
+    COALESCE(r.coins, 0)::bigint AS coins_rewarded,
+    COALESCE(r.xp, 0)::bigint AS xp_rewarded
+FROM session s
+JOIN sessionstate ss
+    ON s.session_id = ss.session_id
+LEFT JOIN (
+    SELECT reference_id, SUM(coins_delta) AS coins, SUM(xp_delta) AS xp
+    FROM wallet_transaction
+    WHERE uid = $1 AND reason = 'game_reward'
+    GROUP BY reference_id
+) r ON r.reference_id = s.session_id
+WHERE s.uid = $1
+  AND s.gameover = true
+  AND s.created_at >= (SELECT MIN(created_at) FROM wallet_transaction WHERE uid = $1)
+`
+
+type ListFinishedSessionRewardsByPlayerIdRow struct {
+	SessionID      string      `json:"session_id"`
+	BoardSize      pgtype.Int4 `json:"board_size"`
+	NumberOfBoards pgtype.Int4 `json:"number_of_boards"`
+	Difficulty     pgtype.Int4 `json:"difficulty"`
+	Seed           int64       `json:"seed"`
+	Boards         []int32     `json:"boards"`
+	IsAiMove       []bool      `json:"is_ai_move"`
+	CoinsRewarded  int64       `json:"coins_rewarded"`
+	XpRewarded     int64       `json:"xp_rewarded"`
+}
+
+// Finished sessions started since the player's first ledger row, with the
+// game_reward amounts the ledger recorded for each.
+func (q *Queries) ListFinishedSessionRewardsByPlayerId(ctx context.Context, uid string) ([]ListFinishedSessionRewardsByPlayerIdRow, error) {
+	rows, err := q.db.Query(ctx, listFinishedSessionRewardsByPlayerId, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFinishedSessionRewardsByPlayerIdRow{}
+	for rows.Next() {
+		var i ListFinishedSessionRewardsByPlayerIdRow
+		if err := rows.Scan(
+			&i.SessionID,
+			&i.BoardSize,
+			&i.NumberOfBoards,
+			&i.Difficulty,
+			&i.Seed,
+			&i.Boards,
+			&i.IsAiMove,
+			&i.CoinsRewarded,
+			&i.XpRewarded,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const quitGameSession = `-- name: QuitGameSession :exec
 UPDATE session
 SET gameover = true,
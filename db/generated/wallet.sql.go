@@ -7,19 +7,23 @@ package db
 
 import (
 	"context"
-
-	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createWallet = `-- name: CreateWallet :exec
-INSERT INTO wallet ( uid, coins, xp)
-VALUES ($1, $2, $3)
+WITH created AS (
+    INSERT INTO wallet ( uid, coins, xp)
+    VALUES ($1, $2, $3)
+    RETURNING uid, coins, xp
+)
+INSERT INTO wallet_transaction (uid, reason, coins_delta, xp_delta, coins_after, xp_after)
+SELECT uid, 'sign_up', coins, xp, coins, xp
+FROM created
 `
 
 type CreateWalletParams struct {
-	Uid   string      `json:"uid"`
-	Coins pgtype.Int4 `json:"coins"`
-	Xp    pgtype.Int4 `json:"xp"`
+	Uid   string `json:"uid"`
+	Coins int64  `json:"coins"`
+	Xp    int64  `json:"xp"`
 }
 
 func (q *Queries) CreateWallet(ctx context.Context, arg CreateWalletParams) error {
@@ -27,11 +31,39 @@ func (q *Queries) CreateWallet(ctx context.Context, arg CreateWalletParams) erro
 	return err
 }
 
+const createWalletAdjustment = `-- name: CreateWalletAdjustment :one
+INSERT INTO WalletAdjustment (uid, admin_uid, coins, xp, reason)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id
+`
+
+type CreateWalletAdjustmentParams struct {
+	Uid      string `json:"uid"`
+	AdminUid string `json:"admin_uid"`
+	Coins    int64  `json:"coins"`
+	Xp       int64  `json:"xp"`
+	Reason   string `json:"reason"`
+}
+
+func (q *Queries) CreateWalletAdjustment(ctx context.Context, arg CreateWalletAdjustmentParams) (int64, error) {
+	row := q.db.QueryRow(ctx, createWalletAdjustment,
+		arg.Uid,
+		arg.AdminUid,
+		arg.Coins,
+		arg.Xp,
+		arg.Reason,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
 const getWalletByPlayerId = `-- name: GetWalletByPlayerId :one
 SELECT
     uid,
     coins,
-    xp
+    xp,
+    debt
 FROM wallet
 WHERE uid = $1
 `
@@ -39,7 +71,12 @@ WHERE uid = $1
 func (q *Queries) GetWalletByPlayerId(ctx context.Context, uid string) (Wallet, error) {
 	row := q.db.QueryRow(ctx, getWalletByPlayerId, uid)
 	var i Wallet
-	err := row.Scan(&i.Uid, &i.Coins, &i.Xp)
+	err := row.Scan(
+		&i.Uid,
+		&i.Coins,
+		&i.Xp,
+		&i.Debt,
+	)
 	return i, err
 }
 
@@ -47,7 +84,8 @@ const getWalletByPlayerIdWithLock = `-- name: GetWalletByPlayerIdWithLock :one
 SELECT
     uid,
     coins,
-    xp
+    xp,
+    debt
 FROM wallet
 WHERE uid = $1
 FOR UPDATE
@@ -56,56 +94,262 @@ FOR UPDATE
 func (q *Queries) GetWalletByPlayerIdWithLock(ctx context.Context, uid string) (Wallet, error) {
 	row := q.db.QueryRow(ctx, getWalletByPlayerIdWithLock, uid)
 	var i Wallet
-	err := row.Scan(&i.Uid, &i.Coins, &i.Xp)
+	err := row.Scan(
+		&i.Uid,
+		&i.Coins,
+		&i.Xp,
+		&i.Debt,
+	)
 	return i, err
 }
 
-const updateWalletCoinsAndXpReward = `-- name: UpdateWalletCoinsAndXpReward :exec
-UPDATE wallet
-SET coins = coins+$2,
-    xp = xp+$3
+const getWalletTransactionTotals = `-- name: GetWalletTransactionTotals :one
+SELECT
+    COALESCE(SUM(coins_delta), 0)::bigint AS coins,
+    COALESCE(SUM(xp_delta), 0)::bigint AS xp,
+    COALESCE(SUM(coins_delta) FILTER (WHERE reason = 'purchase'), 0)::bigint AS purchase_coins
+FROM wallet_transaction
 WHERE uid = $1
 `
 
+type GetWalletTransactionTotalsRow struct {
+	Coins         int64 `json:"coins"`
+	Xp            int64 `json:"xp"`
+	PurchaseCoins int64 `json:"purchase_coins"`
+}
+
+func (q *Queries) GetWalletTransactionTotals(ctx context.Context, uid string) (GetWalletTransactionTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getWalletTransactionTotals, uid)
+	var i GetWalletTransactionTotalsRow
+	err := row.Scan(&i.Coins, &i.Xp, &i.PurchaseCoins)
+	return i, err
+}
+
+const listWalletTransactions = `-- name: ListWalletTransactions :many
+SELECT
+    id,
+    uid,
+    reason,
+    coins_delta,
+    xp_delta,
+    coins_after,
+    xp_after,
+    reference_id,
+    created_at,
+    debt_after
+FROM wallet_transaction
+WHERE uid = $1
+  AND ($2::bigint = 0 OR id < $2::bigint)
+  AND (cardinality($3::text[]) = 0 OR reason::text = ANY($3::text[]))
+ORDER BY id DESC
+LIMIT $4
+`
+
+type ListWalletTransactionsParams struct {
+	Uid      string   `json:"uid"`
+	BeforeID int64    `json:"before_id"`
+	Reasons  []string `json:"reasons"`
+	PageSize int32    `json:"page_size"`
+}
+
+func (q *Queries) ListWalletTransactions(ctx context.Context, arg ListWalletTransactionsParams) ([]WalletTransaction, error) {
+	rows, err := q.db.Query(ctx, listWalletTransactions,
+		arg.Uid,
+		arg.BeforeID,
+		arg.Reasons,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WalletTransaction{}
+	for rows.Next() {
+		var i WalletTransaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uid,
+			&i.Reason,
+			&i.CoinsDelta,
+			&i.XpDelta,
+			&i.CoinsAfter,
+			&i.XpAfter,
+			&i.ReferenceID,
+			&i.CreatedAt,
+			&i.DebtAfter,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWalletsAfterUid = `-- name: ListWalletsAfterUid :many
+SELECT
+    uid,
+    coins,
+    xp,
+    debt
+FROM wallet
+WHERE uid > $1
+ORDER BY uid
+LIMIT $2
+`
+
+type ListWalletsAfterUidParams struct {
+	Uid   string `json:"uid"`
+	Limit int32  `json:"limit"`
+}
+
+func (q *Queries) ListWalletsAfterUid(ctx context.Context, arg ListWalletsAfterUidParams) ([]Wallet, error) {
+	rows, err := q.db.Query(ctx, listWalletsAfterUid, arg.Uid, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Wallet{}
+	for rows.Next() {
+		var i Wallet
+		if err := rows.Scan(
+			&i.Uid,
+			&i.Coins,
+			&i.Xp,
+			&i.Debt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const refundWalletCoins = `-- name: RefundWalletCoins :exec
+WITH updated AS (
+    UPDATE wallet
+    SET coins = GREATEST(coins - $1::bigint, 0),
+        debt = debt + GREATEST($1::bigint - coins, 0)
+    WHERE uid = $2
+    RETURNING uid, coins, xp, debt
+)
+INSERT INTO wallet_transaction (uid, reason, coins_delta, xp_delta, coins_after, xp_after, debt_after, reference_id)
+SELECT uid, $3::wallet_transaction_reason, -$1::bigint, 0, coins, xp, debt, NULLIF($4::text, '')
+FROM updated
+`
+
+type RefundWalletCoinsParams struct {
+	Coins       int64                   `json:"coins"`
+	Uid         string                  `json:"uid"`
+	Reason      WalletTransactionReason `json:"reason"`
+	ReferenceID string                  `json:"reference_id"`
+}
+
+// Takes back coins credited by a refunded payment. Whatever the wallet
+// cannot cover is added to its debt.
+func (q *Queries) RefundWalletCoins(ctx context.Context, arg RefundWalletCoinsParams) error {
+	_, err := q.db.Exec(ctx, refundWalletCoins,
+		arg.Coins,
+		arg.Uid,
+		arg.Reason,
+		arg.ReferenceID,
+	)
+	return err
+}
+
+const updateWalletCoinsAndXpReward = `-- name: UpdateWalletCoinsAndXpReward :exec
+WITH updated AS (
+    UPDATE wallet
+    SET coins = CASE WHEN debt > 0 AND $1::bigint > 0 THEN GREATEST($1::bigint - debt, 0) ELSE coins+$1 END,
+        debt = CASE WHEN debt > 0 AND $1::bigint > 0 THEN GREATEST(debt - $1::bigint, 0) ELSE debt END,
+        xp = xp+$2
+    WHERE uid = $3
+    RETURNING uid, coins, xp, debt
+)
+INSERT INTO wallet_transaction (uid, reason, coins_delta, xp_delta, coins_after, xp_after, debt_after, reference_id)
+SELECT uid, $4::wallet_transaction_reason, $1, $2, coins, xp, debt, NULLIF($5::text, '')
+FROM updated
+`
+
 type UpdateWalletCoinsAndXpRewardParams struct {
-	Uid   string      `json:"uid"`
-	Coins pgtype.Int4 `json:"coins"`
-	Xp    pgtype.Int4 `json:"xp"`
+	Coins       int64                   `json:"coins"`
+	Xp          int64                   `json:"xp"`
+	Uid         string                  `json:"uid"`
+	Reason      WalletTransactionReason `json:"reason"`
+	ReferenceID string                  `json:"reference_id"`
 }
 
+// Coins credited to a wallet in debt repay the debt first.
 func (q *Queries) UpdateWalletCoinsAndXpReward(ctx context.Context, arg UpdateWalletCoinsAndXpRewardParams) error {
-	_, err := q.db.Exec(ctx, updateWalletCoinsAndXpReward, arg.Uid, arg.Coins, arg.Xp)
+	_, err := q.db.Exec(ctx, updateWalletCoinsAndXpReward,
+		arg.Coins,
+		arg.Xp,
+		arg.Uid,
+		arg.Reason,
+		arg.ReferenceID,
+	)
 	return err
 }
 
 const updateWalletReduceCoins = `-- name: UpdateWalletReduceCoins :exec
-UPDATE wallet
-SET coins = coins-$2
-WHERE uid = $1
+WITH updated AS (
+    UPDATE wallet
+    SET coins = coins-$1
+    WHERE uid = $2
+    RETURNING uid, coins, xp, debt
+)
+INSERT INTO wallet_transaction (uid, reason, coins_delta, xp_delta, coins_after, xp_after, debt_after, reference_id)
+SELECT uid, $3::wallet_transaction_reason, -$1::bigint, 0, coins, xp, debt, NULLIF($4::text, '')
+FROM updated
 `
 
 type UpdateWalletReduceCoinsParams struct {
-	Uid   string      `json:"uid"`
-	Coins pgtype.Int4 `json:"coins"`
+	Coins       int64                   `json:"coins"`
+	Uid         string                  `json:"uid"`
+	Reason      WalletTransactionReason `json:"reason"`
+	ReferenceID string                  `json:"reference_id"`
 }
 
 func (q *Queries) UpdateWalletReduceCoins(ctx context.Context, arg UpdateWalletReduceCoinsParams) error {
-	_, err := q.db.Exec(ctx, updateWalletReduceCoins, arg.Uid, arg.Coins)
+	_, err := q.db.Exec(ctx, updateWalletReduceCoins,
+		arg.Coins,
+		arg.Uid,
+		arg.Reason,
+		arg.ReferenceID,
+	)
 	return err
 }
 
 const updateWalletXpReward = `-- name: UpdateWalletXpReward :exec
-UPDATE wallet
-SET xp = xp+$2
-WHERE uid = $1
+WITH updated AS (
+    UPDATE wallet
+    SET xp = xp+$1
+    WHERE uid = $2
+    RETURNING uid, coins, xp, debt
+)
+INSERT INTO wallet_transaction (uid, reason, coins_delta, xp_delta, coins_after, xp_after, debt_after, reference_id)
+SELECT uid, $3::wallet_transaction_reason, 0, $1, coins, xp, debt, NULLIF($4::text, '')
+FROM updated
 `
 
 type UpdateWalletXpRewardParams struct {
-	Uid string      `json:"uid"`
-	Xp  pgtype.Int4 `json:"xp"`
+	Xp          int64                   `json:"xp"`
+	Uid         string                  `json:"uid"`
+	Reason      WalletTransactionReason `json:"reason"`
+	ReferenceID string                  `json:"reference_id"`
 }
 
 func (q *Queries) UpdateWalletXpReward(ctx context.Context, arg UpdateWalletXpRewardParams) error {
-	_, err := q.db.Exec(ctx, updateWalletXpReward, arg.Uid, arg.Xp)
+	_, err := q.db.Exec(ctx, updateWalletXpReward,
+		arg.Xp,
+		arg.Uid,
+		arg.Reason,
+		arg.ReferenceID,
+	)
 	return err
 }
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE Session ADD COLUMN strategy_version TEXT;
+
+-- +goose Down
+ALTER TABLE Session DROP COLUMN strategy_version;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE Session ADD COLUMN seed BIGINT;
+
+-- Backfill existing sessions so every row can drive a deterministic generator
+UPDATE Session
+SET seed = floor((random() * 2 - 1) * 9223372036854775807)::BIGINT
+WHERE seed IS NULL;
+
+ALTER TABLE Session ALTER COLUMN seed SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE Session DROP COLUMN seed;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Players without a row here have the default "player" role
+CREATE TABLE PlayerRole (
+    uid VARCHAR(36) PRIMARY KEY,
+    role TEXT NOT NULL CHECK (role IN ('player', 'support', 'admin')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (uid) REFERENCES Player(uid) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS PlayerRole;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Audit trail of coins and XP credited or debited by an admin
+CREATE TABLE WalletAdjustment (
+    id BIGSERIAL PRIMARY KEY,
+    uid VARCHAR(36) NOT NULL,
+    admin_uid VARCHAR(36) NOT NULL,
+    coins INTEGER NOT NULL,
+    xp INTEGER NOT NULL,
+    reason TEXT NOT NULL CHECK (length(trim(reason)) > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (uid) REFERENCES Player(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_wallet_adjustment_uid ON WalletAdjustment(uid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_wallet_adjustment_uid;
+DROP TABLE IF EXISTS WalletAdjustment;
+-- +goose StatementEnd
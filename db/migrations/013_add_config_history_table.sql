@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every value a config key has held; configs.version is the live one
+CREATE TABLE ConfigHistory (
+    key TEXT NOT NULL,
+    version INTEGER NOT NULL CHECK (version > 0),
+    value JSONB NOT NULL,
+    admin_uid VARCHAR(36),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (key, version)
+);
+
+ALTER TABLE configs ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+
+-- Existing values become version 1, with no admin to attribute them to
+INSERT INTO ConfigHistory (key, version, value, created_at)
+SELECT key, 1, value, updated_at FROM configs;
+
+UPDATE configs SET version = 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE configs DROP COLUMN version;
+DROP TABLE IF EXISTS ConfigHistory;
+-- +goose StatementEnd
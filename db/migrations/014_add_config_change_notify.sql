@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Tell every server instance which config key changed so it can reload it
+CREATE OR REPLACE FUNCTION notify_config_changed() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('config_changed', COALESCE(NEW.key, OLD.key));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER configs_notify_changed
+AFTER INSERT OR UPDATE OR DELETE ON configs
+FOR EACH ROW EXECUTE FUNCTION notify_config_changed();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS configs_notify_changed ON configs;
+DROP FUNCTION IF EXISTS notify_config_changed();
+-- +goose StatementEnd
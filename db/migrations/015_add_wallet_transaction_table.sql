@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Why a wallet balance changed; one value per code path that writes wallet
+CREATE TYPE wallet_transaction_reason AS ENUM (
+    'opening_balance',
+    'sign_up',
+    'game_reward',
+    'skip_cost',
+    'undo_cost',
+    'hint_cost',
+    'purchase',
+    'admin_adjust'
+);
+
+-- Append-only ledger of every change to wallet. reference_id is the session,
+-- payment or wallet adjustment that caused the change, when there is one.
+CREATE TABLE wallet_transaction (
+    id BIGSERIAL PRIMARY KEY,
+    uid VARCHAR(36) NOT NULL,
+    reason wallet_transaction_reason NOT NULL,
+    coins_delta INTEGER NOT NULL,
+    xp_delta INTEGER NOT NULL,
+    coins_after INTEGER NOT NULL,
+    xp_after INTEGER NOT NULL,
+    reference_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (uid) REFERENCES Player(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_wallet_transaction_uid_id ON wallet_transaction(uid, id DESC);
+
+-- Wallets that predate the ledger start from their current balance so the
+-- deltas of every player sum to the wallet.
+INSERT INTO wallet_transaction (uid, reason, coins_delta, xp_delta, coins_after, xp_after)
+SELECT uid, 'opening_balance', COALESCE(coins, 0), COALESCE(xp, 0), COALESCE(coins, 0), COALESCE(xp, 0)
+FROM wallet;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_wallet_transaction_uid_id;
+DROP TABLE IF EXISTS wallet_transaction;
+DROP TYPE IF EXISTS wallet_transaction_reason;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Negative balances cannot be converted silently without losing the ledger
+-- trail; fix them with an admin adjustment first, then rerun.
+DO $$
+DECLARE
+    negative BIGINT;
+BEGIN
+    SELECT COUNT(*) INTO negative FROM wallet WHERE coins < 0 OR xp < 0;
+    IF negative > 0 THEN
+        RAISE EXCEPTION '% wallet(s) have a negative balance', negative;
+    END IF;
+END
+$$;
+
+UPDATE wallet SET coins = 0 WHERE coins IS NULL;
+UPDATE wallet SET xp = 0 WHERE xp IS NULL;
+
+ALTER TABLE wallet
+    ALTER COLUMN coins TYPE BIGINT,
+    ALTER COLUMN coins SET NOT NULL,
+    ALTER COLUMN xp TYPE BIGINT,
+    ALTER COLUMN xp SET NOT NULL,
+    ADD CONSTRAINT wallet_coins_non_negative CHECK (coins >= 0),
+    ADD CONSTRAINT wallet_xp_non_negative CHECK (xp >= 0);
+
+ALTER TABLE wallet_transaction
+    ALTER COLUMN coins_delta TYPE BIGINT,
+    ALTER COLUMN xp_delta TYPE BIGINT,
+    ALTER COLUMN coins_after TYPE BIGINT,
+    ALTER COLUMN xp_after TYPE BIGINT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE wallet_transaction
+    ALTER COLUMN coins_delta TYPE INTEGER,
+    ALTER COLUMN xp_delta TYPE INTEGER,
+    ALTER COLUMN coins_after TYPE INTEGER,
+    ALTER COLUMN xp_after TYPE INTEGER;
+
+ALTER TABLE wallet
+    DROP CONSTRAINT IF EXISTS wallet_coins_non_negative,
+    DROP CONSTRAINT IF EXISTS wallet_xp_non_negative,
+    ALTER COLUMN coins DROP NOT NULL,
+    ALTER COLUMN coins TYPE INTEGER,
+    ALTER COLUMN xp DROP NOT NULL,
+    ALTER COLUMN xp TYPE INTEGER;
+-- +goose StatementEnd
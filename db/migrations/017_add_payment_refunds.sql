@@ -0,0 +1,61 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Nothing below uses the new value; Postgres forbids that in the
+-- transaction that adds it.
+ALTER TYPE wallet_transaction_reason ADD VALUE IF NOT EXISTS 'refund';
+
+-- A refund takes back coins the player may already have spent. What the
+-- wallet cannot cover becomes debt, repaid by later credits; coins stay
+-- non-negative, so debt and coins are never both non-zero.
+ALTER TABLE wallet
+    ADD COLUMN debt BIGINT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT wallet_debt_non_negative CHECK (debt >= 0),
+    ADD CONSTRAINT wallet_debt_without_coins CHECK (debt = 0 OR coins = 0);
+
+ALTER TABLE wallet_transaction
+    ADD COLUMN debt_after BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE Payment
+    ADD COLUMN confirmed_at TIMESTAMPTZ;
+
+UPDATE Payment SET confirmed_at = updated_at WHERE status = 'confirmed';
+
+ALTER TABLE Payment
+    ADD CONSTRAINT payment_status_valid
+        CHECK (status IN ('created', 'pending', 'confirmed', 'failed', 'refunded'));
+
+CREATE TABLE payment_refund (
+    payment_id TEXT PRIMARY KEY,
+    uid VARCHAR(36) NOT NULL,
+    coins INTEGER NOT NULL,
+    coins_clawed_back BIGINT NOT NULL CHECK (coins_clawed_back >= 0),
+    debt_added BIGINT NOT NULL CHECK (debt_added >= 0),
+    provider_status TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (payment_id) REFERENCES Payment(id) ON DELETE CASCADE,
+    FOREIGN KEY (uid) REFERENCES Player(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_payment_refund_uid ON payment_refund(uid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_payment_refund_uid;
+DROP TABLE IF EXISTS payment_refund;
+
+ALTER TABLE Payment
+    DROP CONSTRAINT IF EXISTS payment_status_valid,
+    DROP COLUMN IF EXISTS confirmed_at;
+
+ALTER TABLE wallet_transaction
+    DROP COLUMN IF EXISTS debt_after;
+
+ALTER TABLE wallet
+    DROP CONSTRAINT IF EXISTS wallet_debt_without_coins,
+    DROP CONSTRAINT IF EXISTS wallet_debt_non_negative,
+    DROP COLUMN IF EXISTS debt;
+
+-- Postgres cannot drop an enum value; 'refund' stays in
+-- wallet_transaction_reason, unused.
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+-- invoice_id is set when the charge is created; provider_payment_id once an
+-- IPN names the NOWPayments payment made against the invoice. Together they
+-- let a poller ask NOWPayments about charges whose IPNs never arrived.
+ALTER TABLE Payment
+    ADD COLUMN invoice_id TEXT,
+    ADD COLUMN provider_payment_id TEXT;
+
+ALTER TABLE Payment
+    DROP CONSTRAINT payment_status_valid,
+    ADD CONSTRAINT payment_status_valid
+        CHECK (status IN ('created', 'pending', 'confirmed', 'failed', 'expired', 'refunded'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE Payment SET status = 'failed' WHERE status = 'expired';
+
+ALTER TABLE Payment
+    DROP CONSTRAINT payment_status_valid,
+    ADD CONSTRAINT payment_status_valid
+        CHECK (status IN ('created', 'pending', 'confirmed', 'failed', 'refunded'));
+
+ALTER TABLE Payment
+    DROP COLUMN IF EXISTS provider_payment_id,
+    DROP COLUMN IF EXISTS invoice_id;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Adjustments move the same BIGINT balances as the wallet they change.
+ALTER TABLE WalletAdjustment
+    ALTER COLUMN coins TYPE BIGINT,
+    ALTER COLUMN xp TYPE BIGINT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DO $$
+DECLARE
+    wide BIGINT;
+BEGIN
+    SELECT COUNT(*) INTO wide FROM WalletAdjustment
+    WHERE coins NOT BETWEEN -2147483648 AND 2147483647
+       OR xp NOT BETWEEN -2147483648 AND 2147483647;
+    IF wide > 0 THEN
+        RAISE EXCEPTION '% wallet adjustment(s) do not fit in INTEGER', wide;
+    END IF;
+END
+$$;
+
+ALTER TABLE WalletAdjustment
+    ALTER COLUMN coins TYPE INTEGER,
+    ALTER COLUMN xp TYPE INTEGER;
+-- +goose StatementEnd
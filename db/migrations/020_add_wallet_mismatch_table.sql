@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- What the wallet reconciler found, shared by every instance. A full run
+-- replaces all of wallet_mismatch; a single-wallet check replaces that
+-- wallet's rows.
+CREATE TABLE wallet_reconcile_run (
+    id BIGSERIAL PRIMARY KEY,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    wallets INTEGER NOT NULL,
+    mismatched INTEGER NOT NULL,
+    failed INTEGER NOT NULL
+);
+
+CREATE TABLE wallet_mismatch (
+    id BIGSERIAL PRIMARY KEY,
+    uid VARCHAR(36) NOT NULL,
+    check_name TEXT NOT NULL,
+    reference TEXT NOT NULL DEFAULT '',
+    expected_coins BIGINT NOT NULL,
+    actual_coins BIGINT NOT NULL,
+    expected_xp BIGINT NOT NULL,
+    actual_xp BIGINT NOT NULL,
+    detected_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (uid) REFERENCES Player(uid) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_wallet_mismatch_uid ON wallet_mismatch(uid);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_wallet_mismatch_uid;
+DROP TABLE IF EXISTS wallet_mismatch;
+DROP TABLE IF EXISTS wallet_reconcile_run;
+-- +goose StatementEnd
@@ -2,3 +2,57 @@
 SELECT value
 FROM configs
 WHERE key = $1;
+
+
+-- name: ListConfigs :many
+SELECT *
+FROM configs
+ORDER BY key;
+
+-- name: ListenConfigChanged :exec
+-- Subscribes the connection to the config_changed channel, which the
+-- configs_notify_changed trigger notifies with the key of the row written.
+LISTEN config_changed;
+
+-- name: GetConfigByKeyWithLock :one
+SELECT *
+FROM configs
+WHERE key = $1
+FOR UPDATE;
+
+-- name: UpsertConfig :exec
+INSERT INTO configs (key, value, version, created_at, updated_at)
+VALUES (@key, CAST(@value::text AS JSONB), @version, NOW(), NOW())
+ON CONFLICT (key) DO UPDATE
+SET value = EXCLUDED.value,
+    version = EXCLUDED.version,
+    updated_at = NOW();
+
+-- name: GetLatestConfigVersion :one
+SELECT COALESCE(MAX(version), 0)::INTEGER AS version
+FROM ConfigHistory
+WHERE key = $1;
+
+-- name: CreateConfigHistory :exec
+INSERT INTO ConfigHistory (key, version, value, admin_uid)
+VALUES (@key, @version, CAST(@value::text AS JSONB), @admin_uid);
+
+-- name: GetConfigHistoryByKey :many
+SELECT *
+FROM ConfigHistory
+WHERE key = $1
+ORDER BY version DESC;
+
+-- name: GetConfigHistoryVersion :one
+SELECT *
+FROM ConfigHistory
+WHERE key = $1 AND version = $2;
+
+-- name: GetConfigHistoryValueByValueVersion :one
+-- The newest value of @key whose own "version" field is @value_version, for
+-- configs such as ai_strategies that label each value they hold.
+SELECT value
+FROM ConfigHistory
+WHERE key = @key AND value->>'version' = @value_version::text
+ORDER BY version DESC
+LIMIT 1;
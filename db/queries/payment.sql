@@ -1,25 +1,65 @@
 -- name: CreatePayment :exec
-INSERT INTO Payment (id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW());
+INSERT INTO Payment (id, uid, package_id, coins, amount_cents, status, hosted_url, invoice_id, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW());
 
 -- name: GetPaymentById :one
-SELECT id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at
+SELECT id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at, confirmed_at, invoice_id, provider_payment_id
 FROM Payment
 WHERE id = $1;
 
 -- name: GetPaymentByIdWithLock :one
-SELECT id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at
+SELECT id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at, confirmed_at, invoice_id, provider_payment_id
 FROM Payment
 WHERE id = $1
 FOR UPDATE;
 
--- name: UpdatePaymentStatusIfNotConfirmed :execrows
+-- name: UpdatePaymentStatus :execrows
+-- Moves the payment to @status, but only from one of @from_statuses.
 UPDATE Payment
-SET status = $2, updated_at = NOW()
-WHERE id = $1 AND status != 'confirmed';
+SET status = @status,
+    updated_at = NOW(),
+    confirmed_at = CASE WHEN @status::text = 'confirmed' THEN NOW() ELSE confirmed_at END
+WHERE id = @id AND status = ANY(@from_statuses::text[]);
+
+-- name: UpdatePaymentProviderPaymentId :exec
+-- Records the provider's payment only while the payment is still waiting for
+-- it, so a settled payment keeps the id it was settled with.
+UPDATE Payment
+SET provider_payment_id = $2
+WHERE id = $1 AND status IN ('created', 'pending');
+
+-- name: ListStalePaymentsAfterId :many
+-- Payments still waiting for the provider that were created before
+-- @created_before, paged in id order.
+SELECT id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at, confirmed_at, invoice_id, provider_payment_id
+FROM Payment
+WHERE status IN ('created', 'pending')
+  AND created_at < @created_before::timestamptz
+  AND id > @after_id
+ORDER BY id
+LIMIT @page_size;
 
 -- name: GetPaymentsByUid :many
-SELECT id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at
+SELECT id, uid, package_id, coins, amount_cents, status, hosted_url, created_at, updated_at, confirmed_at, invoice_id, provider_payment_id
 FROM Payment
 WHERE uid = $1
 ORDER BY created_at DESC;
+
+-- name: GetCreditedPaymentCoinsByUid :one
+-- Payments confirmed since the player's first ledger row, including ones
+-- refunded since; earlier ones are part of the opening balance.
+SELECT COALESCE(SUM(coins), 0)::bigint AS coins
+FROM Payment
+WHERE uid = $1
+  AND status IN ('confirmed', 'refunded')
+  AND confirmed_at >= (SELECT MIN(created_at) FROM wallet_transaction WHERE uid = $1);
+
+-- name: CreatePaymentRefund :exec
+INSERT INTO payment_refund (payment_id, uid, coins, coins_clawed_back, debt_added, provider_status)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListPaymentRefundsByUid :many
+SELECT payment_id, uid, coins, coins_clawed_back, debt_added, provider_status, created_at
+FROM payment_refund
+WHERE uid = $1
+ORDER BY created_at DESC;
@@ -6,4 +6,7 @@ INSERT INTO Player (uid, email, name, profile_pic)
 VALUES ($1, $2, $3, $4);
 
 -- name: UpdatePlayerName :one
-UPDATE Player SET name = $2 WHERE uid = $1 RETURNING *;
\ No newline at end of file
+UPDATE Player SET name = $2 WHERE uid = $1 RETURNING *;
+
+-- name: GetPlayerByEmail :one
+SELECT * FROM Player WHERE email = $1;
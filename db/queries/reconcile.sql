@@ -0,0 +1,25 @@
+-- name: CreateWalletReconcileRun :exec
+INSERT INTO wallet_reconcile_run (started_at, finished_at, wallets, mismatched, failed)
+VALUES ($1::timestamptz, $2::timestamptz, $3, $4, $5);
+
+-- name: GetLatestWalletReconcileRun :one
+SELECT id, started_at, finished_at, wallets, mismatched, failed
+FROM wallet_reconcile_run
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: CreateWalletMismatch :exec
+INSERT INTO wallet_mismatch (uid, check_name, reference, expected_coins, actual_coins, expected_xp, actual_xp, detected_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8::timestamptz);
+
+-- name: DeleteWalletMismatches :exec
+DELETE FROM wallet_mismatch;
+
+-- name: DeleteWalletMismatchesByUid :exec
+DELETE FROM wallet_mismatch
+WHERE uid = $1;
+
+-- name: ListWalletMismatches :many
+SELECT id, uid, check_name, reference, expected_coins, actual_coins, expected_xp, actual_xp, detected_at
+FROM wallet_mismatch
+ORDER BY uid, id;
@@ -0,0 +1,4 @@
+-- name: GetPlayerRole :one
+SELECT role
+FROM PlayerRole
+WHERE uid = $1;
@@ -8,6 +8,8 @@ SELECT
     s.board_size,
     s.number_of_boards,
     s.difficulty,
+    s.strategy_version,
+    s.seed,
     ss.boards,
     ss.is_ai_move
 FROM session s
@@ -18,8 +20,8 @@ ORDER BY s.created_at DESC
 LIMIT 1;
 
 -- name: CreateSession :exec
-INSERT INTO session (session_id, uid, created_at, gameover, winner, board_size, number_of_boards, difficulty)
-VALUES ($1, $2, now(), false, NULL, $3, $4, $5);
+INSERT INTO session (session_id, uid, created_at, gameover, winner, board_size, number_of_boards, difficulty, strategy_version, seed)
+VALUES ($1, $2, now(), false, NULL, $3, $4, $5, $6, $7);
 
 -- name: UpdateSessionAfterGameover :exec
 UPDATE session
@@ -49,6 +51,8 @@ SELECT
     s.board_size,
     s.number_of_boards,
     s.difficulty,
+    s.strategy_version,
+    s.seed,
     ss.boards,
     ss.is_ai_move
 FROM session s
@@ -57,4 +61,56 @@ JOIN sessionstate ss
 WHERE s.uid = $1
 ORDER BY s.created_at DESC
 LIMIT 1
-FOR UPDATE OF s,ss;
\ No newline at end of file
+FOR UPDATE OF s,ss;
+
+-- name: GetSessionStateBySessionId :one
+SELECT
+    s.session_id,
+    s.uid,
+    s.created_at,
+    s.gameover,
+    s.winner,
+    s.board_size,
+    s.number_of_boards,
+    s.difficulty,
+    s.strategy_version,
+    s.seed,
+    ss.boards,
+    ss.is_ai_move
+FROM session s
+JOIN sessionstate ss
+    ON s.session_id = ss.session_id
+WHERE s.session_id = $1;
+
+-- name: GetSessionsByPlayerId :many
+SELECT *
+FROM session
+WHERE uid = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: ListFinishedSessionRewardsByPlayerId :many
+-- Finished sessions started since the player's first ledger row, with the
+-- game_reward amounts the ledger recorded for each.
+SELECT
+    s.session_id,
+    s.board_size,
+    s.number_of_boards,
+    s.difficulty,
+    s.seed,
+    ss.boards,
+    ss.is_ai_move,
+    COALESCE(r.coins, 0)::bigint AS coins_rewarded,
+    COALESCE(r.xp, 0)::bigint AS xp_rewarded
+FROM session s
+JOIN sessionstate ss
+    ON s.session_id = ss.session_id
+LEFT JOIN (
+    SELECT reference_id, SUM(coins_delta) AS coins, SUM(xp_delta) AS xp
+    FROM wallet_transaction
+    WHERE uid = $1 AND reason = 'game_reward'
+    GROUP BY reference_id
+) r ON r.reference_id = s.session_id
+WHERE s.uid = $1
+  AND s.gameover = true
+  AND s.created_at >= (SELECT MIN(created_at) FROM wallet_transaction WHERE uid = $1);
@@ -1,36 +1,121 @@
 -- name: CreateWallet :exec
-INSERT INTO wallet ( uid, coins, xp)
-VALUES ($1, $2, $3);
+WITH created AS (
+    INSERT INTO wallet ( uid, coins, xp)
+    VALUES ($1, $2, $3)
+    RETURNING uid, coins, xp
+)
+INSERT INTO wallet_transaction (uid, reason, coins_delta, xp_delta, coins_after, xp_after)
+SELECT uid, 'sign_up', coins, xp, coins, xp
+FROM created;
 
 -- name: GetWalletByPlayerId :one
 SELECT
     uid,
     coins,
-    xp
+    xp,
+    debt
 FROM wallet
 WHERE uid = $1;
 
 -- name: UpdateWalletCoinsAndXpReward :exec
-UPDATE wallet
-SET coins = coins+$2,
-    xp = xp+$3
-WHERE uid = $1;
+-- Coins credited to a wallet in debt repay the debt first.
+WITH updated AS (
+    UPDATE wallet
+    SET coins = CASE WHEN debt > 0 AND @coins::bigint > 0 THEN GREATEST(@coins::bigint - debt, 0) ELSE coins+@coins END,
+        debt = CASE WHEN debt > 0 AND @coins::bigint > 0 THEN GREATEST(debt - @coins::bigint, 0) ELSE debt END,
+        xp = xp+@xp
+    WHERE uid = @uid
+    RETURNING uid, coins, xp, debt
+)
+INSERT INTO wallet_transaction (uid, reason, coins_delta, xp_delta, coins_after, xp_after, debt_after, reference_id)
+SELECT uid, @reason::wallet_transaction_reason, @coins, @xp, coins, xp, debt, NULLIF(@reference_id::text, '')
+FROM updated;
 
 -- name: UpdateWalletXpReward :exec
-UPDATE wallet
-SET xp = xp+$2
-WHERE uid = $1;
+WITH updated AS (
+    UPDATE wallet
+    SET xp = xp+@xp
+    WHERE uid = @uid
+    RETURNING uid, coins, xp, debt
+)
+INSERT INTO wallet_transaction (uid, reason, coins_delta, xp_delta, coins_after, xp_after, debt_after, reference_id)
+SELECT uid, @reason::wallet_transaction_reason, 0, @xp, coins, xp, debt, NULLIF(@reference_id::text, '')
+FROM updated;
 
 -- name: UpdateWalletReduceCoins :exec
-UPDATE wallet
-SET coins = coins-$2
-WHERE uid = $1;
+WITH updated AS (
+    UPDATE wallet
+    SET coins = coins-@coins
+    WHERE uid = @uid
+    RETURNING uid, coins, xp, debt
+)
+INSERT INTO wallet_transaction (uid, reason, coins_delta, xp_delta, coins_after, xp_after, debt_after, reference_id)
+SELECT uid, @reason::wallet_transaction_reason, -@coins::bigint, 0, coins, xp, debt, NULLIF(@reference_id::text, '')
+FROM updated;
+
+-- name: RefundWalletCoins :exec
+-- Takes back coins credited by a refunded payment. Whatever the wallet
+-- cannot cover is added to its debt.
+WITH updated AS (
+    UPDATE wallet
+    SET coins = GREATEST(coins - @coins::bigint, 0),
+        debt = debt + GREATEST(@coins::bigint - coins, 0)
+    WHERE uid = @uid
+    RETURNING uid, coins, xp, debt
+)
+INSERT INTO wallet_transaction (uid, reason, coins_delta, xp_delta, coins_after, xp_after, debt_after, reference_id)
+SELECT uid, @reason::wallet_transaction_reason, -@coins::bigint, 0, coins, xp, debt, NULLIF(@reference_id::text, '')
+FROM updated;
 
 -- name: GetWalletByPlayerIdWithLock :one
 SELECT
     uid,
     coins,
-    xp
+    xp,
+    debt
 FROM wallet
 WHERE uid = $1
-FOR UPDATE;
\ No newline at end of file
+FOR UPDATE;
+
+-- name: CreateWalletAdjustment :one
+INSERT INTO WalletAdjustment (uid, admin_uid, coins, xp, reason)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: ListWalletTransactions :many
+SELECT
+    id,
+    uid,
+    reason,
+    coins_delta,
+    xp_delta,
+    coins_after,
+    xp_after,
+    reference_id,
+    created_at,
+    debt_after
+FROM wallet_transaction
+WHERE uid = @uid
+  AND (@before_id::bigint = 0 OR id < @before_id::bigint)
+  AND (cardinality(@reasons::text[]) = 0 OR reason::text = ANY(@reasons::text[]))
+ORDER BY id DESC
+LIMIT @page_size;
+
+-- name: ListWalletsAfterUid :many
+SELECT
+    uid,
+    coins,
+    xp,
+    debt
+FROM wallet
+WHERE uid > $1
+ORDER BY uid
+LIMIT $2;
+
+-- name: GetWalletTransactionTotals :one
+SELECT
+    COALESCE(SUM(coins_delta), 0)::bigint AS coins,
+    COALESCE(SUM(xp_delta), 0)::bigint AS xp,
+    COALESCE(SUM(coins_delta) FILTER (WHERE reason = 'purchase'), 0)::bigint AS purchase_coins
+FROM wallet_transaction
+WHERE uid = $1;
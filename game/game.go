@@ -0,0 +1,188 @@
+// Package game holds the rules of a single Notakto session: move legality,
+// the AI reply, undo, and how the game ends. Usecases load a session into a
+// Game, call its methods and persist the result.
+package game
+
+import (
+	"errors"
+
+	"github.com/rakshitg600/notakto-solo/logic"
+)
+
+var (
+	ErrCorrupted     = errors.New("session state corrupted: IsAiMove and Boards length mismatch")
+	ErrGameOver      = errors.New("game is already over")
+	ErrInvalidBoard  = errors.New("invalid board index")
+	ErrInvalidCell   = errors.New("invalid cell index")
+	ErrBoardDead     = errors.New("selected board is already dead")
+	ErrCellMarked    = errors.New("cell is already marked")
+	ErrNoMoves       = errors.New("no moves to undo")
+	ErrAINoMoveFound = errors.New("AI could not find a valid move")
+)
+
+// Config is the fixed setup of a session.
+type Config struct {
+	NumberOfBoards int32
+	BoardSize      int32
+	Difficulty     int32
+	Seed           int64
+}
+
+// Game is a session's move log with its boards folded into bitboards. The
+// player always moves first; a skip lets the AI move twice in a row.
+type Game struct {
+	config   Config
+	boards   []int32
+	isAiMove []bool
+	state    logic.BoardState
+}
+
+// New replays a stored move log. The slices are copied.
+func New(config Config, boards []int32, isAiMove []bool) (*Game, error) {
+	if len(boards) != len(isAiMove) {
+		return nil, ErrCorrupted
+	}
+	g := &Game{
+		config:   config,
+		boards:   append([]int32(nil), boards...),
+		isAiMove: append([]bool(nil), isAiMove...),
+	}
+	if err := g.rebuild(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *Game) rebuild() error {
+	state, err := logic.NewBoardState(g.boards, g.config.BoardSize, g.config.NumberOfBoards)
+	if err != nil {
+		return err
+	}
+	g.state = state
+	return nil
+}
+
+// Boards returns the move log as flat global cell indices.
+func (g *Game) Boards() []int32 {
+	return g.boards
+}
+
+// IsAiMove reports, for every move in Boards, whether the AI played it.
+func (g *Game) IsAiMove() []bool {
+	return g.isAiMove
+}
+
+// Ply is the number of moves played so far.
+func (g *Game) Ply() int {
+	return len(g.boards)
+}
+
+// IsOver reports whether every board is dead.
+func (g *Game) IsOver() bool {
+	return g.state.LiveBoards() == 0
+}
+
+// Winner reports whether the player won. It is only meaningful once IsOver
+// is true: whoever killed the last board lost, so the player won iff the AI
+// made the final move.
+func (g *Game) Winner() bool {
+	return g.IsOver() && len(g.isAiMove) > 0 && g.isAiMove[len(g.isAiMove)-1]
+}
+
+// Legal checks a player move without applying it.
+func (g *Game) Legal(boardIndex int32, cellIndex int32) error {
+	if g.IsOver() {
+		return ErrGameOver
+	}
+	if boardIndex < 0 || boardIndex >= g.config.NumberOfBoards {
+		return ErrInvalidBoard
+	}
+	cells := g.config.BoardSize * g.config.BoardSize
+	if cellIndex < 0 || cellIndex >= cells {
+		return ErrInvalidCell
+	}
+	if g.state.IsDead(boardIndex) {
+		return ErrBoardDead
+	}
+	if g.state.IsOccupied(boardIndex*cells + cellIndex) {
+		return ErrCellMarked
+	}
+	return nil
+}
+
+// Apply plays a player move.
+func (g *Game) Apply(boardIndex int32, cellIndex int32) error {
+	if err := g.Legal(boardIndex, cellIndex); err != nil {
+		return err
+	}
+	g.push(boardIndex*g.config.BoardSize*g.config.BoardSize+cellIndex, false)
+	return nil
+}
+
+// ApplyAI plays the AI's reply, drawn from the registry's mix for the
+// session's difficulty with the session's generator for this ply.
+func (g *Game) ApplyAI(registry *logic.StrategyRegistry) (int32, error) {
+	if g.IsOver() {
+		return -1, ErrGameOver
+	}
+	rng := logic.AIMoveRand(g.config.Seed, g.Ply())
+	move := registry.Move(g.boards, g.config.BoardSize, g.config.NumberOfBoards, g.config.Difficulty, rng)
+	if move == -1 {
+		return -1, ErrAINoMoveFound
+	}
+	g.push(move, true)
+	return move, nil
+}
+
+// Hint finds the strongest move for the player with the session's generator
+// for this ply, and whether the position is a win for them. exact is false
+// when the search ran out of budget; winning is then only a guess.
+func (g *Game) Hint() (boardIndex int32, cellIndex int32, winning bool, exact bool, err error) {
+	if g.IsOver() {
+		return 0, 0, false, false, ErrGameOver
+	}
+	rng := logic.AIMoveRand(g.config.Seed, g.Ply())
+	move, winning, exact := logic.EvaluatePosition(g.boards, g.config.BoardSize, g.config.NumberOfBoards, rng)
+	if move == -1 {
+		return 0, 0, false, false, ErrGameOver
+	}
+	cells := g.config.BoardSize * g.config.BoardSize
+	return move / cells, move % cells, winning, exact, nil
+}
+
+func (g *Game) push(move int32, isAi bool) {
+	g.boards = append(g.boards, move)
+	g.isAiMove = append(g.isAiMove, isAi)
+	g.state.Apply(move)
+}
+
+// Undo takes back the player's last turn: a player move together with the
+// AI reply that followed it, or a lone AI move left by a skip. It returns the
+// number of moves removed.
+func (g *Game) Undo() (int, error) {
+	if g.IsOver() {
+		return 0, ErrGameOver
+	}
+	n := len(g.boards)
+	if n == 0 {
+		return 0, ErrNoMoves
+	}
+	removed := 1
+	if n >= 2 && g.isAiMove[n-1] && !g.isAiMove[n-2] {
+		removed = 2
+	}
+	g.boards = g.boards[:n-removed]
+	g.isAiMove = g.isAiMove[:n-removed]
+	if err := g.rebuild(); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// Rewards rolls the coins and XP for a finished game with the session's
+// generator, so a replay of the game always pays the same. It fails with
+// logic.ErrRewardOverflow if the session's setup is too large to pay out.
+func (g *Game) Rewards() (coins int64, xp int64, err error) {
+	rng := logic.RewardRand(g.config.Seed, g.Ply())
+	return logic.CalculateRewards(g.config.NumberOfBoards, g.config.BoardSize, g.config.Difficulty, g.Winner(), rng)
+}
@@ -0,0 +1,245 @@
+package game
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/rakshitg600/notakto-solo/logic"
+)
+
+// One 3x3 board: cells 0-8, dead once a row, column or diagonal is full.
+var single = Config{NumberOfBoards: 1, BoardSize: 3, Difficulty: 1, Seed: 42}
+
+// Two 3x3 boards: board 1 holds cells 9-17.
+var double = Config{NumberOfBoards: 2, BoardSize: 3, Difficulty: 1, Seed: 42}
+
+func mustNew(t *testing.T, config Config, boards []int32, isAiMove []bool) *Game {
+	t.Helper()
+	g, err := New(config, boards, isAiMove)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func randomRegistry(t *testing.T) *logic.StrategyRegistry {
+	t.Helper()
+	registry, err := logic.NewStrategyRegistry("test", map[int32][]logic.StrategyWeight{
+		1: {{Strategy: "random", Weight: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		boards   []int32
+		isAiMove []bool
+		wantErr  error
+	}{
+		{"empty", single, nil, nil, nil},
+		{"log", single, []int32{4, 0}, []bool{false, true}, nil},
+		{"more moves than movers", single, []int32{4, 0}, []bool{false}, ErrCorrupted},
+		{"more movers than moves", single, []int32{4}, []bool{false, true}, ErrCorrupted},
+		{"unsupported board size", Config{NumberOfBoards: 1, BoardSize: 8}, nil, nil, logic.ErrUnsupportedBoardSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.config, tt.boards, tt.isAiMove)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("New error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewCopiesLog(t *testing.T) {
+	boards, isAiMove := []int32{4}, []bool{false}
+	g := mustNew(t, single, boards, isAiMove)
+	boards[0], isAiMove[0] = 0, true
+	if g.Boards()[0] != 4 || g.IsAiMove()[0] {
+		t.Fatalf("Game shares the caller's slices: %v %v", g.Boards(), g.IsAiMove())
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		boards     []int32
+		isAiMove   []bool
+		boardIndex int32
+		cellIndex  int32
+		wantErr    error
+		wantMove   int32
+	}{
+		{"first move", single, nil, nil, 0, 4, nil, 4},
+		{"second board", double, []int32{4, 0}, []bool{false, true}, 1, 4, nil, 13},
+		{"negative board", double, nil, nil, -1, 0, ErrInvalidBoard, 0},
+		{"board out of range", double, nil, nil, 2, 0, ErrInvalidBoard, 0},
+		{"negative cell", single, nil, nil, 0, -1, ErrInvalidCell, 0},
+		{"cell out of range", single, nil, nil, 0, 9, ErrInvalidCell, 0},
+		{"marked cell", single, []int32{4, 0}, []bool{false, true}, 0, 0, ErrCellMarked, 0},
+		{"dead board", double, []int32{0, 1, 2}, []bool{false, true, false}, 0, 4, ErrBoardDead, 0},
+		{"game over", single, []int32{0, 1, 2}, []bool{false, true, false}, 0, 4, ErrGameOver, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mustNew(t, tt.config, tt.boards, tt.isAiMove)
+			err := g.Apply(tt.boardIndex, tt.cellIndex)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Apply error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if g.Ply() != len(tt.boards) {
+					t.Fatalf("rejected move changed the log to %v", g.Boards())
+				}
+				return
+			}
+			if g.Ply() != len(tt.boards)+1 || g.Boards()[g.Ply()-1] != tt.wantMove || g.IsAiMove()[g.Ply()-1] {
+				t.Fatalf("log after Apply = %v %v, want player move %d appended", g.Boards(), g.IsAiMove(), tt.wantMove)
+			}
+		})
+	}
+}
+
+func TestApplyAIAfterSkip(t *testing.T) {
+	registry := randomRegistry(t)
+	g := mustNew(t, double, []int32{4}, []bool{false})
+	// A skip lets the AI answer twice in a row.
+	for range 2 {
+		move, err := g.ApplyAI(registry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if move < 0 || move >= 18 {
+			t.Fatalf("AI played cell %d outside both boards", move)
+		}
+	}
+	if !slices.Equal(g.IsAiMove(), []bool{false, true, true}) {
+		t.Fatalf("IsAiMove = %v, want [false true true]", g.IsAiMove())
+	}
+	if g.Boards()[1] == g.Boards()[2] || g.Boards()[1] == 4 || g.Boards()[2] == 4 {
+		t.Fatalf("AI replayed a marked cell: %v", g.Boards())
+	}
+
+	// The same seed and ply always give the same moves.
+	again := mustNew(t, double, []int32{4}, []bool{false})
+	for range 2 {
+		if _, err := again.ApplyAI(registry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !slices.Equal(again.Boards(), g.Boards()) {
+		t.Fatalf("replayed AI moves %v, first run %v", again.Boards(), g.Boards())
+	}
+
+	over := mustNew(t, single, []int32{0, 1, 2}, []bool{false, true, false})
+	if _, err := over.ApplyAI(registry); !errors.Is(err, ErrGameOver) {
+		t.Fatalf("ApplyAI on a finished game error = %v, want ErrGameOver", err)
+	}
+}
+
+func TestUndo(t *testing.T) {
+	tests := []struct {
+		name        string
+		boards      []int32
+		isAiMove    []bool
+		wantErr     error
+		wantRemoved int
+	}{
+		{"no moves", nil, nil, ErrNoMoves, 0},
+		{"player move and AI reply", []int32{4, 0}, []bool{false, true}, nil, 2},
+		{"AI move after a skip", []int32{4, 0, 1}, []bool{false, true, true}, nil, 1},
+		{"lone player move", []int32{4}, []bool{false}, nil, 1},
+		{"game over", []int32{0, 1, 2}, []bool{false, true, false}, ErrGameOver, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mustNew(t, single, tt.boards, tt.isAiMove)
+			removed, err := g.Undo()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Undo error = %v, want %v", err, tt.wantErr)
+			}
+			if removed != tt.wantRemoved {
+				t.Fatalf("Undo removed %d moves, want %d", removed, tt.wantRemoved)
+			}
+			kept := len(tt.boards) - removed
+			if !slices.Equal(g.Boards(), tt.boards[:kept]) || !slices.Equal(g.IsAiMove(), tt.isAiMove[:kept]) {
+				t.Fatalf("log after Undo = %v %v", g.Boards(), g.IsAiMove())
+			}
+			// Removed cells are free again.
+			for _, move := range tt.boards[kept:] {
+				if err := g.Legal(0, move); err != nil {
+					t.Fatalf("cell %d still marked after Undo: %v", move, err)
+				}
+			}
+		})
+	}
+}
+
+func TestWinner(t *testing.T) {
+	tests := []struct {
+		name     string
+		boards   []int32
+		isAiMove []bool
+		wantOver bool
+		wantWin  bool
+	}{
+		{"not started", nil, nil, false, false},
+		{"in progress", []int32{4, 0}, []bool{false, true}, false, false},
+		{"AI killed the last board", []int32{0, 4, 1, 2}, []bool{false, true, false, true}, true, true},
+		{"player killed the last board", []int32{0, 4, 1, 3, 2}, []bool{false, true, false, true, false}, true, false},
+		{"AI killed it after a skip", []int32{0, 1, 2}, []bool{false, true, true}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mustNew(t, single, tt.boards, tt.isAiMove)
+			if g.IsOver() != tt.wantOver || g.Winner() != tt.wantWin {
+				t.Fatalf("IsOver, Winner = %v, %v, want %v, %v", g.IsOver(), g.Winner(), tt.wantOver, tt.wantWin)
+			}
+		})
+	}
+}
+
+func TestRewards(t *testing.T) {
+	config := Config{NumberOfBoards: 2, BoardSize: 3, Difficulty: 4, Seed: 7}
+	base := int64(config.NumberOfBoards * config.BoardSize * config.Difficulty)
+	tests := []struct {
+		name     string
+		boards   []int32
+		isAiMove []bool
+		win      bool
+	}{
+		{"loss", []int32{9, 0, 10, 1, 4, 2, 11}, []bool{false, true, false, true, false, true, false}, false},
+		{"win", []int32{0, 9, 1, 10, 11, 2}, []bool{false, true, false, true, false, true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mustNew(t, config, tt.boards, tt.isAiMove)
+			if !g.IsOver() || g.Winner() != tt.win {
+				t.Fatalf("fixture: IsOver, Winner = %v, %v, want true, %v", g.IsOver(), g.Winner(), tt.win)
+			}
+			coins, xp, err := g.Rewards()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.win {
+				if coins != 0 || xp != base {
+					t.Fatalf("loss rewards = %d coins, %d xp, want 0, %d", coins, xp, base)
+				}
+			} else if coins < base || coins > 6*base || xp < 6*base || xp > 11*base {
+				t.Fatalf("win rewards = %d coins, %d xp, want [%d,%d] coins and [%d,%d] xp", coins, xp, base, 6*base, 6*base, 11*base)
+			}
+			againCoins, againXP, err := mustNew(t, config, tt.boards, tt.isAiMove).Rewards()
+			if err != nil || againCoins != coins || againXP != xp {
+				t.Fatalf("replayed rewards = %d, %d, %v, want %d, %d", againCoins, againXP, err, coins, xp)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/usecase"
+)
+
+type AdminAdjustWalletRequest struct {
+	UID    string `json:"uid"`
+	Coins  int64  `json:"coins"`
+	XP     int64  `json:"xp"`
+	Reason string `json:"reason"`
+}
+
+func (h *Handler) AdminAdjustWalletHandler(c echo.Context) error {
+	adminUID, ok := contextkey.UIDFromContext(c.Request().Context())
+	if !ok || adminUID == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized: missing or invalid uid")
+	}
+
+	var req AdminAdjustWalletRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	log.Printf("AdminAdjustWalletHandler called by admin: %s, uid: %s, coins: %d, xp: %d", adminUID, req.UID, req.Coins, req.XP)
+
+	wallet, err := usecase.EnsureAdminAdjustWallet(c.Request().Context(), h.Repo, req.UID, req.Coins, req.XP, req.Reason)
+	if err != nil {
+		c.Logger().Errorf("EnsureAdminAdjustWallet failed: %v", err)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, AdminWallet{Coins: wallet.Coins, XP: wallet.Xp, Debt: wallet.Debt})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/usecase"
+)
+
+type AdminConfigVersion struct {
+	Version   int32           `json:"version"`
+	Value     json.RawMessage `json:"value"`
+	AdminUID  string          `json:"adminUid,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+type AdminGetConfigResponse struct {
+	AdminConfig
+	History []AdminConfigVersion `json:"history"`
+}
+
+func (h *Handler) AdminGetConfigHandler(c echo.Context) error {
+	adminUID, ok := contextkey.UIDFromContext(c.Request().Context())
+	if !ok || adminUID == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized: missing or invalid uid")
+	}
+	key := c.QueryParam("key")
+	if key == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "key query parameter is required")
+	}
+	log.Printf("AdminGetConfigHandler called by admin: %s, key: %s", adminUID, key)
+
+	entry, history, err := usecase.EnsureAdminGetConfig(c.Request().Context(), h.Repo, key)
+	if err != nil {
+		c.Logger().Errorf("EnsureAdminGetConfig failed: %v", err)
+		return err
+	}
+
+	resp := AdminGetConfigResponse{
+		AdminConfig: newAdminConfig(entry),
+		History:     make([]AdminConfigVersion, 0, len(history)),
+	}
+	for _, h := range history {
+		resp.History = append(resp.History, AdminConfigVersion{
+			Version:   h.Version,
+			Value:     h.Value,
+			AdminUID:  h.AdminUid.String,
+			CreatedAt: h.CreatedAt,
+		})
+	}
+	return c.JSON(http.StatusOK, resp)
+}
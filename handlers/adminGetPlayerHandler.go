@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/usecase"
+)
+
+type AdminWallet struct {
+	Coins int64 `json:"coins"`
+	XP    int64 `json:"xp"`
+	Debt  int64 `json:"debt"`
+}
+
+type AdminSession struct {
+	SessionID      string    `json:"sessionId"`
+	CreatedAt      time.Time `json:"createdAt"`
+	GameOver       bool      `json:"gameOver"`
+	Winner         *bool     `json:"winner"`
+	BoardSize      int32     `json:"boardSize"`
+	NumberOfBoards int32     `json:"numberOfBoards"`
+	Difficulty     int32     `json:"difficulty"`
+}
+
+type AdminPayment struct {
+	ChargeID    string    `json:"chargeId"`
+	PackageID   string    `json:"packageId"`
+	Coins       int32     `json:"coins"`
+	AmountCents int32     `json:"amountCents"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type AdminRefund struct {
+	ChargeID        string    `json:"chargeId"`
+	Coins           int32     `json:"coins"`
+	CoinsClawedBack int64     `json:"coinsClawedBack"`
+	DebtAdded       int64     `json:"debtAdded"`
+	ProviderStatus  string    `json:"providerStatus"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+type AdminGetPlayerResponse struct {
+	UID        string         `json:"uid"`
+	Name       string         `json:"name"`
+	Email      string         `json:"email"`
+	ProfilePic string         `json:"profilePic"`
+	Wallet     AdminWallet    `json:"wallet"`
+	Sessions   []AdminSession `json:"sessions"`
+	Payments   []AdminPayment `json:"payments"`
+	Refunds    []AdminRefund  `json:"refunds"`
+}
+
+func (h *Handler) AdminGetPlayerHandler(c echo.Context) error {
+	adminUID, ok := contextkey.UIDFromContext(c.Request().Context())
+	if !ok || adminUID == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized: missing or invalid uid")
+	}
+
+	uid := c.QueryParam("uid")
+	email := c.QueryParam("email")
+	if (uid == "") == (email == "") {
+		return echo.NewHTTPError(http.StatusBadRequest, "exactly one of uid or email query parameters is required")
+	}
+
+	log.Printf("AdminGetPlayerHandler called by admin: %s, uid: %q, email: %q", adminUID, uid, email)
+
+	player, wallet, sessions, payments, refunds, err := usecase.EnsureAdminGetPlayer(c.Request().Context(), h.Repo, uid, email)
+	if err != nil {
+		c.Logger().Errorf("EnsureAdminGetPlayer failed: %v", err)
+		return err
+	}
+
+	resp := AdminGetPlayerResponse{
+		UID:        player.Uid,
+		Name:       player.Name,
+		Email:      player.Email,
+		ProfilePic: player.ProfilePic.String,
+		Wallet:     AdminWallet{Coins: wallet.Coins, XP: wallet.Xp, Debt: wallet.Debt},
+		Sessions:   make([]AdminSession, 0, len(sessions)),
+		Payments:   make([]AdminPayment, 0, len(payments)),
+		Refunds:    make([]AdminRefund, 0, len(refunds)),
+	}
+	for _, s := range sessions {
+		session := AdminSession{
+			SessionID:      s.SessionID,
+			CreatedAt:      s.CreatedAt.Time,
+			GameOver:       s.Gameover.Bool,
+			BoardSize:      s.BoardSize.Int32,
+			NumberOfBoards: s.NumberOfBoards.Int32,
+			Difficulty:     s.Difficulty.Int32,
+		}
+		if s.Winner.Valid {
+			winner := s.Winner.Bool
+			session.Winner = &winner
+		}
+		resp.Sessions = append(resp.Sessions, session)
+	}
+	for _, p := range payments {
+		resp.Payments = append(resp.Payments, AdminPayment{
+			ChargeID:    p.ID,
+			PackageID:   p.PackageID,
+			Coins:       p.Coins,
+			AmountCents: p.AmountCents,
+			Status:      p.Status,
+			CreatedAt:   p.CreatedAt,
+		})
+	}
+	for _, r := range refunds {
+		resp.Refunds = append(resp.Refunds, AdminRefund{
+			ChargeID:        r.PaymentID,
+			Coins:           r.Coins,
+			CoinsClawedBack: r.CoinsClawedBack,
+			DebtAdded:       r.DebtAdded,
+			ProviderStatus:  r.ProviderStatus,
+			CreatedAt:       r.CreatedAt,
+		})
+	}
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/usecase"
+)
+
+type AdminConfig struct {
+	Key       string          `json:"key"`
+	Value     json.RawMessage `json:"value"`
+	Version   int32           `json:"version"`
+	Default   bool            `json:"default"`
+	UpdatedAt *time.Time      `json:"updatedAt,omitempty"`
+}
+
+func newAdminConfig(entry usecase.ConfigEntry) AdminConfig {
+	cfg := AdminConfig{
+		Key:     entry.Key,
+		Value:   entry.Value,
+		Version: entry.Version,
+		Default: entry.Default,
+	}
+	if !entry.UpdatedAt.IsZero() {
+		cfg.UpdatedAt = &entry.UpdatedAt
+	}
+	return cfg
+}
+
+func (h *Handler) AdminListConfigsHandler(c echo.Context) error {
+	adminUID, ok := contextkey.UIDFromContext(c.Request().Context())
+	if !ok || adminUID == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized: missing or invalid uid")
+	}
+	log.Printf("AdminListConfigsHandler called by admin: %s", adminUID)
+
+	entries, err := usecase.EnsureAdminListConfigs(c.Request().Context(), h.Repo)
+	if err != nil {
+		c.Logger().Errorf("EnsureAdminListConfigs failed: %v", err)
+		return err
+	}
+
+	configs := make([]AdminConfig, 0, len(entries))
+	for _, entry := range entries {
+		configs = append(configs, newAdminConfig(entry))
+	}
+	return c.JSON(http.StatusOK, map[string][]AdminConfig{"configs": configs})
+}
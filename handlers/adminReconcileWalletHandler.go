@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/usecase"
+)
+
+type AdminReconcileWalletRequest struct {
+	UID string `json:"uid"`
+}
+
+type AdminReconcileWalletResponse struct {
+	UID        string                `json:"uid"`
+	Mismatches []AdminWalletMismatch `json:"mismatches"`
+}
+
+func (h *Handler) AdminReconcileWalletHandler(c echo.Context) error {
+	adminUID, ok := contextkey.UIDFromContext(c.Request().Context())
+	if !ok || adminUID == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized: missing or invalid uid")
+	}
+
+	var req AdminReconcileWalletRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.UID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "uid is required")
+	}
+
+	log.Printf("AdminReconcileWalletHandler called by admin: %s, uid: %s", adminUID, req.UID)
+
+	mismatches, err := usecase.EnsureAdminReconcileWallet(c.Request().Context(), h.Reconciler, req.UID)
+	if err != nil {
+		c.Logger().Errorf("EnsureAdminReconcileWallet failed: %v", err)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, AdminReconcileWalletResponse{
+		UID:        req.UID,
+		Mismatches: newAdminWalletMismatches(mismatches),
+	})
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/usecase"
+)
+
+type AdminRollbackConfigRequest struct {
+	Key     string `json:"key"`
+	Version int32  `json:"version"`
+}
+
+func (h *Handler) AdminRollbackConfigHandler(c echo.Context) error {
+	adminUID, ok := contextkey.UIDFromContext(c.Request().Context())
+	if !ok || adminUID == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized: missing or invalid uid")
+	}
+	var req AdminRollbackConfigRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.Key == "" || req.Version <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "key and a positive version are required")
+	}
+	log.Printf("AdminRollbackConfigHandler called by admin: %s, key: %s, version: %d", adminUID, req.Key, req.Version)
+
+	version, err := usecase.EnsureAdminRollbackConfig(c.Request().Context(), h.Repo, req.Key, req.Version)
+	if err != nil {
+		c.Logger().Errorf("EnsureAdminRollbackConfig failed: %v", err)
+		return err
+	}
+	return c.JSON(http.StatusOK, AdminConfigVersionResponse{Key: req.Key, Version: version})
+}
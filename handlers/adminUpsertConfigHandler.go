@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/usecase"
+)
+
+type AdminConfigRequest struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type AdminConfigVersionResponse struct {
+	Key     string `json:"key"`
+	Version int32  `json:"version"`
+}
+
+func bindAdminConfigRequest(c echo.Context) (AdminConfigRequest, error) {
+	var req AdminConfigRequest
+	if err := c.Bind(&req); err != nil {
+		return req, echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.Key == "" || len(req.Value) == 0 {
+		return req, echo.NewHTTPError(http.StatusBadRequest, "key and value are required")
+	}
+	return req, nil
+}
+
+func (h *Handler) AdminUpsertConfigHandler(c echo.Context) error {
+	adminUID, ok := contextkey.UIDFromContext(c.Request().Context())
+	if !ok || adminUID == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized: missing or invalid uid")
+	}
+	req, err := bindAdminConfigRequest(c)
+	if err != nil {
+		return err
+	}
+	log.Printf("AdminUpsertConfigHandler called by admin: %s, key: %s", adminUID, req.Key)
+
+	version, err := usecase.EnsureAdminUpsertConfig(c.Request().Context(), h.Repo, req.Key, req.Value)
+	if err != nil {
+		c.Logger().Errorf("EnsureAdminUpsertConfig failed: %v", err)
+		return err
+	}
+	return c.JSON(http.StatusOK, AdminConfigVersionResponse{Key: req.Key, Version: version})
+}
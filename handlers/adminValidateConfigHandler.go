@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/usecase"
+)
+
+func (h *Handler) AdminValidateConfigHandler(c echo.Context) error {
+	adminUID, ok := contextkey.UIDFromContext(c.Request().Context())
+	if !ok || adminUID == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized: missing or invalid uid")
+	}
+	req, err := bindAdminConfigRequest(c)
+	if err != nil {
+		return err
+	}
+	log.Printf("AdminValidateConfigHandler called by admin: %s, key: %s", adminUID, req.Key)
+
+	if err := usecase.EnsureAdminValidateConfig(c.Request().Context(), h.Repo, req.Key, req.Value); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]bool{"valid": true})
+}
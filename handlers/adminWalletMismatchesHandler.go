@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/usecase"
+)
+
+type AdminWalletMismatch struct {
+	UID           string    `json:"uid"`
+	Check         string    `json:"check"`
+	Reference     string    `json:"reference,omitempty"`
+	ExpectedCoins int64     `json:"expectedCoins"`
+	ActualCoins   int64     `json:"actualCoins"`
+	ExpectedXP    int64     `json:"expectedXp"`
+	ActualXP      int64     `json:"actualXp"`
+	DetectedAt    time.Time `json:"detectedAt"`
+}
+
+type AdminReconcileRun struct {
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Wallets    int        `json:"wallets"`
+	Mismatched int        `json:"mismatched"`
+	Failed     int        `json:"failed"`
+}
+
+type AdminWalletMismatchesResponse struct {
+	LastRun    AdminReconcileRun     `json:"lastRun"`
+	Mismatches []AdminWalletMismatch `json:"mismatches"`
+}
+
+func newAdminWalletMismatches(mismatches []usecase.WalletMismatch) []AdminWalletMismatch {
+	out := make([]AdminWalletMismatch, 0, len(mismatches))
+	for _, m := range mismatches {
+		out = append(out, AdminWalletMismatch{
+			UID:           m.Uid,
+			Check:         m.Check,
+			Reference:     m.Reference,
+			ExpectedCoins: m.ExpectedCoins,
+			ActualCoins:   m.ActualCoins,
+			ExpectedXP:    m.ExpectedXP,
+			ActualXP:      m.ActualXP,
+			DetectedAt:    m.DetectedAt,
+		})
+	}
+	return out
+}
+
+func (h *Handler) AdminWalletMismatchesHandler(c echo.Context) error {
+	adminUID, ok := contextkey.UIDFromContext(c.Request().Context())
+	if !ok || adminUID == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized: missing or invalid uid")
+	}
+
+	log.Printf("AdminWalletMismatchesHandler called by admin: %s", adminUID)
+
+	run, mismatches, err := usecase.EnsureAdminListWalletMismatches(c.Request().Context(), h.Reconciler)
+	if err != nil {
+		c.Logger().Errorf("EnsureAdminListWalletMismatches failed: %v", err)
+		return err
+	}
+
+	resp := AdminWalletMismatchesResponse{
+		LastRun: AdminReconcileRun{
+			Wallets:    run.Wallets,
+			Mismatched: run.Mismatched,
+			Failed:     run.Failed,
+		},
+		Mismatches: newAdminWalletMismatches(mismatches),
+	}
+	if !run.StartedAt.IsZero() {
+		resp.LastRun.StartedAt = &run.StartedAt
+		resp.LastRun.FinishedAt = &run.FinishedAt
+	}
+	return c.JSON(http.StatusOK, resp)
+}
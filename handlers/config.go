@@ -1,24 +1,32 @@
 package handlers
 
 import (
-	"firebase.google.com/go/v4/auth"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rakshitg600/notakto-solo/identity"
 	"github.com/rakshitg600/notakto-solo/nowpayments"
+	"github.com/rakshitg600/notakto-solo/repository"
+	"github.com/rakshitg600/notakto-solo/usecase"
 	"github.com/redis/go-redis/v9"
 )
 
 type Handler struct {
 	Pool              *pgxpool.Pool
-	AuthClient        *auth.Client
+	Repo              repository.Repository
+	Configs           *usecase.ConfigCache
+	Reconciler        *usecase.WalletReconciler
+	Identity          identity.Provider
 	ValkeyClient      *redis.Client
 	NowpaymentsClient *nowpayments.Client
 	IPNSecret         string
 }
 
-func NewHandler(pool *pgxpool.Pool, authClient *auth.Client, valkeyClient *redis.Client, npClient *nowpayments.Client, ipnSecret string) *Handler {
+func NewHandler(pool *pgxpool.Pool, repo repository.Repository, configs *usecase.ConfigCache, reconciler *usecase.WalletReconciler, identityProvider identity.Provider, valkeyClient *redis.Client, npClient *nowpayments.Client, ipnSecret string) *Handler {
 	return &Handler{
 		Pool:              pool,
-		AuthClient:        authClient,
+		Repo:              repo,
+		Configs:           configs,
+		Reconciler:        reconciler,
+		Identity:          identityProvider,
 		ValkeyClient:      valkeyClient,
 		NowpaymentsClient: npClient,
 		IPNSecret:         ipnSecret,
@@ -35,10 +35,10 @@ func (h *Handler) CreateChargeHandler(c echo.Context) error {
 
 	log.Printf("CreateChargeHandler called for uid: %s, package: %s", uid, req.PackageID)
 
-	chargeID, hostedURL, err := usecase.EnsureCreateCharge(c.Request().Context(), h.Pool, h.NowpaymentsClient, req.PackageID)
+	chargeID, hostedURL, err := usecase.EnsureCreateCharge(c.Request().Context(), h.Repo, h.Configs, h.NowpaymentsClient, req.PackageID)
 	if err != nil {
 		c.Logger().Errorf("EnsureCreateCharge failed: %v", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create charge")
+		return err
 	}
 
 	log.Printf("CreateChargeHandler completed for uid: %s, chargeId: %s", uid, chargeID)
@@ -55,7 +55,8 @@ func (h *Handler) CreateGameHandler(c echo.Context) error {
 	// ✅✅ Logic: get typed values from EnsureSession
 	sessionID, uidOut, boards, isAiMove, winner, boardSize, numberOfBoards, difficulty, gameover, createdAt, err := usecase.EnsureSession(
 		c.Request().Context(),
-		h.Pool,
+		h.Repo,
+		h.Configs,
 		req.NumberOfBoards,
 		req.BoardSize,
 		req.Difficulty,
@@ -63,7 +64,7 @@ func (h *Handler) CreateGameHandler(c echo.Context) error {
 	// ✅ Handle errors
 	if err != nil {
 		c.Logger().Errorf("EnsureSession failed: %v", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	createdAtStr := createdAt.UTC().Format(time.RFC3339)
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakshitg600/notakto-solo/usecase"
+)
+
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorStatus maps usecase error codes to HTTP statuses.
+var errorStatus = map[string]int{
+	usecase.ErrUnauthenticated.Code:       http.StatusUnauthorized,
+	usecase.ErrSessionNotFound.Code:       http.StatusNotFound,
+	usecase.ErrSessionForbidden.Code:      http.StatusForbidden,
+	usecase.ErrGameOver.Code:              http.StatusConflict,
+	usecase.ErrGameNotOver.Code:           http.StatusConflict,
+	usecase.ErrInvalidBoard.Code:          http.StatusBadRequest,
+	usecase.ErrInvalidCell.Code:           http.StatusBadRequest,
+	usecase.ErrBoardDead.Code:             http.StatusUnprocessableEntity,
+	usecase.ErrCellOccupied.Code:          http.StatusUnprocessableEntity,
+	usecase.ErrNoMovesToUndo.Code:         http.StatusUnprocessableEntity,
+	usecase.ErrSessionChanged.Code:        http.StatusConflict,
+	usecase.ErrInsufficientCoins.Code:     http.StatusPaymentRequired,
+	usecase.ErrWalletInDebt.Code:          http.StatusPaymentRequired,
+	usecase.ErrPackageNotFound.Code:       http.StatusBadRequest,
+	usecase.ErrPaymentNotFound.Code:       http.StatusNotFound,
+	usecase.ErrPaymentForbidden.Code:      http.StatusForbidden,
+	usecase.ErrPlayerNotFound.Code:        http.StatusNotFound,
+	usecase.ErrInvalidAdjustment.Code:     http.StatusBadRequest,
+	usecase.ErrNegativeBalance.Code:       http.StatusUnprocessableEntity,
+	usecase.ErrUnknownConfigKey.Code:      http.StatusNotFound,
+	usecase.ErrInvalidConfig.Code:         http.StatusBadRequest,
+	usecase.ErrConfigVersionNotFound.Code: http.StatusNotFound,
+	usecase.ErrConfigConflict.Code:        http.StatusConflict,
+	usecase.ErrInvalidHistoryType.Code:    http.StatusBadRequest,
+	usecase.ErrInvalidCursor.Code:         http.StatusBadRequest,
+}
+
+// ErrorHandler is the Echo HTTPErrorHandler. Usecase errors keep their code;
+// HTTP errors raised by handlers and middleware get a code derived from their
+// status; anything else is logged and reported as an opaque internal error.
+func ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status, resp := errorResponse(err)
+	if status >= http.StatusInternalServerError {
+		c.Logger().Errorf("%s %s failed: %v", c.Request().Method, c.Path(), err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, resp)
+	}
+	if err != nil {
+		c.Logger().Errorf("failed to write error response: %v", err)
+	}
+}
+
+func errorResponse(err error) (int, ErrorResponse) {
+	var domainErr *usecase.Error
+	if errors.As(err, &domainErr) {
+		status, ok := errorStatus[domainErr.Code]
+		if !ok {
+			status = http.StatusBadRequest
+		}
+		return status, ErrorResponse{Code: domainErr.Code, Message: err.Error()}
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		message, ok := httpErr.Message.(string)
+		if !ok {
+			message = http.StatusText(httpErr.Code)
+		}
+		return httpErr.Code, ErrorResponse{Code: statusCode(httpErr.Code), Message: message}
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return http.StatusNotFound, ErrorResponse{Code: statusCode(http.StatusNotFound), Message: "not found"}
+	}
+	return http.StatusInternalServerError, ErrorResponse{Code: statusCode(http.StatusInternalServerError), Message: "internal server error"}
+}
+
+// statusCode turns an HTTP status into a snake_case code, e.g. 429 -> "too_many_requests".
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
@@ -20,10 +20,10 @@ func (h *Handler) GetAllPackagesHandler(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized: missing or invalid uid")
 	}
 
-	packages, err := usecase.EnsureGetAllPackages(c.Request().Context(), h.Pool)
+	packages, err := usecase.EnsureGetAllPackages(c.Request().Context(), h.Configs)
 	if err != nil {
 		c.Logger().Errorf("EnsureGetAllPackages failed: %v", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "failed to get all packages")
+		return err
 	}
 	responsePackages := make([]config.CoinPackage, len(packages))
 	for i, pkg := range packages {
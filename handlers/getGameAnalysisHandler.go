@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/usecase"
+)
+
+type GameAnalysisMove struct {
+	Ply            int    `json:"ply"`
+	BoardIndex     int32  `json:"boardIndex"`
+	CellIndex      int32  `json:"cellIndex"`
+	IsAiMove       bool   `json:"isAiMove"`
+	MoverWinning   bool   `json:"moverWinning"`
+	Exact          bool   `json:"exact"`
+	Blunder        bool   `json:"blunder"`
+	BestBoardIndex *int32 `json:"bestBoardIndex,omitempty"`
+	BestCellIndex  *int32 `json:"bestCellIndex,omitempty"`
+}
+
+type GetGameAnalysisResponse struct {
+	SessionID string             `json:"sessionId"`
+	Winner    bool               `json:"winner"`
+	Accuracy  float64            `json:"accuracy"`
+	Blunders  int                `json:"blunders"`
+	Moves     []GameAnalysisMove `json:"moves"`
+}
+
+func (h *Handler) GetGameAnalysisHandler(c echo.Context) error {
+	uid, ok := contextkey.UIDFromContext(c.Request().Context())
+	if !ok || uid == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized: missing or invalid uid")
+	}
+
+	sessionID := c.QueryParam("sessionId")
+	if sessionID == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "sessionId query parameter is required")
+	}
+
+	log.Printf("GetGameAnalysisHandler called for uid: %s, sessionId: %s", uid, sessionID)
+
+	session, plies, accuracy, blunders, err := usecase.EnsureGetGameAnalysis(c.Request().Context(), h.Repo, sessionID)
+	if err != nil {
+		c.Logger().Errorf("EnsureGetGameAnalysis failed: %v", err)
+		return err
+	}
+
+	cells := session.BoardSize.Int32 * session.BoardSize.Int32
+	moves := make([]GameAnalysisMove, len(plies))
+	for i, ply := range plies {
+		moves[i] = GameAnalysisMove{
+			Ply:          i + 1,
+			BoardIndex:   ply.Move / cells,
+			CellIndex:    ply.Move % cells,
+			IsAiMove:     ply.IsAiMove,
+			MoverWinning: ply.MoverWinning,
+			Exact:        ply.Exact,
+			Blunder:      ply.Blunder,
+		}
+		if ply.BestMove >= 0 {
+			bestBoard, bestCell := ply.BestMove/cells, ply.BestMove%cells
+			moves[i].BestBoardIndex = &bestBoard
+			moves[i].BestCellIndex = &bestCell
+		}
+	}
+
+	return c.JSON(http.StatusOK, GetGameAnalysisResponse{
+		SessionID: session.SessionID,
+		Winner:    session.Winner.Valid && session.Winner.Bool,
+		Accuracy:  accuracy,
+		Blunders:  blunders,
+		Moves:     moves,
+	})
+}
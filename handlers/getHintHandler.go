@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/usecase"
+)
+
+type GetHintRequest struct {
+	SessionID string `json:"sessionId"`
+}
+
+type GetHintResponse struct {
+	BoardIndex int32 `json:"boardIndex"`
+	CellIndex  int32 `json:"cellIndex"`
+	IsWinning  bool  `json:"isWinning"`
+	Exact      bool  `json:"exact"`
+}
+
+func (h *Handler) GetHintHandler(c echo.Context) error {
+	uid, ok := contextkey.UIDFromContext(c.Request().Context())
+	if !ok || uid == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized: missing or invalid uid")
+	}
+
+	log.Printf("GetHintHandler called for uid: %s", uid)
+	var req GetHintRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	boardIndex, cellIndex, winning, exact, err := usecase.EnsureGetHint(
+		c.Request().Context(),
+		h.Repo,
+		req.SessionID,
+	)
+	if err != nil {
+		c.Logger().Errorf("GetHint failed: %v", err)
+		return err
+	}
+
+	resp := GetHintResponse{
+		BoardIndex: boardIndex,
+		CellIndex:  cellIndex,
+		IsWinning:  winning,
+		Exact:      exact,
+	}
+	log.Printf("GetHintHandler completed for uid: %s, sessionID: %s, boardIndex: %d, cellIndex: %d, isWinning: %v, exact: %v", uid, req.SessionID, boardIndex, cellIndex, winning, exact)
+	return c.JSON(http.StatusOK, resp)
+}
@@ -11,8 +11,9 @@ import (
 )
 
 type GetWalletResponse struct {
-	Coins   int32  `json:"coins"`
-	XP      int32  `json:"xp"`
+	Coins   int64  `json:"coins"`
+	XP      int64  `json:"xp"`
+	Debt    int64  `json:"debt"`
 	Success bool   `json:"success"`
 	Error   string `json:"error,omitempty"`
 }
@@ -23,12 +24,13 @@ func (h *Handler) GetWalletHandler(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized: missing or invalid uid")
 	}
 	log.Printf("GetWalletHandler called for uid: %s", uid)
-	coins, xp, err := usecase.EnsureGetWallet(c.Request().Context(), h.Pool)
+	coins, xp, debt, err := usecase.EnsureGetWallet(c.Request().Context(), h.Repo)
 	if err != nil {
 		c.Logger().Errorf("EnsureGetWallet failed: %v", err)
 		return c.JSON(http.StatusOK, GetWalletResponse{
 			Coins:   coins,
 			XP:      xp,
+			Debt:    debt,
 			Success: false,
 			Error:   err.Error(),
 		})
@@ -38,6 +40,7 @@ func (h *Handler) GetWalletHandler(c echo.Context) error {
 		Success: true,
 		Coins:   coins,
 		XP:      xp,
+		Debt:    debt,
 	}
 	log.Printf("GetWalletHandler completed for uid: %s", uid)
 	return c.JSON(http.StatusOK, resp)
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+	"github.com/rakshitg600/notakto-solo/usecase"
+)
+
+// walletReasonDescriptions are the player-facing texts for ledger reasons.
+var walletReasonDescriptions = map[db.WalletTransactionReason]string{
+	db.WalletTransactionReasonOpeningBalance: "Balance before history was recorded",
+	db.WalletTransactionReasonSignUp:         "Sign-up bonus",
+	db.WalletTransactionReasonGameReward:     "Game reward",
+	db.WalletTransactionReasonSkipCost:       "Skipped a move",
+	db.WalletTransactionReasonUndoCost:       "Undid a move",
+	db.WalletTransactionReasonHintCost:       "Used a hint",
+	db.WalletTransactionReasonPurchase:       "Coin purchase",
+	db.WalletTransactionReasonAdminAdjust:    "Adjusted by support",
+	db.WalletTransactionReasonRefund:         "Purchase refunded",
+}
+
+type WalletHistoryEntry struct {
+	ID          int64     `json:"id"`
+	Reason      string    `json:"reason"`
+	Description string    `json:"description"`
+	Coins       int64     `json:"coins"`
+	XP          int64     `json:"xp"`
+	CoinsAfter  int64     `json:"coinsAfter"`
+	XPAfter     int64     `json:"xpAfter"`
+	DebtAfter   int64     `json:"debtAfter"`
+	SessionID   string    `json:"sessionId,omitempty"`
+	PaymentID   string    `json:"paymentId,omitempty"`
+	Link        string    `json:"link,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type GetWalletHistoryResponse struct {
+	Entries    []WalletHistoryEntry `json:"entries"`
+	NextCursor string               `json:"nextCursor,omitempty"`
+}
+
+func newWalletHistoryEntry(t db.WalletTransaction) WalletHistoryEntry {
+	entry := WalletHistoryEntry{
+		ID:          t.ID,
+		Reason:      string(t.Reason),
+		Description: walletReasonDescriptions[t.Reason],
+		Coins:       t.CoinsDelta,
+		XP:          t.XpDelta,
+		CoinsAfter:  t.CoinsAfter,
+		XPAfter:     t.XpAfter,
+		DebtAfter:   t.DebtAfter,
+		CreatedAt:   t.CreatedAt,
+	}
+	if entry.Description == "" {
+		entry.Description = string(t.Reason)
+	}
+	if !t.ReferenceID.Valid {
+		return entry
+	}
+	switch t.Reason {
+	case db.WalletTransactionReasonGameReward,
+		db.WalletTransactionReasonSkipCost,
+		db.WalletTransactionReasonUndoCost,
+		db.WalletTransactionReasonHintCost:
+		entry.SessionID = t.ReferenceID.String
+		entry.Link = "/v1/game-analysis?sessionId=" + url.QueryEscape(t.ReferenceID.String)
+	case db.WalletTransactionReasonPurchase, db.WalletTransactionReasonRefund:
+		entry.PaymentID = t.ReferenceID.String
+		entry.Link = "/v1/payment-status?chargeId=" + url.QueryEscape(t.ReferenceID.String)
+	}
+	return entry
+}
+
+func (h *Handler) GetWalletHistoryHandler(c echo.Context) error {
+	uid, ok := contextkey.UIDFromContext(c.Request().Context())
+	if !ok || uid == "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized: missing or invalid uid")
+	}
+
+	limit := 0
+	if raw := c.QueryParam("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be a positive integer")
+		}
+		limit = n
+	}
+	historyType := c.QueryParam("type")
+	cursor := c.QueryParam("cursor")
+
+	log.Printf("GetWalletHistoryHandler called for uid: %s, type: %q, cursor: %q", uid, historyType, cursor)
+
+	transactions, nextCursor, err := usecase.EnsureGetWalletHistory(c.Request().Context(), h.Repo, historyType, cursor, limit)
+	if err != nil {
+		c.Logger().Errorf("EnsureGetWalletHistory failed: %v", err)
+		return err
+	}
+
+	resp := GetWalletHistoryResponse{
+		Entries:    make([]WalletHistoryEntry, 0, len(transactions)),
+		NextCursor: nextCursor,
+	}
+	for _, t := range transactions {
+		resp.Entries = append(resp.Entries, newWalletHistoryEntry(t))
+	}
+	return c.JSON(http.StatusOK, resp)
+}
@@ -21,8 +21,8 @@ type MakeMoveResponse struct {
 	IsAiMove      []bool  `json:"isAiMove"`
 	Gameover      bool    `json:"gameover"`
 	Winner        bool    `json:"winner"`
-	CoinsRewarded int32   `json:"coinsRewarded"`
-	XpRewarded    int32   `json:"xpRewarded"`
+	CoinsRewarded int64   `json:"coinsRewarded"`
+	XpRewarded    int64   `json:"xpRewarded"`
 }
 
 func (h *Handler) MakeMoveHandler(c echo.Context) error {
@@ -39,14 +39,15 @@ func (h *Handler) MakeMoveHandler(c echo.Context) error {
 	}
 	boards, isAiMove, gameOver, winner, coinsRewarded, xpRewarded, err := usecase.EnsureMakeMove(
 		c.Request().Context(),
-		h.Pool,
+		h.Repo,
+		h.Configs,
 		req.SessionID,
 		req.BoardIndex,
 		req.CellIndex,
 	)
 	if err != nil {
 		c.Logger().Errorf("MakeMove failed: %v", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	resp := MakeMoveResponse{
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rakshitg600/notakto-solo/contextkey"
+)
+
+// MetricsHandler serves the process's expvar counters, including per-usecase
+// transaction commit/retry counts. It shares the keepalive token.
+func (h *Handler) MetricsHandler(c echo.Context) error {
+	authorized, _ := contextkey.KeepaliveAuthorizedFromContext(c.Request().Context())
+	if !authorized {
+		return c.NoContent(http.StatusUnauthorized)
+	}
+	expvar.Handler().ServeHTTP(c.Response(), c.Request())
+	return nil
+}
@@ -1,11 +1,9 @@
 package handlers
 
 import (
-	"errors"
 	"log"
 	"net/http"
 
-	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
 
 	"github.com/rakshitg600/notakto-solo/contextkey"
@@ -34,16 +32,10 @@ func (h *Handler) PaymentStatusHandler(c echo.Context) error {
 
 	log.Printf("PaymentStatusHandler called for uid: %s, chargeId: %s", uid, chargeID)
 
-	payment, err := usecase.EnsureGetPaymentStatus(c.Request().Context(), h.Pool, chargeID)
+	payment, err := usecase.EnsureGetPaymentStatus(c.Request().Context(), h.Repo, chargeID)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return echo.NewHTTPError(http.StatusNotFound, "payment not found")
-		}
-		if errors.Is(err, usecase.ErrPaymentForbidden) {
-			return echo.NewHTTPError(http.StatusForbidden, "access denied")
-		}
 		c.Logger().Errorf("EnsureGetPaymentStatus failed: %v", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "failed to fetch payment")
+		return err
 	}
 
 	return c.JSON(http.StatusOK, PaymentStatusResponse{
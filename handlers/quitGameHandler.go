@@ -29,7 +29,7 @@ func (h *Handler) QuitGameHandler(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
 	}
-	success, err := usecase.EnsureQuitGame(c.Request().Context(), h.Pool, req.SessionID)
+	success, err := usecase.EnsureQuitGame(c.Request().Context(), h.Repo, req.SessionID)
 	if err != nil {
 		c.Logger().Errorf("EnsureQuitGame failed: %v", err)
 		return c.JSON(http.StatusOK, QuitGameResponse{
@@ -26,13 +26,14 @@ func (h *Handler) SignInHandler(c echo.Context) error {
 	log.Printf("SignInHandler called for uid: %s", uid)
 	profilePic, name, email, isNew, err := usecase.EnsureLogin(
 		c.Request().Context(),
-		h.Pool,
-		h.AuthClient,
+		h.Repo,
+		h.Configs,
+		h.Identity,
 	)
 
 	if err != nil {
 		c.Logger().Errorf("EnsurePlayer failed: %v", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	resp := SignInResponse{
@@ -18,8 +18,8 @@ type SkipMoveResponse struct {
 	IsAiMove      []bool  `json:"isAiMove"`
 	Gameover      bool    `json:"gameover"`
 	Winner        bool    `json:"winner"`
-	CoinsRewarded int32   `json:"coinsRewarded"`
-	XpRewarded    int32   `json:"xpRewarded"`
+	CoinsRewarded int64   `json:"coinsRewarded"`
+	XpRewarded    int64   `json:"xpRewarded"`
 }
 
 func (h *Handler) SkipMoveHandler(c echo.Context) error {
@@ -36,12 +36,13 @@ func (h *Handler) SkipMoveHandler(c echo.Context) error {
 	}
 	boards, isAiMove, gameOver, winner, coinsRewarded, xpRewarded, err := usecase.EnsureSkipMove(
 		c.Request().Context(),
-		h.Pool,
+		h.Repo,
+		h.Configs,
 		req.SessionID,
 	)
 	if err != nil {
 		c.Logger().Errorf("SkipMove failed: %v", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "failed to process skip move")
+		return err
 	}
 
 	resp := SkipMoveResponse{
@@ -28,10 +28,10 @@ func (h *Handler) UpdateNameHandler(c echo.Context) error {
 		return echo.NewHTTPError(400, "name is required")
 	}
 	// ✅ Update the name
-	updatedName, err := usecase.EnsureUpdateName(c.Request().Context(), h.Pool, req.Name)
+	updatedName, err := usecase.EnsureUpdateName(c.Request().Context(), h.Repo, req.Name)
 	if err != nil {
 		log.Printf("UpdateNameHandler error for uid %s: %v", uid, err)
-		return echo.NewHTTPError(500, "internal server error")
+		return err
 	}
 	// ✅ Return the updated name
 	log.Printf("Updated name for uid %s to %s", uid, updatedName)
@@ -75,7 +75,7 @@ func (h *Handler) WebhookHandler(c echo.Context) error {
 	log.Printf("webhook: received status %s for order %s (payment_id=%s)",
 		payload.PaymentStatus, payload.OrderID, payload.PaymentID.String())
 
-	if err := usecase.EnsureProcessWebhook(c.Request().Context(), h.Pool, payload.PaymentStatus, payload.OrderID); err != nil {
+	if err := usecase.EnsureProcessWebhook(c.Request().Context(), h.Repo, payload.PaymentStatus, payload.OrderID, payload.PaymentID.String()); err != nil {
 		log.Printf("webhook: processing failed for order %s: %v", payload.OrderID, err)
 		return c.NoContent(http.StatusInternalServerError)
 	}
@@ -0,0 +1,322 @@
+package identity
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	sharedTokenKeyPrefix  = "notakto:auth:token:"
+	sharedTokenTimeout    = 200 * time.Millisecond
+	revocationCheckBudget = 30 * time.Second
+)
+
+// cacheMetrics counts hits (local and shared tier), misses that went to the
+// provider, tokens dropped because they were revoked, and entries evicted to
+// stay within the size bound. Published through expvar under
+// "auth_token_cache".
+var cacheMetrics = expvar.NewMap("auth_token_cache")
+
+type CacheConfig struct {
+	// Size bounds how many tokens are held in process.
+	Size int
+	// Shared, when set, is a second tier so instances reuse each other's
+	// verifications.
+	Shared *redis.Client
+	// RevocationInterval is how often cached tokens are checked against
+	// the provider for revocation. Zero disables the check, as does a
+	// provider that does not implement RevocationChecker.
+	RevocationInterval time.Duration
+	// ResolveRole, when set, resolves the role of each newly verified
+	// token, which is then cached with it as Token.Role. A role granted
+	// or withdrawn after that takes effect with the player's next token.
+	ResolveRole func(ctx context.Context, token *Token) (Role, error)
+}
+
+// CachedProvider remembers verified tokens, with the profile and resolved
+// role they carry, until they expire, keyed by a SHA-256 of the raw token so
+// the token itself is never stored. A revoked token may keep working for up
+// to RevocationInterval. Cached Tokens are shared between callers and must
+// not be modified.
+type CachedProvider struct {
+	Provider
+	config CacheConfig
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // front is most recently used
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type cacheEntry struct {
+	key   string
+	token *Token
+}
+
+// sharedToken is how a Token is stored in the shared tier.
+type sharedToken struct {
+	UID      string                 `json:"uid"`
+	IssuedAt int64                  `json:"iat"`
+	Expires  int64                  `json:"exp"`
+	Claims   map[string]interface{} `json:"claims,omitempty"`
+	Profile  *Profile               `json:"profile,omitempty"`
+	Role     Role                   `json:"role,omitempty"`
+}
+
+func NewCachedProvider(provider Provider, config CacheConfig) *CachedProvider {
+	c := &CachedProvider{
+		Provider: provider,
+		config:   config,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	checker, ok := provider.(RevocationChecker)
+	if !ok || config.RevocationInterval <= 0 {
+		close(c.done)
+		return c
+	}
+	go c.checkRevocations(checker)
+	return c
+}
+
+// Close stops the revocation check.
+func (c *CachedProvider) Close() {
+	select {
+	case <-c.stop:
+	default:
+		close(c.stop)
+	}
+	<-c.done
+}
+
+func (c *CachedProvider) VerifyToken(ctx context.Context, idToken string) (*Token, error) {
+	sum := sha256.Sum256([]byte(idToken))
+	key := hex.EncodeToString(sum[:])
+
+	if token := c.get(key); token != nil {
+		cacheMetrics.Add("hits", 1)
+		return token, nil
+	}
+	if token := c.getShared(ctx, key); token != nil {
+		cacheMetrics.Add("shared_hits", 1)
+		token, err := c.resolveRole(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		c.put(key, token)
+		return token, nil
+	}
+
+	cacheMetrics.Add("misses", 1)
+	token, err := c.Provider.VerifyToken(ctx, idToken)
+	if err != nil {
+		return nil, err
+	}
+	if token, err = c.resolveRole(ctx, token); err != nil {
+		return nil, err
+	}
+	c.put(key, token)
+	c.putShared(ctx, key, token)
+	return token, nil
+}
+
+// resolveRole returns token with its Role set by ResolveRole. The token is
+// copied, as the provider's may be shared.
+func (c *CachedProvider) resolveRole(ctx context.Context, token *Token) (*Token, error) {
+	if c.config.ResolveRole == nil || token.Role != "" {
+		return token, nil
+	}
+	role, err := c.config.ResolveRole(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *token
+	resolved.Role = role
+	return &resolved, nil
+}
+
+func (c *CachedProvider) get(key string) *Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !time.Now().Before(entry.token.Expires) {
+		c.removeLocked(elem)
+		return nil
+	}
+	c.lru.MoveToFront(elem)
+	return entry.token
+}
+
+func (c *CachedProvider) put(key string, token *Token) {
+	if c.config.Size <= 0 || !time.Now().Before(token.Expires) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).token = token
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, token: token})
+	for c.lru.Len() > c.config.Size {
+		c.removeLocked(c.lru.Back())
+		cacheMetrics.Add("evictions", 1)
+	}
+}
+
+func (c *CachedProvider) removeLocked(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+}
+
+func (c *CachedProvider) getShared(ctx context.Context, key string) *Token {
+	if c.config.Shared == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, sharedTokenTimeout)
+	defer cancel()
+	raw, err := c.config.Shared.Get(ctx, sharedTokenKeyPrefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("auth token cache: shared get failed: %v", err)
+		}
+		return nil
+	}
+	var shared sharedToken
+	if err := json.Unmarshal(raw, &shared); err != nil {
+		log.Printf("auth token cache: bad shared entry: %v", err)
+		return nil
+	}
+	token := &Token{
+		UID:      shared.UID,
+		IssuedAt: time.Unix(shared.IssuedAt, 0),
+		Expires:  time.Unix(shared.Expires, 0),
+		Claims:   shared.Claims,
+		Profile:  shared.Profile,
+		Role:     shared.Role,
+	}
+	if token.UID == "" || !time.Now().Before(token.Expires) {
+		return nil
+	}
+	return token
+}
+
+func (c *CachedProvider) putShared(ctx context.Context, key string, token *Token) {
+	if c.config.Shared == nil {
+		return
+	}
+	ttl := time.Until(token.Expires)
+	if ttl <= 0 {
+		return
+	}
+	raw, err := json.Marshal(sharedToken{
+		UID:      token.UID,
+		IssuedAt: token.IssuedAt.Unix(),
+		Expires:  token.Expires.Unix(),
+		Claims:   token.Claims,
+		Profile:  token.Profile,
+		Role:     token.Role,
+	})
+	if err != nil {
+		log.Printf("auth token cache: encode failed: %v", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, sharedTokenTimeout)
+	defer cancel()
+	if err := c.config.Shared.Set(ctx, sharedTokenKeyPrefix+key, raw, ttl).Err(); err != nil {
+		log.Printf("auth token cache: shared set failed: %v", err)
+	}
+}
+
+func (c *CachedProvider) checkRevocations(checker RevocationChecker) {
+	defer close(c.done)
+	ticker := time.NewTicker(c.config.RevocationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.revoke(checker)
+		}
+	}
+}
+
+// revoke drops expired entries and asks the provider whether any user with a
+// cached token has had their tokens revoked since it was issued.
+func (c *CachedProvider) revoke(checker RevocationChecker) {
+	c.mu.Lock()
+	now := time.Now()
+	byUID := make(map[string][]string)
+	for key, elem := range c.entries {
+		token := elem.Value.(*cacheEntry).token
+		if !now.Before(token.Expires) {
+			c.removeLocked(elem)
+			continue
+		}
+		byUID[token.UID] = append(byUID[token.UID], key)
+	}
+	c.mu.Unlock()
+	if len(byUID) == 0 {
+		return
+	}
+
+	uids := make([]string, 0, len(byUID))
+	for uid := range byUID {
+		uids = append(uids, uid)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), revocationCheckBudget)
+	defer cancel()
+	validAfter, err := checker.TokensValidAfter(ctx, uids)
+	if err != nil {
+		log.Printf("auth token cache: revocation check failed: %v", err)
+		return
+	}
+
+	var revoked []string
+	c.mu.Lock()
+	for uid, keys := range byUID {
+		after, exists := validAfter[uid]
+		for _, key := range keys {
+			elem, ok := c.entries[key]
+			if !ok {
+				continue
+			}
+			if exists && !elem.Value.(*cacheEntry).token.IssuedAt.Before(after) {
+				continue
+			}
+			c.removeLocked(elem)
+			revoked = append(revoked, sharedTokenKeyPrefix+key)
+		}
+	}
+	c.mu.Unlock()
+	if len(revoked) == 0 {
+		return
+	}
+
+	cacheMetrics.Add("revocations", int64(len(revoked)))
+	if c.config.Shared != nil {
+		if err := c.config.Shared.Del(ctx, revoked...).Err(); err != nil {
+			log.Printf("auth token cache: shared delete failed: %v", err)
+		}
+	}
+}
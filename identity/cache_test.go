@@ -0,0 +1,75 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingProvider verifies any token as uid "u1", issued now, and counts
+// the calls.
+type countingProvider struct {
+	verifications atomic.Int32
+	validAfter    time.Time
+}
+
+func (p *countingProvider) VerifyToken(ctx context.Context, idToken string) (*Token, error) {
+	p.verifications.Add(1)
+	now := time.Now()
+	return &Token{UID: "u1", IssuedAt: now, Expires: now.Add(time.Hour), Profile: &Profile{Name: "Ada"}}, nil
+}
+
+func (p *countingProvider) GetProfile(ctx context.Context, uid string) (Profile, error) {
+	return Profile{}, ErrUserNotFound
+}
+
+func (p *countingProvider) TokensValidAfter(ctx context.Context, uids []string) (map[string]time.Time, error) {
+	return map[string]time.Time{"u1": p.validAfter}, nil
+}
+
+func TestCachedProviderCachesResolvedRole(t *testing.T) {
+	provider := &countingProvider{}
+	var resolutions atomic.Int32
+	resolveErr := errors.New("roles table unavailable")
+	failing := true
+	c := NewCachedProvider(provider, CacheConfig{Size: 10, ResolveRole: func(ctx context.Context, token *Token) (Role, error) {
+		resolutions.Add(1)
+		if failing {
+			return "", resolveErr
+		}
+		return RoleSupport, nil
+	}})
+	defer c.Close()
+	verify := func() *Token {
+		t.Helper()
+		token, err := c.VerifyToken(context.Background(), "raw")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	// A failed resolution is not cached.
+	if _, err := c.VerifyToken(context.Background(), "raw"); !errors.Is(err, resolveErr) {
+		t.Fatalf("VerifyToken error = %v, want the ResolveRole error", err)
+	}
+	failing = false
+	for range 3 {
+		if token := verify(); token.Role != RoleSupport || token.Profile == nil || token.Profile.Name != "Ada" {
+			t.Fatalf("token = %+v, want role support and the provider's profile", token)
+		}
+	}
+	if provider.verifications.Load() != 2 || resolutions.Load() != 2 {
+		t.Fatalf("%d verifications and %d role resolutions, want 2 of each", provider.verifications.Load(), resolutions.Load())
+	}
+
+	// Revoking the token drops the role with it.
+	provider.validAfter = time.Now().Add(time.Minute)
+	c.revoke(provider)
+	verify()
+	if provider.verifications.Load() != 3 || resolutions.Load() != 3 {
+		t.Fatalf("after revocation: %d verifications and %d role resolutions, want 3 of each", provider.verifications.Load(), resolutions.Load())
+	}
+}
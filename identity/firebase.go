@@ -0,0 +1,69 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+)
+
+// Firebase verifies Firebase ID tokens with the Admin SDK. The profile is
+// taken from the token's claims when it has them and otherwise looked up
+// with GetUser. Setting
+// FIREBASE_AUTH_EMULATOR_HOST makes the SDK talk to the Auth emulator.
+type Firebase struct {
+	client *auth.Client
+}
+
+func NewFirebase(client *auth.Client) *Firebase {
+	return &Firebase{client: client}
+}
+
+func (f *Firebase) VerifyToken(ctx context.Context, idToken string) (*Token, error) {
+	token, err := f.client.VerifyIDToken(ctx, idToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return &Token{
+		UID:      token.UID,
+		IssuedAt: time.Unix(token.IssuedAt, 0),
+		Expires:  time.Unix(token.Expires, 0),
+		Claims:   token.Claims,
+		Profile:  profileFromClaims(token.Claims),
+	}, nil
+}
+
+func (f *Firebase) GetProfile(ctx context.Context, uid string) (Profile, error) {
+	u, err := f.client.GetUser(ctx, uid)
+	if err != nil {
+		if auth.IsUserNotFound(err) {
+			return Profile{}, ErrUserNotFound
+		}
+		return Profile{}, err
+	}
+	return Profile{Name: u.DisplayName, Email: u.Email, PhotoURL: u.PhotoURL}, nil
+}
+
+// getUsersBatchSize is the most identifiers GetUsers accepts per call.
+const getUsersBatchSize = 100
+
+// TokensValidAfter reports when each user's refresh tokens were last revoked.
+func (f *Firebase) TokensValidAfter(ctx context.Context, uids []string) (map[string]time.Time, error) {
+	validAfter := make(map[string]time.Time, len(uids))
+	for start := 0; start < len(uids); start += getUsersBatchSize {
+		end := min(start+getUsersBatchSize, len(uids))
+		identifiers := make([]auth.UserIdentifier, 0, end-start)
+		for _, uid := range uids[start:end] {
+			identifiers = append(identifiers, auth.UIDIdentifier{UID: uid})
+		}
+		result, err := f.client.GetUsers(ctx, identifiers)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range result.Users {
+			validAfter[u.UID] = time.UnixMilli(u.TokensValidAfterMillis)
+		}
+	}
+	return validAfter, nil
+}
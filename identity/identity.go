@@ -0,0 +1,88 @@
+// Package identity verifies bearer tokens and looks up the profile of the
+// player behind them. Firebase is the default provider; JWT accepts tokens
+// from any issuer that publishes a JWKS, including a self-hosted one or the
+// Firebase Auth emulator.
+package identity
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid identity token")
+	ErrUserNotFound = errors.New("identity user not found")
+)
+
+// Profile is what the identity provider knows about a player.
+type Profile struct {
+	Name     string
+	Email    string
+	PhotoURL string
+}
+
+// Token is a verified ID token.
+type Token struct {
+	UID      string
+	IssuedAt time.Time
+	Expires  time.Time
+	Claims   map[string]interface{}
+	// Profile is the player's profile when the token's claims carry one,
+	// so sign-in need not ask the provider for it. Nil otherwise.
+	Profile *Profile
+	// Role is the player's resolved role when a CachedProvider with a
+	// ResolveRole resolved it, cached for as long as the token. Empty
+	// otherwise.
+	Role Role
+}
+
+// profileFromClaims reads the standard name, email and picture claims, or
+// returns nil when the token carries none of them.
+func profileFromClaims(claims map[string]interface{}) *Profile {
+	profile := Profile{
+		Name:     stringClaim(claims, "name"),
+		Email:    stringClaim(claims, "email"),
+		PhotoURL: stringClaim(claims, "picture"),
+	}
+	if profile == (Profile{}) {
+		return nil
+	}
+	return &profile
+}
+
+func stringClaim(claims map[string]interface{}, name string) string {
+	s, _ := claims[name].(string)
+	return s
+}
+
+type tokenKey struct{}
+
+// NewContext returns a copy of ctx that carries the verified token.
+func NewContext(ctx context.Context, token *Token) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// TokenFromContext returns the token stored in ctx by NewContext, if any.
+func TokenFromContext(ctx context.Context) (*Token, bool) {
+	token, ok := ctx.Value(tokenKey{}).(*Token)
+	return token, ok
+}
+
+// Provider is the IdentityProvider the auth middleware and sign-in flow
+// depend on.
+type Provider interface {
+	// VerifyToken checks idToken's signature and claims.
+	VerifyToken(ctx context.Context, idToken string) (*Token, error)
+	// GetProfile returns the profile for uid. Callers holding a Token
+	// with a Profile should use that instead.
+	GetProfile(ctx context.Context, uid string) (Profile, error)
+}
+
+// RevocationChecker is implemented by providers that can revoke tokens
+// before they expire. For each of uids it returns the time before which
+// that user's tokens are no longer valid; users that no longer exist are
+// omitted from the result.
+type RevocationChecker interface {
+	TokensValidAfter(ctx context.Context, uids []string) (map[string]time.Time, error)
+}
@@ -0,0 +1,133 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/MicahParks/keyfunc"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	jwksRefreshInterval  = time.Hour
+	jwksRefreshRateLimit = 5 * time.Minute
+	jwksRefreshTimeout   = 10 * time.Second
+)
+
+// signedMethods are the algorithms accepted from a JWKS. Symmetric
+// algorithms are excluded so a public key can never be used as an HMAC secret.
+var signedMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+type JWTConfig struct {
+	// JWKSURL is where the issuer publishes its signing keys.
+	JWKSURL string
+	// Issuer and Audience must match the token's iss and aud claims.
+	Issuer   string
+	Audience string
+	// AllowUnsigned accepts tokens with alg "none", which is what the
+	// Firebase Auth emulator issues. Never enable it against a real issuer.
+	AllowUnsigned bool
+}
+
+// JWT verifies tokens signed by keys from a JWKS. An issuer publishes no
+// user lookup, so the player's profile is only known from the name, email
+// and picture claims of their token (Token.Profile).
+type JWT struct {
+	config JWTConfig
+	jwks   *keyfunc.JWKS
+	parser *jwt.Parser
+}
+
+func NewJWT(config JWTConfig) (*JWT, error) {
+	if config.Issuer == "" || config.Audience == "" {
+		return nil, errors.New("jwt identity provider needs an issuer and an audience")
+	}
+	if config.JWKSURL == "" && !config.AllowUnsigned {
+		return nil, errors.New("jwt identity provider needs a JWKS URL")
+	}
+
+	methods := []string{}
+	var jwks *keyfunc.JWKS
+	if config.JWKSURL != "" {
+		var err error
+		jwks, err = keyfunc.Get(config.JWKSURL, keyfunc.Options{
+			RefreshInterval:   jwksRefreshInterval,
+			RefreshRateLimit:  jwksRefreshRateLimit,
+			RefreshTimeout:    jwksRefreshTimeout,
+			RefreshUnknownKID: true,
+			RefreshErrorHandler: func(err error) {
+				log.Printf("jwks refresh from %s failed: %v", config.JWKSURL, err)
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("load JWKS from %s: %w", config.JWKSURL, err)
+		}
+		methods = append(methods, signedMethods...)
+	}
+	if config.AllowUnsigned {
+		methods = append(methods, jwt.SigningMethodNone.Alg())
+	}
+
+	return &JWT{
+		config: config,
+		jwks:   jwks,
+		parser: jwt.NewParser(jwt.WithValidMethods(methods)),
+	}, nil
+}
+
+// Close stops the background JWKS refresh.
+func (j *JWT) Close() {
+	if j.jwks != nil {
+		j.jwks.EndBackground()
+	}
+}
+
+func (j *JWT) keyFor(token *jwt.Token) (interface{}, error) {
+	if token.Method == jwt.SigningMethodNone {
+		return jwt.UnsafeAllowNoneSignatureType, nil
+	}
+	return j.jwks.Keyfunc(token)
+}
+
+func (j *JWT) VerifyToken(ctx context.Context, idToken string) (*Token, error) {
+	claims := jwt.MapClaims{}
+	if _, err := j.parser.ParseWithClaims(idToken, claims, j.keyFor); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	exp, hasExp := numericClaim(claims, "exp")
+	iat, _ := numericClaim(claims, "iat")
+	sub, _ := claims["sub"].(string)
+	switch {
+	case !hasExp:
+		return nil, fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	case !claims.VerifyIssuer(j.config.Issuer, true):
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	case !claims.VerifyAudience(j.config.Audience, true):
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	case sub == "" || len(sub) > 128:
+		return nil, fmt.Errorf("%w: invalid subject", ErrInvalidToken)
+	}
+
+	return &Token{UID: sub, IssuedAt: iat, Expires: exp, Claims: claims, Profile: profileFromClaims(claims)}, nil
+}
+
+func numericClaim(claims jwt.MapClaims, name string) (time.Time, bool) {
+	switch v := claims[name].(type) {
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case json.Number:
+		n, err := v.Int64()
+		return time.Unix(n, 0), err == nil
+	}
+	return time.Time{}, false
+}
+
+// GetProfile always reports ErrUserNotFound; use the verified Token's
+// Profile instead.
+func (j *JWT) GetProfile(ctx context.Context, uid string) (Profile, error) {
+	return Profile{}, ErrUserNotFound
+}
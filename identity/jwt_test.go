@@ -0,0 +1,78 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// unsignedToken builds an alg "none" token, as the Firebase Auth emulator
+// issues, with the issuer and audience newTestJWT expects.
+func unsignedToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	claims["iss"] = "https://issuer.example.com"
+	claims["aud"] = "notakto"
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	raw, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func newTestJWT(t *testing.T) *JWT {
+	t.Helper()
+	j, err := NewJWT(JWTConfig{Issuer: "https://issuer.example.com", Audience: "notakto", AllowUnsigned: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(j.Close)
+	return j
+}
+
+func TestJWTProfileFromClaims(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   *Profile
+	}{
+		{"all claims", jwt.MapClaims{"sub": "u1", "name": "Ada", "email": "ada@example.com", "picture": "https://example.com/ada.png"},
+			&Profile{Name: "Ada", Email: "ada@example.com", PhotoURL: "https://example.com/ada.png"}},
+		{"email only", jwt.MapClaims{"sub": "u1", "email": "ada@example.com"}, &Profile{Email: "ada@example.com"}},
+		{"no profile claims", jwt.MapClaims{"sub": "u1"}, nil},
+	}
+	j := newTestJWT(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := j.VerifyToken(context.Background(), unsignedToken(t, tt.claims))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (token.Profile == nil) != (tt.want == nil) || (tt.want != nil && *token.Profile != *tt.want) {
+				t.Fatalf("Profile = %+v, want %+v", token.Profile, tt.want)
+			}
+		})
+	}
+	if _, err := j.GetProfile(context.Background(), "u1"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GetProfile error = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestCachedProviderKeepsProfile(t *testing.T) {
+	c := NewCachedProvider(newTestJWT(t), CacheConfig{Size: 10})
+	defer c.Close()
+	raw := unsignedToken(t, jwt.MapClaims{"sub": "u1", "name": "Ada"})
+	for _, attempt := range []string{"miss", "hit"} {
+		token, err := c.VerifyToken(context.Background(), raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.Profile == nil || token.Profile.Name != "Ada" {
+			t.Fatalf("%s: Profile = %+v, want the name claim", attempt, token.Profile)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package identity
+
+// Role is what a player is authorized to do. Roles are ordered: each one
+// includes everything the roles below it may do.
+type Role string
+
+const (
+	RolePlayer  Role = "player"
+	RoleSupport Role = "support"
+	RoleAdmin   Role = "admin"
+)
+
+// roleClaim is the custom claim a provider may carry the role in, e.g. one
+// set with the Firebase Admin SDK's SetCustomUserClaims.
+const roleClaim = "role"
+
+var roleRank = map[Role]int{
+	RolePlayer:  1,
+	RoleSupport: 2,
+	RoleAdmin:   3,
+}
+
+// ParseRole reports whether s names a known role.
+func ParseRole(s string) (Role, bool) {
+	role := Role(s)
+	_, ok := roleRank[role]
+	return role, ok
+}
+
+// Includes reports whether r may do everything required may.
+func (r Role) Includes(required Role) bool {
+	rank, ok := roleRank[r]
+	return ok && rank >= roleRank[required]
+}
+
+// ClaimedRole returns the role carried in the token's custom claims, if any.
+func (t *Token) ClaimedRole() (Role, bool) {
+	s, _ := t.Claims[roleClaim].(string)
+	return ParseRole(s)
+}
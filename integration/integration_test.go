@@ -0,0 +1,519 @@
+// Package integration drives the HTTP API end to end: the real routes,
+// middleware, handlers and usecases, with fakes for the identity provider,
+// Valkey and NOWPayments. The flows run against the in-memory repository,
+// and against Postgres when TEST_DATABASE_URL is set.
+package integration
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+
+	"github.com/rakshitg600/notakto-solo/config"
+	"github.com/rakshitg600/notakto-solo/game"
+	"github.com/rakshitg600/notakto-solo/handlers"
+	"github.com/rakshitg600/notakto-solo/identity"
+	"github.com/rakshitg600/notakto-solo/nowpayments"
+	"github.com/rakshitg600/notakto-solo/repository"
+	"github.com/rakshitg600/notakto-solo/routes"
+	"github.com/rakshitg600/notakto-solo/usecase"
+)
+
+const (
+	npAPIKey       = "test-api-key"
+	ipnSecret      = "test-ipn-secret"
+	keepaliveToken = "test-keepalive-token"
+)
+
+// fakeIdentity accepts "token-<uid>" for every uid it has a profile for.
+// Like the JWT provider, it has no profile lookup: the profile only travels
+// in the verified token.
+type fakeIdentity struct {
+	mu       sync.Mutex
+	profiles map[string]identity.Profile
+}
+
+func (f *fakeIdentity) add(uid string, profile identity.Profile) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.profiles[uid] = profile
+	return "token-" + uid
+}
+
+func (f *fakeIdentity) VerifyToken(ctx context.Context, idToken string) (*identity.Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	uid, ok := strings.CutPrefix(idToken, "token-")
+	profile, known := f.profiles[uid]
+	if !ok || !known {
+		return nil, identity.ErrInvalidToken
+	}
+	now := time.Now()
+	return &identity.Token{UID: uid, IssuedAt: now, Expires: now.Add(time.Hour), Claims: map[string]interface{}{}, Profile: &profile}, nil
+}
+
+func (f *fakeIdentity) GetProfile(ctx context.Context, uid string) (identity.Profile, error) {
+	return identity.Profile{}, identity.ErrUserNotFound
+}
+
+// fakeNOWPayments serves the invoice endpoint and remembers the invoices it
+// created, by order id.
+type fakeNOWPayments struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	invoices map[string]nowpayments.InvoiceRequest
+}
+
+func newFakeNOWPayments(t *testing.T) *fakeNOWPayments {
+	t.Helper()
+	f := &fakeNOWPayments{invoices: make(map[string]nowpayments.InvoiceRequest)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /invoice", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != npAPIKey {
+			http.Error(w, `{"message":"Invalid api key"}`, http.StatusForbidden)
+			return
+		}
+		var req nowpayments.InvoiceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.invoices[req.OrderID] = req
+		id := fmt.Sprint(4000 + len(f.invoices))
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(nowpayments.InvoiceResponse{
+			ID:         id,
+			OrderID:    req.OrderID,
+			InvoiceURL: f.URL + "/pay/" + id,
+		})
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeNOWPayments) invoice(orderID string) (nowpayments.InvoiceRequest, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	req, ok := f.invoices[orderID]
+	return req, ok
+}
+
+type testServer struct {
+	*httptest.Server
+	identity *fakeIdentity
+	valkey   *fakeValkey
+	np       *fakeNOWPayments
+}
+
+// newTestServer serves the API over repo. pool is only used by the
+// keepalive route and may be nil.
+func newTestServer(t *testing.T, pool *pgxpool.Pool, repo repository.Repository) *testServer {
+	t.Helper()
+	configs, err := usecase.NewConfigCache(context.Background(), repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := &fakeIdentity{profiles: make(map[string]identity.Profile)}
+	tokens := identity.NewCachedProvider(ids, identity.CacheConfig{
+		Size: 100,
+		ResolveRole: func(ctx context.Context, token *identity.Token) (identity.Role, error) {
+			return usecase.ResolveRole(ctx, repo, token)
+		},
+	})
+	t.Cleanup(tokens.Close)
+	valkey, valkeyClient := newFakeValkey(t)
+	np := newFakeNOWPayments(t)
+
+	e := echo.New()
+	e.HideBanner = true
+	routes.SetupRoutes(e, pool, repo, configs, usecase.NewWalletReconciler(repo, nil, 100), tokens, valkeyClient,
+		nowpayments.NewClient(npAPIKey, np.URL), ipnSecret, keepaliveToken)
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+	return &testServer{Server: srv, identity: ids, valkey: valkey, np: np}
+}
+
+// call sends body as JSON with token as the bearer token, decodes the
+// response into out when it is not nil, and returns the status.
+func (s *testServer) call(t *testing.T, method string, path string, token string, body any, out any) int {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decode response: %v", method, path, err)
+		}
+	} else if out, ok := out.(*handlers.ErrorResponse); ok {
+		json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp.StatusCode
+}
+
+// ipn posts a NOWPayments callback for orderID, signed with secret.
+func (s *testServer) ipn(t *testing.T, secret string, orderID string, status string) int {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{
+		"payment_id":     5077125051,
+		"invoice_id":     4001,
+		"order_id":       orderID,
+		"payment_status": status,
+		"price_amount":   4.99,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac := hmac.New(sha512.New, []byte(secret))
+	mac.Write(body) // json.Marshal already sorts map keys, as NOWPayments does
+	req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/nowpayments-webhook", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-nowpayments-sig", hex.EncodeToString(mac.Sum(nil)))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// newUID returns a uid no earlier run has used, so the flows can share a
+// database.
+func newUID(t *testing.T) string {
+	t.Helper()
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return "it-" + hex.EncodeToString(b)
+}
+
+// testFlows runs every flow against s.
+func testFlows(t *testing.T, s *testServer) {
+	t.Run("player", func(t *testing.T) { testPlayerFlow(t, s) })
+	t.Run("game reward", func(t *testing.T) { testGameRewardFlow(t, s) })
+	t.Run("paid moves", func(t *testing.T) { testPaidMovesFlow(t, s) })
+}
+
+// signInNewPlayer signs a new player in and returns their token.
+func signInNewPlayer(t *testing.T, s *testServer) string {
+	t.Helper()
+	uid := newUID(t)
+	token := s.identity.add(uid, identity.Profile{Name: "Grace", Email: uid + "@example.com"})
+	var signIn handlers.SignInResponse
+	if status := s.call(t, http.MethodPost, "/v1/sign-in", token, nil, &signIn); status != http.StatusOK || !signIn.NewAccount {
+		t.Fatalf("sign-in: status %d, %+v", status, signIn)
+	}
+	return token
+}
+
+// getWallet returns the wallet of the player with token.
+func (s *testServer) getWallet(t *testing.T, token string) handlers.GetWalletResponse {
+	t.Helper()
+	var wallet handlers.GetWalletResponse
+	if status := s.call(t, http.MethodGet, "/v1/get-wallet", token, nil, &wallet); status != http.StatusOK || !wallet.Success {
+		t.Fatalf("get-wallet: status %d, %+v", status, wallet)
+	}
+	return wallet
+}
+
+// firstLegalMove returns the lowest free cell on a live board, given the
+// move log the API returned.
+func firstLegalMove(t *testing.T, setup handlers.CreateGameRequest, boards []int32, isAiMove []bool) (boardIndex int32, cellIndex int32) {
+	t.Helper()
+	g, err := game.New(game.Config{NumberOfBoards: setup.NumberOfBoards, BoardSize: setup.BoardSize, Difficulty: setup.Difficulty}, boards, isAiMove)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for b := range setup.NumberOfBoards {
+		for c := range setup.BoardSize * setup.BoardSize {
+			if g.Legal(b, c) == nil {
+				return b, c
+			}
+		}
+	}
+	t.Fatal("no legal move left")
+	return 0, 0
+}
+
+// testPlayerFlow signs a new player in, plays a few moves, buys coins and
+// has the payment confirmed by an IPN.
+func testPlayerFlow(t *testing.T, s *testServer) {
+	uid := newUID(t)
+	token := s.identity.add(uid, identity.Profile{Name: "Ada", Email: uid + "@example.com", PhotoURL: "https://example.com/ada.png"})
+
+	// Authentication
+	var apiErr handlers.ErrorResponse
+	if status := s.call(t, http.MethodPost, "/v1/sign-in", "", nil, &apiErr); status != http.StatusUnauthorized {
+		t.Fatalf("sign-in without a token: status %d, want 401", status)
+	}
+	if status := s.call(t, http.MethodPost, "/v1/sign-in", "token-nobody", nil, &apiErr); status != http.StatusUnauthorized {
+		t.Fatalf("sign-in with an unknown token: status %d, want 401", status)
+	}
+
+	// Sign-in creates the player from the identity profile, once
+	var signIn handlers.SignInResponse
+	if status := s.call(t, http.MethodPost, "/v1/sign-in", token, nil, &signIn); status != http.StatusOK {
+		t.Fatalf("sign-in: status %d", status)
+	}
+	if signIn.Uid != uid || signIn.Name != "Ada" || signIn.Email != uid+"@example.com" || !signIn.NewAccount {
+		t.Fatalf("sign-in = %+v", signIn)
+	}
+	if s.call(t, http.MethodPost, "/v1/sign-in", token, nil, &signIn); signIn.NewAccount {
+		t.Fatal("second sign-in created the account again")
+	}
+	var walletBefore handlers.GetWalletResponse
+	if status := s.call(t, http.MethodGet, "/v1/get-wallet", token, nil, &walletBefore); status != http.StatusOK || !walletBefore.Success {
+		t.Fatalf("get-wallet: status %d, %+v", status, walletBefore)
+	}
+	if walletBefore.Coins != int64(config.DefaultSignUpConfig().InitialCoins) {
+		t.Fatalf("new wallet holds %d coins, want the sign-up grant %d", walletBefore.Coins, config.DefaultSignUpConfig().InitialCoins)
+	}
+
+	// A game
+	var game handlers.CreateGameResponse
+	createGame := handlers.CreateGameRequest{NumberOfBoards: 2, BoardSize: 3, Difficulty: 1}
+	if status := s.call(t, http.MethodPost, "/v1/create-game", token, createGame, &game); status != http.StatusOK {
+		t.Fatalf("create-game: status %d", status)
+	}
+	if game.SessionId == "" || len(game.Boards) != 0 || game.Gameover {
+		t.Fatalf("create-game = %+v", game)
+	}
+	var move handlers.MakeMoveResponse
+	makeMove := handlers.MakeMoveRequest{SessionID: game.SessionId, BoardIndex: 0, CellIndex: 4}
+	if status := s.call(t, http.MethodPost, "/v1/make-move", token, makeMove, &move); status != http.StatusOK {
+		t.Fatalf("make-move: status %d", status)
+	}
+	if len(move.Boards) != 2 || move.Boards[0] != 4 || move.IsAiMove[0] || !move.IsAiMove[1] {
+		t.Fatalf("make-move = %+v, want the move and an AI reply", move)
+	}
+	apiErr = handlers.ErrorResponse{}
+	if status := s.call(t, http.MethodPost, "/v1/make-move", token, makeMove, &apiErr); status != http.StatusUnprocessableEntity || apiErr.Code != usecase.ErrCellOccupied.Code {
+		t.Fatalf("repeated make-move: status %d, %+v", status, apiErr)
+	}
+
+	// A purchase, confirmed by IPN
+	var packages handlers.GetAllPackagesResponse
+	if status := s.call(t, http.MethodGet, "/v1/all-packages", token, nil, &packages); status != http.StatusOK || len(packages.CoinPackages) == 0 {
+		t.Fatalf("all-packages: status %d, %+v", status, packages)
+	}
+	pkg := packages.CoinPackages[0]
+	var charge handlers.CreateChargeResponse
+	if status := s.call(t, http.MethodPost, "/v1/create-charge", token, handlers.CreateChargeRequest{PackageID: pkg.PackageID}, &charge); status != http.StatusOK {
+		t.Fatalf("create-charge: status %d", status)
+	}
+	invoice, ok := s.np.invoice(charge.ChargeID)
+	if !ok || invoice.PriceAmount != float64(pkg.AmountCents)/100 || !strings.HasPrefix(charge.HostedURL, s.np.URL+"/pay/") {
+		t.Fatalf("create-charge = %+v, invoice %+v (found %v)", charge, invoice, ok)
+	}
+	paymentStatus := func() handlers.PaymentStatusResponse {
+		t.Helper()
+		var resp handlers.PaymentStatusResponse
+		if status := s.call(t, http.MethodGet, "/v1/payment-status?chargeId="+charge.ChargeID, token, nil, &resp); status != http.StatusOK {
+			t.Fatalf("payment-status: status %d", status)
+		}
+		return resp
+	}
+	if got := paymentStatus(); got.Status != "created" || got.Coins != pkg.Coins {
+		t.Fatalf("payment-status before the IPN = %+v", got)
+	}
+	if status := s.ipn(t, "wrong-secret", charge.ChargeID, "finished"); status != http.StatusUnauthorized {
+		t.Fatalf("IPN with a bad signature: status %d, want 401", status)
+	}
+	for _, ipnStatus := range []string{"waiting", "finished", "finished"} {
+		if status := s.ipn(t, ipnSecret, charge.ChargeID, ipnStatus); status != http.StatusOK {
+			t.Fatalf("IPN %s: status %d", ipnStatus, status)
+		}
+	}
+	if got := paymentStatus(); got.Status != "confirmed" {
+		t.Fatalf("payment-status after the IPN = %+v", got)
+	}
+	var walletAfter handlers.GetWalletResponse
+	s.call(t, http.MethodGet, "/v1/get-wallet", token, nil, &walletAfter)
+	if walletAfter.Coins != walletBefore.Coins+int64(pkg.Coins) {
+		t.Fatalf("wallet holds %d coins after buying %d, want %d", walletAfter.Coins, pkg.Coins, walletBefore.Coins+int64(pkg.Coins))
+	}
+
+	if locks := s.valkey.keys("lock:uid:"); len(locks) != 0 {
+		t.Fatalf("uid locks left behind: %v", locks)
+	}
+}
+
+// testGameRewardFlow plays a game to the end and checks that the reward it
+// reports reaches the wallet.
+func testGameRewardFlow(t *testing.T, s *testServer) {
+	token := signInNewPlayer(t, s)
+	before := s.getWallet(t, token)
+
+	setup := handlers.CreateGameRequest{NumberOfBoards: 1, BoardSize: 3, Difficulty: 1}
+	var created handlers.CreateGameResponse
+	if status := s.call(t, http.MethodPost, "/v1/create-game", token, setup, &created); status != http.StatusOK {
+		t.Fatalf("create-game: status %d", status)
+	}
+	var move handlers.MakeMoveResponse
+	for !move.Gameover {
+		if len(move.Boards) >= 9 {
+			t.Fatalf("game not over after %d moves: %+v", len(move.Boards), move)
+		}
+		boardIndex, cellIndex := firstLegalMove(t, setup, move.Boards, move.IsAiMove)
+		makeMove := handlers.MakeMoveRequest{SessionID: created.SessionId, BoardIndex: boardIndex, CellIndex: cellIndex}
+		if status := s.call(t, http.MethodPost, "/v1/make-move", token, makeMove, &move); status != http.StatusOK {
+			t.Fatalf("make-move: status %d", status)
+		}
+	}
+
+	// base is difficulty x boards x size; a win multiplies it by a roll
+	base := int64(setup.Difficulty * setup.NumberOfBoards * setup.BoardSize)
+	if move.Winner {
+		if move.CoinsRewarded < base || move.CoinsRewarded > 6*base || move.XpRewarded < 6*base || move.XpRewarded > 11*base {
+			t.Fatalf("won game paid %d coins, %d xp", move.CoinsRewarded, move.XpRewarded)
+		}
+	} else if move.CoinsRewarded != 0 || move.XpRewarded != base {
+		t.Fatalf("lost game paid %d coins, %d xp, want 0 and %d", move.CoinsRewarded, move.XpRewarded, base)
+	}
+	after := s.getWallet(t, token)
+	if after.Coins != before.Coins+move.CoinsRewarded || after.XP != before.XP+move.XpRewarded {
+		t.Fatalf("wallet went from %d coins, %d xp to %d, %d after a reward of %d, %d",
+			before.Coins, before.XP, after.Coins, after.XP, move.CoinsRewarded, move.XpRewarded)
+	}
+
+	var apiErr handlers.ErrorResponse
+	finished := handlers.MakeMoveRequest{SessionID: created.SessionId}
+	if status := s.call(t, http.MethodPost, "/v1/make-move", token, finished, &apiErr); status != http.StatusConflict || apiErr.Code != usecase.ErrGameOver.Code {
+		t.Fatalf("make-move after game over: status %d, %+v", status, apiErr)
+	}
+}
+
+// testPaidMovesFlow skips and undoes moves until the player runs out of
+// coins. A skip costs 200 coins and an undo 100.
+func testPaidMovesFlow(t *testing.T, s *testServer) {
+	const skipCost, undoCost = 200, 100
+	token := signInNewPlayer(t, s)
+	coins := s.getWallet(t, token).Coins
+	if coins != int64(config.DefaultSignUpConfig().InitialCoins) {
+		t.Fatalf("new wallet holds %d coins, want the sign-up grant %d", coins, config.DefaultSignUpConfig().InitialCoins)
+	}
+
+	// Enough boards that the AI's extra moves cannot end the game
+	setup := handlers.CreateGameRequest{NumberOfBoards: 5, BoardSize: 3, Difficulty: 1}
+	var created handlers.CreateGameResponse
+	if status := s.call(t, http.MethodPost, "/v1/create-game", token, setup, &created); status != http.StatusOK {
+		t.Fatalf("create-game: status %d", status)
+	}
+	var boards []int32
+	var isAiMove []bool
+	wantCoins := func(step string) {
+		t.Helper()
+		if got := s.getWallet(t, token).Coins; got != coins {
+			t.Fatalf("after %s: wallet holds %d coins, want %d", step, got, coins)
+		}
+	}
+	skip := func() {
+		t.Helper()
+		var resp handlers.SkipMoveResponse
+		if status := s.call(t, http.MethodPost, "/v1/skip-move", token, handlers.SkipMoveRequest{SessionID: created.SessionId}, &resp); status != http.StatusOK {
+			t.Fatalf("skip-move with %d coins: status %d", coins, status)
+		}
+		boards, isAiMove = resp.Boards, resp.IsAiMove
+		coins -= skipCost
+		wantCoins("skip-move")
+	}
+	undo := func() {
+		t.Helper()
+		var resp handlers.UndoMoveResponse
+		if status := s.call(t, http.MethodPost, "/v1/undo-move", token, handlers.UndoMoveRequest{SessionID: created.SessionId}, &resp); status != http.StatusOK {
+			t.Fatalf("undo-move with %d coins: status %d", coins, status)
+		}
+		boards, isAiMove = resp.Boards, resp.IsAiMove
+		coins -= undoCost
+		wantCoins("undo-move")
+	}
+	makeMove := func() {
+		t.Helper()
+		boardIndex, cellIndex := firstLegalMove(t, setup, boards, isAiMove)
+		var resp handlers.MakeMoveResponse
+		req := handlers.MakeMoveRequest{SessionID: created.SessionId, BoardIndex: boardIndex, CellIndex: cellIndex}
+		if status := s.call(t, http.MethodPost, "/v1/make-move", token, req, &resp); status != http.StatusOK {
+			t.Fatalf("make-move: status %d", status)
+		}
+		boards, isAiMove = resp.Boards, resp.IsAiMove
+	}
+
+	// A skip lets the AI move; an undo takes back the player's move and the
+	// AI's reply
+	skip()
+	if len(boards) != 1 || !isAiMove[0] {
+		t.Fatalf("skip-move left %v %v, want one AI move", boards, isAiMove)
+	}
+	skippedBoards, skippedIsAiMove := boards, isAiMove
+	makeMove()
+	undo()
+	if !slices.Equal(boards, skippedBoards) || !slices.Equal(isAiMove, skippedIsAiMove) {
+		t.Fatalf("undo-move left %v %v, want %v %v", boards, isAiMove, skippedBoards, skippedIsAiMove)
+	}
+
+	// Spend down to less than a skip, then less than an undo; the refused
+	// action charges nothing
+	makeMove()
+	for coins >= skipCost {
+		skip()
+	}
+	var apiErr handlers.ErrorResponse
+	if status := s.call(t, http.MethodPost, "/v1/skip-move", token, handlers.SkipMoveRequest{SessionID: created.SessionId}, &apiErr); status != http.StatusPaymentRequired || apiErr.Code != usecase.ErrInsufficientCoins.Code {
+		t.Fatalf("skip-move with %d coins: status %d, %+v", coins, status, apiErr)
+	}
+	wantCoins("refused skip-move")
+	for coins >= undoCost {
+		undo()
+	}
+	apiErr = handlers.ErrorResponse{}
+	if status := s.call(t, http.MethodPost, "/v1/undo-move", token, handlers.UndoMoveRequest{SessionID: created.SessionId}, &apiErr); status != http.StatusPaymentRequired || apiErr.Code != usecase.ErrInsufficientCoins.Code {
+		t.Fatalf("undo-move with %d coins: status %d, %+v", coins, status, apiErr)
+	}
+	wantCoins("refused undo-move")
+}
+
+func TestPlayerFlowMemory(t *testing.T) {
+	repo := repository.NewMemory()
+	testFlows(t, newTestServer(t, nil, repo))
+}
@@ -0,0 +1,48 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+// newTestPool migrates the database at TEST_DATABASE_URL with goose and
+// connects to it the way main does. The test is skipped when the variable
+// is unset. Every flow uses fresh uids, so the database need not be empty.
+func newTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	goose, err := exec.LookPath("goose")
+	if err != nil {
+		t.Fatal("TEST_DATABASE_URL is set but goose is not installed: ", err)
+	}
+	if out, err := exec.Command(goose, "-dir", "../db/migrations", "postgres", dbURL, "up").CombinedOutput(); err != nil {
+		t.Fatalf("goose up: %v\n%s", err, out)
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poolConfig.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestPlayerFlowPostgres(t *testing.T) {
+	pool := newTestPool(t)
+	testFlows(t, newTestServer(t, pool, repository.NewPostgres(pool)))
+}
@@ -0,0 +1,148 @@
+package integration
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/rakshitg600/notakto-solo/lua"
+)
+
+// fakeValkey is just enough of a Valkey server for the middleware: SET with
+// NX, and the scripts in package lua run natively. Expiry is not modelled
+// and the rate limit never trips.
+type fakeValkey struct {
+	ln net.Listener
+
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// newFakeValkey starts a fakeValkey and returns it with a client for it.
+func newFakeValkey(t *testing.T) (*fakeValkey, *redis.Client) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &fakeValkey{ln: ln, values: make(map[string]string)}
+	go v.serve()
+	client := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), Protocol: 2, DisableIdentity: true})
+	t.Cleanup(func() {
+		client.Close()
+		ln.Close()
+	})
+	return v, client
+}
+
+// keys returns the keys starting with prefix.
+func (v *fakeValkey) keys(prefix string) []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	var keys []string
+	for key := range v.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (v *fakeValkey) serve() {
+	for {
+		conn, err := v.ln.Accept()
+		if err != nil {
+			return
+		}
+		go v.handle(conn)
+	}
+}
+
+func (v *fakeValkey) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, v.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand reads one RESP array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	n, err := readLength(r, '*')
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		size, err := readLength(r, '$')
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func readLength(r *bufio.Reader, kind byte) (int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	if len(line) < 3 || line[0] != kind {
+		return 0, fmt.Errorf("unexpected RESP line %q", line)
+	}
+	return strconv.Atoi(strings.TrimSpace(line[1:]))
+}
+
+func (v *fakeValkey) exec(args []string) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "SET":
+		for _, opt := range args[3:] {
+			if _, ok := v.values[args[1]]; ok && strings.EqualFold(opt, "NX") {
+				return "$-1\r\n"
+			}
+		}
+		v.values[args[1]] = args[2]
+		return "+OK\r\n"
+	case "EVALSHA":
+		return "-NOSCRIPT No matching script\r\n"
+	case "EVAL":
+		sum := sha1.Sum([]byte(args[1]))
+		numKeys, _ := strconv.Atoi(args[2])
+		keys, argv := args[3:3+numKeys], args[3+numKeys:]
+		switch hex.EncodeToString(sum[:]) {
+		case lua.Unlock.Hash():
+			if v.values[keys[0]] != argv[0] {
+				return ":0\r\n"
+			}
+			delete(v.values, keys[0])
+			return ":1\r\n"
+		case lua.RateLimit.Hash():
+			return "*2\r\n:0\r\n:0\r\n"
+		}
+		return "-ERR unknown script\r\n"
+	default:
+		return "-ERR unknown command '" + args[0] + "'\r\n"
+	}
+}
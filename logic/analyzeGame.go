@@ -0,0 +1,111 @@
+package logic
+
+// analysisSearchNodeBudget keeps a whole-game replay inside the request
+// deadline; positions it cannot decide are reported as inexact.
+const analysisSearchNodeBudget = defaultSearchNodeBudget / 5
+
+var analysisSearchEngine = &SearchEngine{
+	table:      defaultSearchEngine.table,
+	nodeBudget: analysisSearchNodeBudget,
+}
+
+// PlyAnalysis annotates one move of a finished game.
+type PlyAnalysis struct {
+	Move     int32
+	IsAiMove bool
+	// MoverWinning is whether the position before the move was a win for the mover.
+	MoverWinning bool
+	// Exact is false when either side of the move could not be solved.
+	Exact bool
+	// Blunder marks a move that turned a winning position into a losing one.
+	Blunder bool
+	// BestMove is a winning move the mover had instead, or -1.
+	BestMove int32
+}
+
+type positionEvaluation struct {
+	move    int32
+	winning bool
+	exact   bool
+}
+
+func evaluateForAnalysis(boards []int32, boardSize int32, numberOfBoards int32) positionEvaluation {
+	if boardSize == 3 {
+		move, winning := GetMisereQuotientMove(boards, numberOfBoards)
+		if move == -1 {
+			// The previous mover killed the last board.
+			return positionEvaluation{move: -1, winning: true, exact: true}
+		}
+		return positionEvaluation{move: move, winning: winning, exact: true}
+	}
+	move, winning, exact := analysisSearchEngine.BestMove(boards, boardSize, numberOfBoards)
+	if move == -1 && exact {
+		return positionEvaluation{move: -1, winning: true, exact: true}
+	}
+	return positionEvaluation{move: move, winning: winning, exact: exact}
+}
+
+// AnalyzeGame replays a move log and evaluates every ply for the side that
+// played it. Turns usually alternate, but after a skip the AI moves twice in a
+// row, so isAiMove decides whose turn the position after each move is.
+func AnalyzeGame(boards []int32, isAiMove []bool, boardSize int32, numberOfBoards int32) []PlyAnalysis {
+	evaluations := make([]positionEvaluation, len(boards)+1)
+	for i := range evaluations {
+		evaluations[i] = evaluateForAnalysis(boards[:i], boardSize, numberOfBoards)
+	}
+
+	plies := make([]PlyAnalysis, len(boards))
+	for i, move := range boards {
+		before, after := evaluations[i], evaluations[i+1]
+		ply := PlyAnalysis{
+			Move:         move,
+			IsAiMove:     i < len(isAiMove) && isAiMove[i],
+			MoverWinning: before.winning,
+			Exact:        before.exact && after.exact,
+			BestMove:     -1,
+		}
+		// after is evaluated for whoever moves next: the mover again after a
+		// skip, otherwise the other side. Once the last board is dead the
+		// game is over and the next side is the other one.
+		moverWinsAfter := !after.winning
+		if i+1 < len(isAiMove) && isAiMove[i+1] == ply.IsAiMove {
+			moverWinsAfter = after.winning
+		}
+		if ply.Exact && before.winning && !moverWinsAfter {
+			ply.Blunder = true
+			ply.BestMove = before.move
+		}
+		plies[i] = ply
+	}
+	return plies
+}
+
+// CountBlunders counts the player's moves that threw away a win.
+func CountBlunders(plies []PlyAnalysis) int {
+	blunders := 0
+	for _, ply := range plies {
+		if ply.Blunder && !ply.IsAiMove {
+			blunders++
+		}
+	}
+	return blunders
+}
+
+// GameAccuracy is the percentage of the player's exactly evaluated moves that
+// did not throw away a win. A game with no such moves scores 100.
+func GameAccuracy(plies []PlyAnalysis) float64 {
+	counted, blunders := 0, 0
+	for _, ply := range plies {
+		if ply.IsAiMove || !ply.Exact {
+			continue
+		}
+		counted++
+		if ply.Blunder {
+			blunders++
+		}
+	}
+	if counted == 0 {
+		return 100
+	}
+	return 100 * float64(counted-blunders) / float64(counted)
+}
@@ -0,0 +1,62 @@
+package logic
+
+import "testing"
+
+func TestAnalyzeGameFlagsBlunders(t *testing.T) {
+	// On one 3x3 board the first player wins only by taking the centre.
+	plies := AnalyzeGame([]int32{0}, []bool{false}, 3, 1)
+	if !plies[0].MoverWinning || !plies[0].Blunder || plies[0].BestMove != 4 {
+		t.Fatalf("corner opening = %+v, want a blunder with best move 4", plies[0])
+	}
+	plies = AnalyzeGame([]int32{4}, []bool{false}, 3, 1)
+	if plies[0].Blunder {
+		t.Fatalf("centre opening flagged as a blunder: %+v", plies[0])
+	}
+}
+
+func TestAnalyzeGameAfterSkip(t *testing.T) {
+	// Find an AI move that hands the next turn a winning position: a blunder
+	// when the player moves next, a good move when a skip lets the AI move
+	// again.
+	for first := int32(0); first < 9; first++ {
+		for second := int32(0); second < 9; second++ {
+			boards := []int32{first, second, -1}
+			if first == second {
+				continue
+			}
+			next, _ := GetMisereQuotientMove(boards[:2], 1)
+			if next == -1 {
+				continue
+			}
+			boards[2] = next
+			alternating := AnalyzeGame(boards, []bool{false, true, false}, 3, 1)
+			if !alternating[1].Blunder {
+				continue
+			}
+			skipped := AnalyzeGame(boards, []bool{false, true, true}, 3, 1)
+			if skipped[1].Blunder {
+				t.Fatalf("log %v: AI move before a skip flagged as a blunder: %+v", boards, skipped[1])
+			}
+			if !skipped[2].IsAiMove || alternating[2].IsAiMove {
+				t.Fatalf("log %v: movers not taken from isAiMove", boards)
+			}
+			return
+		}
+	}
+	t.Fatal("no 3x3 position where the AI hands the player a win")
+}
+
+func TestCountBlunders(t *testing.T) {
+	plies := []PlyAnalysis{
+		{Exact: true, Blunder: true},
+		{Exact: true, Blunder: true, IsAiMove: true},
+		{Exact: true},
+		{Exact: true, Blunder: true},
+	}
+	if got := CountBlunders(plies); got != 2 {
+		t.Errorf("CountBlunders = %d, want 2 (AI blunders are not counted)", got)
+	}
+	if got := GameAccuracy(plies); got != 100*1.0/3 {
+		t.Errorf("GameAccuracy = %v, want %v", got, 100*1.0/3)
+	}
+}
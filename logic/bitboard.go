@@ -0,0 +1,211 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// maxBitboardSize is the largest board whose cells fit in a uint32 mask.
+const maxBitboardSize = 5
+
+// ErrUnsupportedBoardSize is returned by NewBoardState for boards that have
+// no bitboard form.
+var ErrUnsupportedBoardSize = errors.New("unsupported board size")
+
+// boardGeometry holds the per-size line masks, centre-first cell order and
+// symmetry lookup tables. Cell (r, c) is bit r*size+c of a board mask.
+type boardGeometry struct {
+	size  int
+	cells int
+	lines []uint32
+	// cellOrder lists cells from most to least central, ties by index.
+	cellOrder []int32
+	// symmetry[s][k][v] is the image under symmetry s of byte k of a mask whose value is v.
+	symmetry [8][][256]uint32
+}
+
+var geometries = func() (g [maxBitboardSize + 1]*boardGeometry) {
+	for size := 1; size <= maxBitboardSize; size++ {
+		g[size] = newBoardGeometry(size)
+	}
+	return g
+}()
+
+// geometryFor returns the precomputed geometry, or nil if boardSize has no bitboard form.
+func geometryFor(boardSize int32) *boardGeometry {
+	if boardSize < 1 || boardSize > maxBitboardSize {
+		return nil
+	}
+	return geometries[boardSize]
+}
+
+func newBoardGeometry(size int) *boardGeometry {
+	g := &boardGeometry{size: size, cells: size * size}
+
+	var mainDiag, antiDiag uint32
+	for i := 0; i < size; i++ {
+		var row, col uint32
+		for j := 0; j < size; j++ {
+			row |= 1 << (i*size + j)
+			col |= 1 << (j*size + i)
+		}
+		g.lines = append(g.lines, row, col)
+		mainDiag |= 1 << (i*size + i)
+		antiDiag |= 1 << (i*size + size - 1 - i)
+	}
+	g.lines = append(g.lines, mainDiag, antiDiag)
+
+	g.cellOrder = make([]int32, g.cells)
+	for cell := range g.cellOrder {
+		g.cellOrder[cell] = int32(cell)
+	}
+	sort.SliceStable(g.cellOrder, func(i, j int) bool {
+		return getCellValue(g.cellOrder[i], int32(size)) > getCellValue(g.cellOrder[j], int32(size))
+	})
+
+	n := size - 1
+	transforms := [8]func(r, c int) (int, int){
+		func(r, c int) (int, int) { return r, c },
+		func(r, c int) (int, int) { return c, n - r },
+		func(r, c int) (int, int) { return n - r, n - c },
+		func(r, c int) (int, int) { return n - c, r },
+		func(r, c int) (int, int) { return r, n - c },
+		func(r, c int) (int, int) { return c, r },
+		func(r, c int) (int, int) { return n - r, c },
+		func(r, c int) (int, int) { return n - c, n - r },
+	}
+	chunks := (g.cells + 7) / 8
+	for s, transform := range transforms {
+		g.symmetry[s] = make([][256]uint32, chunks)
+		for k := 0; k < chunks; k++ {
+			for v := 0; v < 256; v++ {
+				var image uint32
+				for bit := 0; bit < 8; bit++ {
+					cell := k*8 + bit
+					if v&(1<<bit) == 0 || cell >= g.cells {
+						continue
+					}
+					r, c := transform(cell/size, cell%size)
+					image |= 1 << (r*size + c)
+				}
+				g.symmetry[s][k][v] = image
+			}
+		}
+	}
+	return g
+}
+
+func (g *boardGeometry) isDead(mask uint32) bool {
+	for _, line := range g.lines {
+		if mask&line == line {
+			return true
+		}
+	}
+	return false
+}
+
+// canonical returns the smallest mask among the board's dihedral images.
+func (g *boardGeometry) canonical(mask uint32) uint32 {
+	best := mask
+	for s := range g.symmetry {
+		var image uint32
+		for k, table := range g.symmetry[s] {
+			image |= table[(mask>>(8*k))&0xff]
+		}
+		if image < best {
+			best = image
+		}
+	}
+	return best
+}
+
+// BoardState folds the flat move log into one occupied-cell mask per board,
+// so liveness and occupancy checks no longer rescan the log.
+type BoardState struct {
+	geometry *boardGeometry
+	masks    []uint32
+}
+
+// NewBoardState builds the bitboards for a move log. boardSize must be
+// between 1 and 5; moves outside the first numberOfBoards boards are ignored.
+func NewBoardState(boards []int32, boardSize int32, numberOfBoards int32) (BoardState, error) {
+	g := geometryFor(boardSize)
+	if g == nil {
+		return BoardState{}, fmt.Errorf("%w: %d", ErrUnsupportedBoardSize, boardSize)
+	}
+	if numberOfBoards < 0 {
+		return BoardState{}, fmt.Errorf("negative number of boards: %d", numberOfBoards)
+	}
+	return newBoardState(g, boards, numberOfBoards), nil
+}
+
+// newBoardState is NewBoardState for a geometry the caller already resolved.
+func newBoardState(g *boardGeometry, boards []int32, numberOfBoards int32) BoardState {
+	s := BoardState{
+		geometry: g,
+		masks:    make([]uint32, max(numberOfBoards, 0)),
+	}
+	for _, move := range boards {
+		s.Apply(move)
+	}
+	return s
+}
+
+func (s BoardState) cellCount() int32 {
+	return int32(s.geometry.cells)
+}
+
+// IsDead reports whether the board has a completed line.
+func (s BoardState) IsDead(boardIndex int32) bool {
+	return s.geometry.isDead(s.masks[boardIndex])
+}
+
+// IsOccupied reports whether the cell addressed by a global move index is marked.
+func (s BoardState) IsOccupied(move int32) bool {
+	cells := s.cellCount()
+	return s.masks[move/cells]&(1<<(move%cells)) != 0
+}
+
+// IsKillingMove reports whether playing move would complete a line on its board.
+func (s BoardState) IsKillingMove(move int32) bool {
+	cells := s.cellCount()
+	return s.geometry.isDead(s.masks[move/cells] | 1<<(move%cells))
+}
+
+// LiveBoards counts the boards without a completed line.
+func (s BoardState) LiveBoards() int32 {
+	live := int32(0)
+	for _, mask := range s.masks {
+		if !s.geometry.isDead(mask) {
+			live++
+		}
+	}
+	return live
+}
+
+// Apply marks the cell addressed by a global move index.
+func (s *BoardState) Apply(move int32) {
+	cells := s.cellCount()
+	if b := move / cells; move >= 0 && b < int32(len(s.masks)) {
+		s.masks[b] |= 1 << (move % cells)
+	}
+}
+
+// ValidMoves returns every empty cell on a live board, most central first.
+func (s BoardState) ValidMoves() []int32 {
+	cells := s.cellCount()
+	live := make([]bool, len(s.masks))
+	for b, mask := range s.masks {
+		live[b] = !s.geometry.isDead(mask)
+	}
+	moves := make([]int32, 0, len(s.masks)*int(cells))
+	for _, cell := range s.geometry.cellOrder {
+		for b, mask := range s.masks {
+			if live[b] && mask&(1<<cell) == 0 {
+				moves = append(moves, int32(b)*cells+cell)
+			}
+		}
+	}
+	return moves
+}
@@ -0,0 +1,283 @@
+package logic
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// The array functions below are the move-log scans the bitboards replaced,
+// kept as a reference for equivalence tests and benchmarks.
+
+func arrayIsBoardDead(boardIndex int32, boards []int32, boardSize int32) bool {
+	start := boardIndex * boardSize * boardSize
+	end := start + boardSize*boardSize
+	rowCount := make([]int32, boardSize)
+	colCount := make([]int32, boardSize)
+	var mainDiagCount, antiDiagCount int32
+	for _, idx := range boards {
+		if idx < start || idx >= end {
+			continue
+		}
+		local := idx - start
+		r := local / boardSize
+		c := local % boardSize
+		rowCount[r]++
+		colCount[c]++
+		if r == c {
+			mainDiagCount++
+		}
+		if r+c == boardSize-1 {
+			antiDiagCount++
+		}
+	}
+	for i := range boardSize {
+		if rowCount[i] == boardSize || colCount[i] == boardSize {
+			return true
+		}
+	}
+	return mainDiagCount == boardSize || antiDiagCount == boardSize
+}
+
+func arrayValidMoves(boards []int32, boardSize, numberOfBoards int32) []int32 {
+	set := make(map[int32]bool, len(boards))
+	for _, idx := range boards {
+		set[idx] = true
+	}
+	moves := []int32{}
+	for b := int32(0); b < numberOfBoards; b++ {
+		if arrayIsBoardDead(b, boards, boardSize) {
+			continue
+		}
+		boardOffset := b * boardSize * boardSize
+		for i := int32(0); i < boardSize*boardSize; i++ {
+			if global := boardOffset + i; !set[global] {
+				moves = append(moves, global)
+			}
+		}
+	}
+	for i := 0; i < len(moves)-1; i++ {
+		for j := i + 1; j < len(moves); j++ {
+			if getCellValue(moves[j], boardSize) > getCellValue(moves[i], boardSize) {
+				moves[i], moves[j] = moves[j], moves[i]
+			}
+		}
+	}
+	return moves
+}
+
+func arrayIsKillingMove(boards []int32, boardSize int32, move int32) bool {
+	next := make([]int32, len(boards)+1)
+	copy(next, boards)
+	next[len(boards)] = move
+	return arrayIsBoardDead(move/(boardSize*boardSize), next, boardSize)
+}
+
+// arrayBestMove is SearchEngine.BestMove as it was before bitboards: the
+// root position is rebuilt from the move log with the array scans.
+func arrayBestMove(e *SearchEngine, boards []int32, boardSize int32, numberOfBoards int32) (int32, bool, bool) {
+	g := geometryFor(boardSize)
+	moves := arrayValidMoves(boards, boardSize, numberOfBoards)
+	if len(moves) == 0 {
+		return -1, false, true
+	}
+	cells := boardSize * boardSize
+	masks := make([]uint32, numberOfBoards)
+	for _, idx := range boards {
+		if b := idx / cells; b >= 0 && b < numberOfBoards {
+			masks[b] |= 1 << (idx % cells)
+		}
+	}
+	budget := e.nodeBudget
+	solved := true
+	fallback := moves[0]
+	foundFallback := false
+	for _, m := range moves {
+		b := m / cells
+		next := masks[b] | 1<<(m%cells)
+		child := make([]uint32, 0, numberOfBoards)
+		for other, mask := range masks {
+			switch {
+			case int32(other) == b:
+				if !g.isDead(next) {
+					child = append(child, g.canonical(next))
+				}
+			case !g.isDead(mask):
+				child = append(child, g.canonical(mask))
+			}
+		}
+		sort.Slice(child, func(i, j int) bool { return child[i] < child[j] })
+		opponentWins, ok := e.solve(g, child, &budget)
+		if !ok {
+			solved = false
+			continue
+		}
+		if !opponentWins {
+			return m, true, true
+		}
+		if !foundFallback && !arrayIsKillingMove(boards, boardSize, m) {
+			fallback = m
+			foundFallback = true
+		}
+	}
+	return fallback, false, solved
+}
+
+// randomLog plays up to plies random legal moves.
+func randomLog(rng *rand.Rand, boardSize, numberOfBoards int32, plies int) []int32 {
+	var boards []int32
+	for range plies {
+		moves := arrayValidMoves(boards, boardSize, numberOfBoards)
+		if len(moves) == 0 {
+			break
+		}
+		boards = append(boards, moves[rng.IntN(len(moves))])
+	}
+	return boards
+}
+
+func TestBoardStateMatchesArrayPath(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for boardSize := int32(1); boardSize <= maxBitboardSize; boardSize++ {
+		for numberOfBoards := int32(1); numberOfBoards <= 5; numberOfBoards++ {
+			for range 20 {
+				boards := randomLog(rng, boardSize, numberOfBoards, rng.IntN(int(boardSize*boardSize*numberOfBoards)+1))
+				state, err := NewBoardState(boards, boardSize, numberOfBoards)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				live := int32(0)
+				for b := int32(0); b < numberOfBoards; b++ {
+					dead := arrayIsBoardDead(b, boards, boardSize)
+					if state.IsDead(b) != dead {
+						t.Fatalf("size %d log %v: IsDead(%d) = %v, want %v", boardSize, boards, b, !dead, dead)
+					}
+					if !dead {
+						live++
+					}
+				}
+				if state.LiveBoards() != live {
+					t.Fatalf("size %d log %v: LiveBoards = %d, want %d", boardSize, boards, state.LiveBoards(), live)
+				}
+
+				got := state.ValidMoves()
+				want := arrayValidMoves(boards, boardSize, numberOfBoards)
+				for i := 1; i < len(got); i++ {
+					if getCellValue(got[i], boardSize) > getCellValue(got[i-1], boardSize) {
+						t.Fatalf("size %d log %v: ValidMoves %v not most central first", boardSize, boards, got)
+					}
+				}
+				slices.Sort(got)
+				slices.Sort(want)
+				if !slices.Equal(got, want) {
+					t.Fatalf("size %d log %v: ValidMoves = %v, want %v", boardSize, boards, got, want)
+				}
+				for _, m := range want {
+					if state.IsKillingMove(m) != arrayIsKillingMove(boards, boardSize, m) {
+						t.Fatalf("size %d log %v: IsKillingMove(%d) disagrees", boardSize, boards, m)
+					}
+					if state.IsOccupied(m) {
+						t.Fatalf("size %d log %v: valid move %d reported occupied", boardSize, boards, m)
+					}
+				}
+				for _, m := range boards {
+					if !state.IsOccupied(m) {
+						t.Fatalf("size %d log %v: played move %d reported empty", boardSize, boards, m)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestNewBoardStateRejectsUnsupportedSize(t *testing.T) {
+	for _, boardSize := range []int32{-1, 0, maxBitboardSize + 1, 8} {
+		if _, err := NewBoardState(nil, boardSize, 1); !errors.Is(err, ErrUnsupportedBoardSize) {
+			t.Errorf("NewBoardState(size %d) error = %v, want ErrUnsupportedBoardSize", boardSize, err)
+		}
+	}
+	if _, err := NewBoardState(nil, 3, -1); err == nil {
+		t.Error("NewBoardState with a negative number of boards succeeded")
+	}
+	if moves := getValidMoves(nil, 7, 1); len(moves) != 0 {
+		t.Errorf("getValidMoves(size 7) = %v, want none", moves)
+	}
+	if move := (parityStrategy{}).ChooseMove(nil, 0, 1, rand.New(rand.NewPCG(1, 1))); move != -1 {
+		t.Errorf("parity ChooseMove(size 0) = %d, want -1", move)
+	}
+}
+
+func TestSearchEngineMatchesArrayPath(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	for range 30 {
+		boards := randomLog(rng, 4, 2, 8+rng.IntN(10))
+		e := NewSearchEngine(1<<12, defaultSearchNodeBudget)
+		move, winning, solved := e.BestMove(boards, 4, 2)
+		_, wantWinning, wantSolved := arrayBestMove(NewSearchEngine(1<<12, defaultSearchNodeBudget), boards, 4, 2)
+		// Equally central cells are ordered differently, so only the verdict
+		// has to agree, not which of several good moves is returned.
+		if winning != wantWinning || solved != wantSolved {
+			t.Fatalf("log %v: BestMove = (%v, %v), want (%v, %v)", boards, winning, solved, wantWinning, wantSolved)
+		}
+		if valid := arrayValidMoves(boards, 4, 2); len(valid) > 0 && !slices.Contains(valid, move) {
+			t.Fatalf("log %v: BestMove returned illegal move %d", boards, move)
+		}
+	}
+}
+
+// benchmarkLog is a mid-game position: 5 boards of 5x5 after 40 plies.
+func benchmarkLog() []int32 {
+	return randomLog(rand.New(rand.NewPCG(5, 6)), 5, 5, 40)
+}
+
+func BenchmarkBitboard(b *testing.B) {
+	boards := benchmarkLog()
+	b.Run("array", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			killing := 0
+			for _, m := range arrayValidMoves(boards, 5, 5) {
+				if arrayIsKillingMove(boards, 5, m) {
+					killing++
+				}
+			}
+		}
+	})
+	b.Run("bitboard", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			state, _ := NewBoardState(boards, 5, 5)
+			killing := 0
+			for _, m := range state.ValidMoves() {
+				if state.IsKillingMove(m) {
+					killing++
+				}
+			}
+		}
+	})
+}
+
+// BenchmarkSearchEngine measures a BestMove call against a warm table, where
+// rebuilding the root position from the move log dominates.
+func BenchmarkSearchEngine(b *testing.B) {
+	boards := randomLog(rand.New(rand.NewPCG(7, 8)), 4, 3, 20)
+	b.Run("array", func(b *testing.B) {
+		e := NewSearchEngine(defaultTranspositionEntries, defaultSearchNodeBudget)
+		arrayBestMove(e, boards, 4, 3)
+		b.ReportAllocs()
+		for b.Loop() {
+			arrayBestMove(e, boards, 4, 3)
+		}
+	})
+	b.Run("bitboard", func(b *testing.B) {
+		e := NewSearchEngine(defaultTranspositionEntries, defaultSearchNodeBudget)
+		e.BestMove(boards, 4, 3)
+		b.ReportAllocs()
+		for b.Loop() {
+			e.BestMove(boards, 4, 3)
+		}
+	})
+}
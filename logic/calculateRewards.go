@@ -1,19 +1,48 @@
 package logic
 
-import "math/rand/v2"
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+)
 
-func CalculateRewards(NumberOfBoards int32, BoardSize int32, DifficultyLevel int32, win bool) (coinsReward int32, xpReward int32) {
+// ErrRewardOverflow is returned when a reward does not fit in an int64.
+var ErrRewardOverflow = errors.New("reward overflows int64")
+
+func CalculateRewards(NumberOfBoards int32, BoardSize int32, DifficultyLevel int32, win bool, rng *rand.Rand) (coinsReward int64, xpReward int64, err error) {
 	start := int32(0)
 	end := int32(5)
-	baseMultiplier := DifficultyLevel * NumberOfBoards * BoardSize
-	xpMultiplier := rand.Int32N(end-start+1) + int32(6)
-	coinMultiplier := rand.Int32N(end-start+1) + int32(1)
-	if win {
-		coinsReward = baseMultiplier * coinMultiplier
-		xpReward = baseMultiplier * xpMultiplier
-	} else {
-		coinsReward = 0
-		xpReward = baseMultiplier
+	xpMultiplier := rng.Int32N(end-start+1) + int32(6)
+	coinMultiplier := rng.Int32N(end-start+1) + int32(1)
+	baseMultiplier, ok := mulInt64(int64(DifficultyLevel), int64(NumberOfBoards))
+	if ok {
+		baseMultiplier, ok = mulInt64(baseMultiplier, int64(BoardSize))
+	}
+	if !ok {
+		return 0, 0, ErrRewardOverflow
+	}
+	if !win {
+		return 0, baseMultiplier, nil
+	}
+	coinsReward, ok = mulInt64(baseMultiplier, int64(coinMultiplier))
+	if !ok {
+		return 0, 0, ErrRewardOverflow
+	}
+	xpReward, ok = mulInt64(baseMultiplier, int64(xpMultiplier))
+	if !ok {
+		return 0, 0, ErrRewardOverflow
+	}
+	return coinsReward, xpReward, nil
+}
+
+// mulInt64 returns a*b and whether it fit in an int64.
+func mulInt64(a int64, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
 	}
-	return coinsReward, xpReward
+	c := a * b
+	return c, c/b == a
 }
@@ -0,0 +1,34 @@
+package logic
+
+import "math/rand/v2"
+
+// hintSearchNodeBudget lets paid hints search deeper than the AI's own moves.
+const hintSearchNodeBudget = 8 * defaultSearchNodeBudget
+
+// hintSearchEngine shares the AI's transposition table so both warm the same cache.
+var hintSearchEngine = &SearchEngine{
+	table:      defaultSearchEngine.table,
+	nodeBudget: hintSearchNodeBudget,
+}
+
+// EvaluatePosition returns the strongest move available for the player to move
+// and whether the position is a win for them. exact is false when a non-3x3
+// search ran out of budget; the move then comes from the parity heuristic and
+// winning is only a guess. Returns -1 if no move is left.
+func EvaluatePosition(boards []int32, boardSize int32, numberOfBoards int32, rng *rand.Rand) (move int32, winning bool, exact bool) {
+	if boardSize == 3 {
+		move, winning = GetMisereQuotientMove(boards, numberOfBoards)
+		return move, winning, true
+	}
+	move, winning, exact = hintSearchEngine.BestMove(boards, boardSize, numberOfBoards)
+	g := geometryFor(boardSize)
+	if exact || g == nil {
+		return move, winning, exact
+	}
+	state := newBoardState(g, boards, numberOfBoards)
+	moves := state.ValidMoves()
+	if len(moves) == 0 {
+		return -1, false, true
+	}
+	return getParityMove(state, moves, rng), state.LiveBoards()%2 == 1, false
+}
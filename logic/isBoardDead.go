@@ -4,6 +4,16 @@ func IsBoardDead(boardIndex int32, boards []int32, boardSize int32) bool {
 	start := boardIndex * boardSize * boardSize
 	end := start + boardSize*boardSize
 
+	if g := geometryFor(boardSize); g != nil {
+		var mask uint32
+		for _, idx := range boards {
+			if idx >= start && idx < end {
+				mask |= 1 << (idx - start)
+			}
+		}
+		return g.isDead(mask)
+	}
+
 	rowCount := make([]int32, boardSize)
 	colCount := make([]int32, boardSize)
 	var mainDiagCount, antiDiagCount int32
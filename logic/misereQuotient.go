@@ -0,0 +1,153 @@
+package logic
+
+// Multi-board 3x3 Notakto is a misère disjunctive sum, so board parity says
+// nothing about who wins. Plambeck & Siegel showed the game is governed by the
+// 18-element misère quotient
+//
+//	Q = ⟨a, b, c, d | a² = 1, b³ = b, b²c = c, c³ = ac², b²d = d, cd = ad, d² = c²⟩
+//
+// with P-positions {a, b², bc, c²}. Every 3x3 board maps to one element of Q,
+// the value of the whole position is the product of its boards, and the player
+// to move loses iff that product is in P.
+
+// quotientElement is a^a b^b c^c d^d kept in normal form by normalizeQuotient.
+type quotientElement struct {
+	a, b, c, d uint8
+}
+
+var quotientIdentity = quotientElement{}
+
+func normalizeQuotient(a, b, c, d int) quotientElement {
+	// c^m d = a^m d
+	if c > 0 && d > 0 {
+		a += c
+		c = 0
+	}
+	// d² = c², d³ = d
+	if d >= 2 {
+		if d%2 == 0 {
+			c += 2
+			d = 0
+		} else {
+			d = 1
+		}
+	}
+	// c³ = ac²
+	if c >= 3 {
+		a += c - 2
+		c = 2
+	}
+	// b³ = b
+	if b >= 3 {
+		b = 2 - b%2
+	}
+	// b²c = c, b²d = d
+	if b == 2 && (c > 0 || d > 0) {
+		b = 0
+	}
+	return quotientElement{a: uint8(a % 2), b: uint8(b), c: uint8(c), d: uint8(d)}
+}
+
+func (x quotientElement) mul(y quotientElement) quotientElement {
+	return normalizeQuotient(
+		int(x.a)+int(y.a),
+		int(x.b)+int(y.b),
+		int(x.c)+int(y.c),
+		int(x.d)+int(y.d),
+	)
+}
+
+// isP reports whether a position with this value is a loss for the player to move.
+func (x quotientElement) isP() bool {
+	switch x {
+	case quotientElement{a: 1}, quotientElement{b: 2}, quotientElement{b: 1, c: 1}, quotientElement{c: 2}:
+		return true
+	}
+	return false
+}
+
+var (
+	qa  = quotientElement{a: 1}
+	qb  = quotientElement{b: 1}
+	qab = quotientElement{a: 1, b: 1}
+	qc  = quotientElement{c: 1}
+	qc2 = quotientElement{c: 2}
+	qd  = quotientElement{d: 1}
+	qad = quotientElement{a: 1, d: 1}
+)
+
+// canonicalQuotient lists every live 3x3 board up to symmetry, keyed by the
+// smallest of its eight dihedral cell masks (bit r*3+c is cell (r, c)).
+var canonicalQuotient = map[uint32]quotientElement{
+	0x000: qc, 0x001: quotientIdentity, 0x002: quotientIdentity, 0x003: qad,
+	0x005: qb, 0x00a: qa, 0x00b: qb, 0x00c: qb,
+	0x00d: qa, 0x00e: qd, 0x010: qc2, 0x011: qb,
+	0x012: qb, 0x013: qab, 0x015: qa, 0x01a: qab,
+	0x01b: qa, 0x01c: qa, 0x01d: qb, 0x01e: qb,
+	0x028: qa, 0x029: qd, 0x02a: qb, 0x02b: qa,
+	0x02d: qb, 0x044: qa, 0x045: qab, 0x046: qd,
+	0x04e: qab, 0x061: qa, 0x062: quotientIdentity, 0x063: qb,
+	0x065: qb, 0x066: qa, 0x06a: qab, 0x06c: qa,
+	0x06e: qb, 0x071: qb, 0x072: qb, 0x073: qa,
+	0x0aa: qa, 0x0ab: qb, 0x0ad: qa, 0x0e5: qa,
+	0x0ee: qa, 0x145: qa,
+}
+
+// quotientTable3x3 holds the quotient value of every 3x3 cell mask. Dead
+// boards take no further moves, so they map to the identity.
+var quotientTable3x3 = func() (table [512]quotientElement) {
+	g := geometryFor(3)
+	for mask := uint32(0); mask < 512; mask++ {
+		if g.isDead(mask) {
+			table[mask] = quotientIdentity
+			continue
+		}
+		value, ok := canonicalQuotient[g.canonical(mask)]
+		if !ok {
+			panic("logic: missing misère quotient entry for live 3x3 board")
+		}
+		table[mask] = value
+	}
+	return table
+}()
+
+// GetMisereQuotientMove returns a perfect-play move for 3x3 Notakto and
+// whether the position is a win for the player to move. When it is not, the
+// move is the most central one that keeps its board alive, so the opponent
+// still has to find the refutation. Returns -1 if no move is left.
+func GetMisereQuotientMove(boards []int32, numberOfBoards int32) (move int32, winning bool) {
+	state := newBoardState(geometries[3], boards, numberOfBoards)
+
+	values := make([]quotientElement, numberOfBoards)
+	total := quotientIdentity
+	for b, mask := range state.masks {
+		values[b] = quotientTable3x3[mask]
+		total = total.mul(values[b])
+	}
+	winning = !total.isP()
+
+	moves := state.ValidMoves()
+	if len(moves) == 0 {
+		return -1, false
+	}
+	fallback := moves[0]
+	foundFallback := false
+	for _, m := range moves {
+		b := m / 9
+		next := state.masks[b] | 1<<(m%9)
+		after := quotientTable3x3[next]
+		for other, value := range values {
+			if int32(other) != b {
+				after = after.mul(value)
+			}
+		}
+		if after.isP() {
+			return m, true
+		}
+		if !foundFallback && !state.IsKillingMove(m) {
+			fallback = m
+			foundFallback = true
+		}
+	}
+	return fallback, winning
+}
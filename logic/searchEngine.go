@@ -0,0 +1,189 @@
+package logic
+
+import "sort"
+
+// Board sizes other than 3 have no known misère quotient, so they are solved
+// by searching the disjunctive sum directly. Each board is reduced to the
+// smallest of its eight dihedral images and the live boards are kept sorted,
+// which collapses symmetric boards and reordered sums onto one table entry.
+
+const (
+	defaultTranspositionEntries = 1 << 20
+	defaultSearchNodeBudget     = 100_000
+)
+
+// SearchEngine solves multi-board positions by exhaustive search backed by a
+// shared transposition table. It is safe for concurrent use.
+type SearchEngine struct {
+	table      *TranspositionTable
+	nodeBudget int
+}
+
+// NewSearchEngine returns an engine that caches up to ttEntries positions and
+// visits at most nodeBudget uncached positions per call.
+func NewSearchEngine(ttEntries int, nodeBudget int) *SearchEngine {
+	return &SearchEngine{
+		table:      NewTranspositionTable(ttEntries),
+		nodeBudget: nodeBudget,
+	}
+}
+
+var defaultSearchEngine = NewSearchEngine(defaultTranspositionEntries, defaultSearchNodeBudget)
+
+// BestMove searches the position for a winning move. solved is false when the
+// node budget ran out before the position could be decided. When solved and
+// not winning, move is the most central move that keeps its board alive.
+func (e *SearchEngine) BestMove(boards []int32, boardSize int32, numberOfBoards int32) (move int32, winning bool, solved bool) {
+	g := geometryFor(boardSize)
+	if g == nil {
+		return -1, false, false
+	}
+	state := newBoardState(g, boards, numberOfBoards)
+	moves := state.ValidMoves()
+	if len(moves) == 0 {
+		return -1, false, true
+	}
+
+	cells := boardSize * boardSize
+	budget := e.nodeBudget
+	solved = true
+	fallback := moves[0]
+	foundFallback := false
+	for _, m := range moves {
+		b := m / cells
+		next := state.masks[b] | 1<<(m%cells)
+
+		child := make([]uint32, 0, numberOfBoards)
+		for other, mask := range state.masks {
+			switch {
+			case int32(other) == b:
+				if !g.isDead(next) {
+					child = append(child, g.canonical(next))
+				}
+			case !g.isDead(mask):
+				child = append(child, g.canonical(mask))
+			}
+		}
+		sort.Slice(child, func(i, j int) bool { return child[i] < child[j] })
+
+		opponentWins, ok := e.solve(g, child, &budget)
+		if !ok {
+			solved = false
+			continue
+		}
+		if !opponentWins {
+			return m, true, true
+		}
+		if !foundFallback && !g.isDead(next) {
+			fallback = m
+			foundFallback = true
+		}
+	}
+	return fallback, false, solved
+}
+
+// solve reports whether the player to move wins the sum of the given live,
+// canonical, sorted boards. ok is false if the budget was exhausted.
+func (e *SearchEngine) solve(g *boardGeometry, boards []uint32, budget *int) (win bool, ok bool) {
+	if len(boards) == 0 {
+		// The opponent just killed the last board.
+		return true, true
+	}
+	key := hashBoards(g.size, boards)
+	if win, ok := e.table.Get(key); ok {
+		return win, true
+	}
+	if *budget <= 0 {
+		return false, false
+	}
+	*budget--
+
+	complete := true
+	for i, mask := range boards {
+		if i > 0 && mask == boards[i-1] {
+			continue
+		}
+		triedKill := false
+		var seen []uint32
+		for cell := 0; cell < g.cells; cell++ {
+			if mask&(1<<cell) != 0 {
+				continue
+			}
+			next := mask | 1<<cell
+			var child []uint32
+			if g.isDead(next) {
+				if triedKill {
+					continue
+				}
+				triedKill = true
+				child = replaceBoard(boards, i, 0, false)
+			} else {
+				canon := g.canonical(next)
+				if containsMask(seen, canon) {
+					continue
+				}
+				seen = append(seen, canon)
+				child = replaceBoard(boards, i, canon, true)
+			}
+
+			opponentWins, ok := e.solve(g, child, budget)
+			if !ok {
+				complete = false
+				continue
+			}
+			if !opponentWins {
+				e.table.Put(key, true)
+				return true, true
+			}
+		}
+	}
+	if !complete {
+		return false, false
+	}
+	e.table.Put(key, false)
+	return false, true
+}
+
+// replaceBoard returns a sorted copy of boards with boards[i] replaced by
+// mask, or removed when keep is false.
+func replaceBoard(boards []uint32, i int, mask uint32, keep bool) []uint32 {
+	out := make([]uint32, 0, len(boards))
+	out = append(out, boards[:i]...)
+	out = append(out, boards[i+1:]...)
+	if !keep {
+		return out
+	}
+	pos := sort.Search(len(out), func(j int) bool { return out[j] >= mask })
+	out = append(out, 0)
+	copy(out[pos+1:], out[pos:])
+	out[pos] = mask
+	return out
+}
+
+func containsMask(masks []uint32, mask uint32) bool {
+	for _, m := range masks {
+		if m == mask {
+			return true
+		}
+	}
+	return false
+}
+
+// hashBoards mixes the board size and sorted canonical masks with splitmix64.
+func hashBoards(size int, boards []uint32) uint64 {
+	h := uint64(size)
+	for _, mask := range boards {
+		h ^= uint64(mask) + 0x9e3779b97f4a7c15 + (h << 6) + (h >> 2)
+		h = mixHash(h)
+	}
+	return mixHash(h ^ uint64(len(boards)))
+}
+
+func mixHash(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
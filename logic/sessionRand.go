@@ -0,0 +1,32 @@
+package logic
+
+import "math/rand/v2"
+
+// Every random choice in a session is drawn from a PCG stream keyed by the
+// session seed, the ply it is made at and what it is for, so a game can be
+// replayed exactly from its stored seed and move log.
+const (
+	aiMoveStream uint64 = iota + 1
+	rewardStream
+)
+
+// NewSessionSeed draws the seed for a new session.
+func NewSessionSeed() int64 {
+	return rand.Int64()
+}
+
+func sessionRand(seed int64, ply int, stream uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(uint64(seed), uint64(ply)<<8|stream))
+}
+
+// AIMoveRand returns the generator for the AI decision at ply, the number of
+// moves already played.
+func AIMoveRand(seed int64, ply int) *rand.Rand {
+	return sessionRand(seed, ply, aiMoveStream)
+}
+
+// RewardRand returns the generator for the reward roll of a game that ended
+// after ply moves.
+func RewardRand(seed int64, ply int) *rand.Rand {
+	return sessionRand(seed, ply, rewardStream)
+}
@@ -0,0 +1,211 @@
+package logic
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+)
+
+// Strategy picks the AI's reply for a position, drawing any randomness from
+// rng. Implementations return -1 only when no move is left or the board
+// size is not supported.
+type Strategy interface {
+	Name() string
+	ChooseMove(boards []int32, boardSize int32, numberOfBoards int32, rng *rand.Rand) int32
+}
+
+type randomStrategy struct{}
+
+func (randomStrategy) Name() string { return "random" }
+
+func (randomStrategy) ChooseMove(boards []int32, boardSize int32, numberOfBoards int32, rng *rand.Rand) int32 {
+	moves := getValidMoves(boards, boardSize, numberOfBoards)
+	if len(moves) == 0 {
+		return -1
+	}
+	return moves[rng.IntN(len(moves))]
+}
+
+// centerStrategy plays the most central cell that keeps its board alive.
+type centerStrategy struct{}
+
+func (centerStrategy) Name() string { return "center" }
+
+func (centerStrategy) ChooseMove(boards []int32, boardSize int32, numberOfBoards int32, rng *rand.Rand) int32 {
+	state, err := NewBoardState(boards, boardSize, numberOfBoards)
+	if err != nil {
+		return -1
+	}
+	moves := state.ValidMoves()
+	if len(moves) == 0 {
+		return -1
+	}
+	for _, m := range moves {
+		if !state.IsKillingMove(m) {
+			return m
+		}
+	}
+	return moves[0]
+}
+
+type parityStrategy struct{}
+
+func (parityStrategy) Name() string { return "parity" }
+
+func (parityStrategy) ChooseMove(boards []int32, boardSize int32, numberOfBoards int32, rng *rand.Rand) int32 {
+	state, err := NewBoardState(boards, boardSize, numberOfBoards)
+	if err != nil {
+		return -1
+	}
+	moves := state.ValidMoves()
+	if len(moves) == 0 {
+		return -1
+	}
+	return getParityMove(state, moves, rng)
+}
+
+// solverStrategy plays perfectly where the position can be solved and falls
+// back to parity where the search budget runs out.
+type solverStrategy struct{}
+
+func (solverStrategy) Name() string { return "solver" }
+
+func (solverStrategy) ChooseMove(boards []int32, boardSize int32, numberOfBoards int32, rng *rand.Rand) int32 {
+	state, err := NewBoardState(boards, boardSize, numberOfBoards)
+	if err != nil {
+		return -1
+	}
+	moves := state.ValidMoves()
+	if len(moves) == 0 {
+		return -1
+	}
+
+	if boardSize == 3 {
+		move, _ := GetMisereQuotientMove(boards, numberOfBoards)
+		return move
+	}
+	if move, _, solved := defaultSearchEngine.BestMove(boards, boardSize, numberOfBoards); solved {
+		return move
+	}
+
+	// Budget ran out: fall back to live-board parity
+	return getParityMove(state, moves, rng)
+}
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = map[string]Strategy{
+		"random": randomStrategy{},
+		"center": centerStrategy{},
+		"parity": parityStrategy{},
+		"solver": solverStrategy{},
+	}
+)
+
+// RegisterStrategy makes a strategy available to registries by its name,
+// replacing any strategy already registered under it.
+func RegisterStrategy(s Strategy) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	strategies[s.Name()] = s
+}
+
+// LookupStrategy returns the strategy registered under name.
+func LookupStrategy(name string) (Strategy, bool) {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	s, ok := strategies[name]
+	return s, ok
+}
+
+// StrategyWeight is one entry of a difficulty level's mix.
+type StrategyWeight struct {
+	Strategy string
+	Weight   float64
+}
+
+type weightedStrategy struct {
+	strategy Strategy
+	weight   float64
+}
+
+type strategyLevel struct {
+	difficulty int32
+	mix        []weightedStrategy
+	total      float64
+}
+
+// StrategyRegistry maps difficulty levels to weighted strategy mixes.
+type StrategyRegistry struct {
+	version string
+	// levels is sorted by difficulty.
+	levels []strategyLevel
+}
+
+// NewStrategyRegistry validates the mixes against the registered strategies.
+// Every level needs at least one strategy with a positive weight.
+func NewStrategyRegistry(version string, levels map[int32][]StrategyWeight) (*StrategyRegistry, error) {
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("strategy registry %q has no difficulty levels", version)
+	}
+	r := &StrategyRegistry{version: version}
+	for difficulty, weights := range levels {
+		level := strategyLevel{difficulty: difficulty}
+		for _, w := range weights {
+			if w.Weight < 0 {
+				return nil, fmt.Errorf("difficulty %d: negative weight for strategy %q", difficulty, w.Strategy)
+			}
+			s, ok := LookupStrategy(w.Strategy)
+			if !ok {
+				return nil, fmt.Errorf("difficulty %d: unknown strategy %q", difficulty, w.Strategy)
+			}
+			if w.Weight == 0 {
+				continue
+			}
+			level.mix = append(level.mix, weightedStrategy{strategy: s, weight: w.Weight})
+			level.total += w.Weight
+		}
+		if level.total == 0 {
+			return nil, fmt.Errorf("difficulty %d: no strategy with a positive weight", difficulty)
+		}
+		r.levels = append(r.levels, level)
+	}
+	sort.Slice(r.levels, func(i, j int) bool { return r.levels[i].difficulty < r.levels[j].difficulty })
+	return r, nil
+}
+
+// Version identifies the configuration the registry was built from.
+func (r *StrategyRegistry) Version() string {
+	return r.version
+}
+
+// levelFor returns the highest level not above difficulty, or the lowest level.
+func (r *StrategyRegistry) levelFor(difficulty int32) strategyLevel {
+	level := r.levels[0]
+	for _, l := range r.levels {
+		if l.difficulty > difficulty {
+			break
+		}
+		level = l
+	}
+	return level
+}
+
+// Strategy draws a strategy from the difficulty level's mix.
+func (r *StrategyRegistry) Strategy(difficulty int32, rng *rand.Rand) Strategy {
+	level := r.levelFor(difficulty)
+	pick := rng.Float64() * level.total
+	for _, w := range level.mix {
+		if pick < w.weight {
+			return w.strategy
+		}
+		pick -= w.weight
+	}
+	return level.mix[len(level.mix)-1].strategy
+}
+
+// Move returns the AI's reply at the given difficulty, or -1 if no move is left.
+func (r *StrategyRegistry) Move(boards []int32, boardSize int32, numberOfBoards int32, difficulty int32, rng *rand.Rand) int32 {
+	return r.Strategy(difficulty, rng).ChooseMove(boards, boardSize, numberOfBoards, rng)
+}
@@ -0,0 +1,47 @@
+package logic
+
+import "sync/atomic"
+
+// TranspositionTable is a fixed-size, lock-free cache of solved positions.
+// Each slot packs the position hash and its outcome into one word, so
+// concurrent readers and writers never see a torn entry; a colliding write
+// simply replaces the older result.
+type TranspositionTable struct {
+	entries []atomic.Uint64
+	mask    uint64
+}
+
+const (
+	ttLoss uint64 = 1
+	ttWin  uint64 = 2
+)
+
+// NewTranspositionTable allocates a table with capacity rounded up to a power of two.
+func NewTranspositionTable(capacity int) *TranspositionTable {
+	size := 1
+	for size < capacity {
+		size <<= 1
+	}
+	return &TranspositionTable{
+		entries: make([]atomic.Uint64, size),
+		mask:    uint64(size - 1),
+	}
+}
+
+// Get returns whether the position is a win for the player to move, if known.
+func (t *TranspositionTable) Get(key uint64) (win bool, ok bool) {
+	entry := t.entries[key&t.mask].Load()
+	if entry == 0 || entry&^3 != key&^3 {
+		return false, false
+	}
+	return entry&3 == ttWin, true
+}
+
+// Put records the outcome of a position.
+func (t *TranspositionTable) Put(key uint64, win bool) {
+	outcome := ttLoss
+	if win {
+		outcome = ttWin
+	}
+	t.entries[key&t.mask].Store(key&^3 | outcome)
+}
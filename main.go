@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -19,9 +20,12 @@ import (
 
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
 	"github.com/rakshitg600/notakto-solo/config"
+	"github.com/rakshitg600/notakto-solo/identity"
 	appMiddleware "github.com/rakshitg600/notakto-solo/middleware"
 	"github.com/rakshitg600/notakto-solo/nowpayments"
+	"github.com/rakshitg600/notakto-solo/repository"
 	"github.com/rakshitg600/notakto-solo/routes"
+	"github.com/rakshitg600/notakto-solo/usecase"
 )
 
 func main() {
@@ -29,16 +33,7 @@ func main() {
 		log.Fatal("Failed to load environment variables:", err)
 	}
 
-	// Initialize Firebase Admin SDK (ServiceAccount type avoids deprecated WithCredentialsJSON)
-	credJSON := config.MustGetEnv("FIREBASE_CREDENTIALS_JSON")
-	firebaseApp, err := firebase.NewApp(context.Background(), nil, option.WithAuthCredentialsJSON(option.ServiceAccount, []byte(credJSON)))
-	if err != nil {
-		log.Fatal("failed to initialize Firebase app:", err)
-	}
-	authClient, err := firebaseApp.Auth(context.Background())
-	if err != nil {
-		log.Fatal("failed to get Firebase Auth client:", err)
-	}
+	identityProvider, closeIdentity := newIdentityProvider()
 
 	e := echo.New()
 	e.Use(appMiddleware.CORSMiddleware)
@@ -72,6 +67,15 @@ func main() {
 		log.Fatal("failed to connect to database:", err)
 	}
 
+	// Load configs once and reload them as they change, on every instance
+	repo := repository.NewPostgres(pool)
+	configCache, err := usecase.NewConfigCache(dbCtx, repo)
+	if err != nil {
+		log.Fatal("failed to load configs:", err)
+	}
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	go configCache.Run(backgroundCtx, repo)
+
 	// Initialize Valkey (Redis-compatible) client
 	valkeyURL := config.MustGetEnv("VALKEY_URL")
 	valkeyOpts, err := redis.ParseURL(valkeyURL)
@@ -85,13 +89,25 @@ func main() {
 		log.Fatal("failed to connect to Valkey:", err)
 	}
 
+	// Cache verified tokens in front of the identity provider
+	tokenCache := newTokenCache(identityProvider, repo, valkeyClient)
+
 	// Initialize NOWPayments client
 	nowpaymentsAPIKey := config.MustGetEnv("NOWPAYMENTS_API_KEY")
-	npClient := nowpayments.NewClient(nowpaymentsAPIKey)
+	npClient := nowpayments.NewClient(nowpaymentsAPIKey, config.MustGetEnv("NOWPAYMENTS_BASE_URL"))
 	ipnSecret := config.MustGetEnv("NOWPAYMENTS_IPN_SECRET")
+	if email := config.MustGetEnv("NOWPAYMENTS_EMAIL"); email != "" {
+		npClient.SetCredentials(email, config.MustGetEnv("NOWPAYMENTS_PASSWORD"))
+	}
+
+	// Periodically ask NOWPayments about payments whose IPNs never arrived
+	newPaymentPoller(backgroundCtx, repo, npClient, valkeyClient)
+
+	// Periodically check every wallet against its ledger, payments and games
+	reconciler := newWalletReconciler(backgroundCtx, repo, valkeyClient)
 	keepaliveToken := config.MustGetEnv("KEEPALIVE_TOKEN")
 
-	routes.SetupRoutes(e, pool, authClient, valkeyClient, npClient, ipnSecret, keepaliveToken)
+	routes.SetupRoutes(e, pool, repo, configCache, reconciler, tokenCache, valkeyClient, npClient, ipnSecret, keepaliveToken)
 	port := config.MustGetEnv("PORT")
 	serverErr := make(chan error, 1)
 	go func() {
@@ -120,6 +136,124 @@ func main() {
 		log.Println("Valkey close error:", err)
 	}
 	log.Println("closing database pool...")
+	stopBackground()
 	pool.Close()
+	tokenCache.Close()
+	closeIdentity()
 	log.Println("server exited gracefully")
 }
+
+// newIdentityProvider builds the token verifier selected by IDENTITY_PROVIDER
+// and returns a func that releases it.
+func newIdentityProvider() (identity.Provider, func()) {
+	if provider := config.MustGetEnv("IDENTITY_PROVIDER"); provider == "jwt" {
+		jwtProvider, err := identity.NewJWT(identity.JWTConfig{
+			JWKSURL:       config.MustGetEnv("JWT_JWKS_URL"),
+			Issuer:        config.MustGetEnv("JWT_ISSUER"),
+			Audience:      config.MustGetEnv("JWT_AUDIENCE"),
+			AllowUnsigned: config.MustGetEnv("JWT_ALLOW_UNSIGNED") == "true",
+		})
+		if err != nil {
+			log.Fatal("failed to initialize JWT identity provider:", err)
+		}
+		return jwtProvider, jwtProvider.Close
+	}
+
+	// Initialize Firebase Admin SDK (ServiceAccount type avoids deprecated WithCredentialsJSON)
+	credJSON := config.MustGetEnv("FIREBASE_CREDENTIALS_JSON")
+	firebaseApp, err := firebase.NewApp(context.Background(), nil, option.WithAuthCredentialsJSON(option.ServiceAccount, []byte(credJSON)))
+	if err != nil {
+		log.Fatal("failed to initialize Firebase app:", err)
+	}
+	authClient, err := firebaseApp.Auth(context.Background())
+	if err != nil {
+		log.Fatal("failed to get Firebase Auth client:", err)
+	}
+	return identity.NewFirebase(authClient), func() {}
+}
+
+// newTokenCache wraps provider in the verified-token cache configured by the
+// AUTH_TOKEN_CACHE_* variables. Each token's role is resolved against roles
+// once and cached with it.
+func newTokenCache(provider identity.Provider, roles repository.RoleRepository, valkeyClient *redis.Client) *identity.CachedProvider {
+	size, err := strconv.Atoi(config.MustGetEnv("AUTH_TOKEN_CACHE_SIZE"))
+	if err != nil || size < 0 {
+		log.Fatal("AUTH_TOKEN_CACHE_SIZE must be a non-negative integer")
+	}
+	interval, err := time.ParseDuration(config.MustGetEnv("AUTH_TOKEN_REVOCATION_INTERVAL"))
+	if err != nil || interval < 0 {
+		log.Fatal("AUTH_TOKEN_REVOCATION_INTERVAL must be a non-negative duration")
+	}
+	cacheConfig := identity.CacheConfig{
+		Size:               size,
+		RevocationInterval: interval,
+		ResolveRole: func(ctx context.Context, token *identity.Token) (identity.Role, error) {
+			return usecase.ResolveRole(ctx, roles, token)
+		},
+	}
+	if config.MustGetEnv("AUTH_TOKEN_CACHE_SHARED") == "true" {
+		cacheConfig.Shared = valkeyClient
+	}
+	return identity.NewCachedProvider(provider, cacheConfig)
+}
+
+// newWalletReconciler builds the wallet reconciler configured by the
+// WALLET_RECONCILE_* variables and, unless the interval is 0, starts its
+// periodic run, which one instance at a time takes a Valkey lock for.
+func newWalletReconciler(ctx context.Context, repo repository.Repository, valkeyClient *redis.Client) *usecase.WalletReconciler {
+	batchSize, err := strconv.ParseInt(config.MustGetEnv("WALLET_RECONCILE_BATCH_SIZE"), 10, 32)
+	if err != nil || batchSize <= 0 {
+		log.Fatal("WALLET_RECONCILE_BATCH_SIZE must be a positive integer")
+	}
+	interval, err := time.ParseDuration(config.MustGetEnv("WALLET_RECONCILE_INTERVAL"))
+	if err != nil || interval < 0 {
+		log.Fatal("WALLET_RECONCILE_INTERVAL must be a non-negative duration")
+	}
+	reconciler := usecase.NewWalletReconciler(repo, valkeyRunGate(valkeyClient, walletReconcileLockKey), int32(batchSize))
+	if interval > 0 {
+		go reconciler.Run(ctx, interval)
+	}
+	return reconciler
+}
+
+// newPaymentPoller builds the payment poller configured by the PAYMENT_POLL_*
+// and PAYMENT_EXPIRE_AFTER variables and, unless the interval is 0, starts
+// its periodic run, which one instance at a time takes a Valkey lock for.
+func newPaymentPoller(ctx context.Context, repo repository.Repository, npClient *nowpayments.Client, valkeyClient *redis.Client) {
+	batchSize, err := strconv.ParseInt(config.MustGetEnv("PAYMENT_POLL_BATCH_SIZE"), 10, 32)
+	if err != nil || batchSize <= 0 {
+		log.Fatal("PAYMENT_POLL_BATCH_SIZE must be a positive integer")
+	}
+	interval, err := time.ParseDuration(config.MustGetEnv("PAYMENT_POLL_INTERVAL"))
+	if err != nil || interval < 0 {
+		log.Fatal("PAYMENT_POLL_INTERVAL must be a non-negative duration")
+	}
+	minAge, err := time.ParseDuration(config.MustGetEnv("PAYMENT_POLL_MIN_AGE"))
+	if err != nil || minAge < 0 {
+		log.Fatal("PAYMENT_POLL_MIN_AGE must be a non-negative duration")
+	}
+	expireAfter, err := time.ParseDuration(config.MustGetEnv("PAYMENT_EXPIRE_AFTER"))
+	if err != nil || expireAfter <= 0 {
+		log.Fatal("PAYMENT_EXPIRE_AFTER must be a positive duration")
+	}
+	poller := usecase.NewPaymentPoller(repo, npClient, valkeyRunGate(valkeyClient, paymentPollLockKey), minAge, expireAfter, int32(batchSize))
+	if interval > 0 {
+		go poller.Run(ctx, interval)
+	}
+}
+
+// Valkey keys of the background jobs' run locks.
+const (
+	paymentPollLockKey     = "lock:job:payment-poll"
+	walletReconcileLockKey = "lock:job:wallet-reconcile"
+)
+
+// valkeyRunGate lets the instance that sets key first run the job until key
+// lapses. The value names the instance, for whoever inspects the lock.
+func valkeyRunGate(rdb *redis.Client, key string) usecase.RunGate {
+	hostname, _ := os.Hostname()
+	instance := hostname + ":" + strconv.Itoa(os.Getpid())
+	return func(ctx context.Context, ttl time.Duration) (bool, error) {
+		return rdb.SetNX(ctx, key, instance, ttl).Result()
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/identity"
+)
+
+// RequireRole rejects requests whose authenticated role does not include
+// required. It must run after AuthMiddleware.
+func RequireRole(required identity.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			role, ok := contextkey.RoleFromContext(c.Request().Context())
+			if !ok {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Missing role")
+			}
+			if !identity.Role(role).Includes(required) {
+				return echo.NewHTTPError(http.StatusForbidden, "Insufficient role")
+			}
+			return next(c)
+		}
+	}
+}
@@ -2,17 +2,19 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
-	"firebase.google.com/go/v4/auth"
 	"github.com/labstack/echo/v4"
 	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/identity"
+	"github.com/rakshitg600/notakto-solo/repository"
 	"github.com/rakshitg600/notakto-solo/usecase"
 )
 
-// FirebaseAuthMiddleware validates the Bearer token and injects UID into the request context.
-func FirebaseAuthMiddleware(authClient *auth.Client) echo.MiddlewareFunc {
+// AuthMiddleware validates the Bearer token with the identity provider and injects the token, UID and role into the request context.
+func AuthMiddleware(provider identity.Provider, roles repository.RoleRepository) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			authHeader := c.Request().Header.Get("Authorization")
@@ -25,12 +27,17 @@ func FirebaseAuthMiddleware(authClient *auth.Client) echo.MiddlewareFunc {
 
 			ctx := c.Request().Context()
 			idToken := authHeader[len("Bearer "):]
-			uid, err := usecase.VerifyFirebaseToken(ctx, authClient, idToken)
-			if err != nil {
+			token, role, err := usecase.VerifyToken(ctx, provider, roles, idToken)
+			if errors.Is(err, identity.ErrInvalidToken) {
 				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid token")
 			}
-			req := c.Request().WithContext(context.WithValue(ctx, contextkey.UID, uid))
-			c.SetRequest(req)
+			if err != nil {
+				return err
+			}
+			ctx = identity.NewContext(ctx, token)
+			ctx = context.WithValue(ctx, contextkey.UID, token.UID)
+			ctx = context.WithValue(ctx, contextkey.Role, string(role))
+			c.SetRequest(c.Request().WithContext(ctx))
 			return next(c)
 		}
 	}
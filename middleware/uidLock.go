@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -19,7 +20,7 @@ const (
 )
 
 // UIDLockMiddleware returns middleware that serializes requests per UID using
-// a distributed lock in Valkey/Redis. Must run after FirebaseAuthMiddleware.
+// a distributed lock in Valkey/Redis. Must run after AuthMiddleware.
 func UIDLockMiddleware(rdb *redis.Client) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -35,7 +36,7 @@ func UIDLockMiddleware(rdb *redis.Client) echo.MiddlewareFunc {
 			// value in key value pair, this value is unique to each request ==> uid,requestIdentifier map
 			nonce := make([]byte, 16)
 			if _, err := rand.Read(nonce); err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to generate lock nonce")
+				return fmt.Errorf("uid-lock: generate nonce: %w", err)
 			}
 			lockVal := hex.EncodeToString(nonce)
 			ticker := time.NewTicker(lockRetryWait)
@@ -45,7 +46,7 @@ func UIDLockMiddleware(rdb *redis.Client) echo.MiddlewareFunc {
 			for {
 				ok, err := rdb.SetNX(ctx, lockKey, lockVal, lockTTL).Result()
 				if err != nil {
-					return echo.NewHTTPError(http.StatusInternalServerError, "Lock service unavailable")
+					return fmt.Errorf("uid-lock: acquire %s: %w", lockKey, err)
 				}
 				if ok {
 					break
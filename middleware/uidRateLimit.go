@@ -9,7 +9,7 @@ import (
 )
 
 // UIDRateLimitMiddleware enforces the given requests/min limit per authenticated user.
-// Must run after FirebaseAuthMiddleware.
+// Must run after AuthMiddleware.
 func UIDRateLimitMiddleware(rdb *redis.Client, limit int) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
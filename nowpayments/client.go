@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,12 +20,24 @@ type Client struct {
 	apiKey  string
 	baseURL string
 	http    *http.Client
+
+	mu          sync.Mutex
+	email       string
+	password    string
+	token       string
+	tokenIssued time.Time
 }
 
-func NewClient(apiKey string) *Client {
+// NewClient returns a client for the NOWPayments API at baseURL, or the
+// production API when baseURL is empty. Point it at a local server to run
+// the payment flows without the real provider.
+func NewClient(apiKey string, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
 	return &Client{
 		apiKey:  apiKey,
-		baseURL: defaultBaseURL,
+		baseURL: strings.TrimRight(baseURL, "/"),
 		http:    &http.Client{Timeout: 10 * time.Second},
 	}
 }
@@ -87,3 +103,138 @@ func (c *Client) CreateInvoice(ctx context.Context, req InvoiceRequest) (*Invoic
 	}
 	return &out, nil
 }
+
+// PaymentStatus is what NOWPayments reports about one payment made against
+// an invoice. Only the fields the payment poller uses are typed.
+type PaymentStatus struct {
+	PaymentID     json.Number `json:"payment_id"`
+	InvoiceID     json.Number `json:"invoice_id"`
+	PaymentStatus string      `json:"payment_status"`
+	OrderID       string      `json:"order_id"`
+	CreatedAt     string      `json:"created_at"`
+	UpdatedAt     string      `json:"updated_at"`
+}
+
+// tokenLifetime is how long a token from /auth is reused. NOWPayments
+// expires them after five minutes.
+const tokenLifetime = 4 * time.Minute
+
+// SetCredentials enables ListInvoicePayments, which NOWPayments only serves
+// with a token issued for the account's email and password.
+func (c *Client) SetCredentials(email string, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.email = email
+	c.password = password
+	c.token = ""
+}
+
+// CanListPayments reports whether SetCredentials was given an account.
+func (c *Client) CanListPayments() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.email != ""
+}
+
+// GetPaymentStatus returns the current state of payment paymentID.
+func (c *Client) GetPaymentStatus(ctx context.Context, paymentID string) (*PaymentStatus, error) {
+	var out PaymentStatus
+	if err := c.doJSON(ctx, http.MethodGet, "/payment/"+url.PathEscape(paymentID), "", nil, &out); err != nil {
+		return nil, fmt.Errorf("nowpayments get payment %s: %w", paymentID, err)
+	}
+	return &out, nil
+}
+
+// ListInvoicePayments returns the payments made against invoice invoiceID,
+// most recently updated first. A player who never picked a currency has made
+// none. Requires SetCredentials.
+func (c *Client) ListInvoicePayments(ctx context.Context, invoiceID string) ([]PaymentStatus, error) {
+	token, err := c.authToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{
+		"invoiceId": {invoiceID},
+		"sortBy":    {"updated_at"},
+		"orderBy":   {"desc"},
+		"limit":     {"50"},
+	}
+	var out struct {
+		Data []PaymentStatus `json:"data"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/payment/?"+query.Encode(), token, nil, &out); err != nil {
+		return nil, fmt.Errorf("nowpayments list payments of invoice %s: %w", invoiceID, err)
+	}
+	return out.Data, nil
+}
+
+// authToken returns a cached token from /auth, fetching a new one once it is
+// tokenLifetime old.
+func (c *Client) authToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.email == "" {
+		return "", errors.New("nowpayments: listing payments needs credentials")
+	}
+	if c.token != "" && time.Since(c.tokenIssued) < tokenLifetime {
+		return c.token, nil
+	}
+	req := map[string]string{"email": c.email, "password": c.password}
+	var out struct {
+		Token string `json:"token"`
+	}
+	if err := c.doJSON(ctx, http.MethodPost, "/auth", "", req, &out); err != nil {
+		return "", fmt.Errorf("nowpayments auth: %w", err)
+	}
+	if out.Token == "" {
+		return "", errors.New("nowpayments auth: empty token")
+	}
+	c.token = out.Token
+	c.tokenIssued = time.Now()
+	return c.token, nil
+}
+
+// doJSON sends body (if any) as JSON to path and decodes a 2xx response into
+// out. A non-empty token is sent as a bearer token next to the API key.
+func (c *Client) doJSON(ctx context.Context, method string, path string, token string, body any, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
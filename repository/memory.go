@@ -0,0 +1,1098 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rakshitg600/notakto-solo/config"
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+// Memory is an in-process Repository for tests. It is safe for concurrent
+// use. Transactions run one at a time against a private copy of the data,
+// which replaces the shared copy only when fn succeeds, so they are
+// serializable and roll back cleanly. Calls made directly on Memory are
+// single-statement transactions.
+//
+// It mirrors the Postgres constraints the usecases rely on: primary and
+// unique keys fail with SQLSTATE 23505, missing parents with 23503 and
+// failed CHECKs with 23514. Calling Memory itself from inside InTx deadlocks;
+// use the Queries passed to fn.
+type Memory struct {
+	memoryQueries
+	mu   sync.Mutex
+	data *memoryData
+}
+
+func NewMemory() *Memory {
+	m := &Memory{data: newMemoryData()}
+	m.memoryQueries = memoryQueries{m: m}
+	return m
+}
+
+func (m *Memory) InTx(ctx context.Context, fn func(qtx Queries) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	snapshot := m.data.clone()
+	if err := fn(memoryQueries{m: m, tx: snapshot}); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.data = snapshot
+	return nil
+}
+
+// InSnapshot runs fn against a copy of the data that is then discarded.
+func (m *Memory) InSnapshot(ctx context.Context, fn func(qtx Queries) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fn(memoryQueries{m: m, tx: m.data.clone()})
+}
+
+// SetConfigValue stores value under key, as an admin editing the configs
+// table would.
+func (m *Memory) SetConfigValue(key string, value []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	existing, ok := m.data.configs[key]
+	if !ok {
+		existing.CreatedAt = now
+	}
+	m.data.configs[key] = db.Config{Key: key, Value: slices.Clone(value), CreatedAt: existing.CreatedAt, UpdatedAt: now}
+}
+
+// SetPlayerRole grants role to the player uid, as an admin inserting into
+// the PlayerRole table would.
+func (m *Memory) SetPlayerRole(uid string, role string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data.roles[uid] = role
+}
+
+type memorySession struct {
+	db.Session
+	seq int64 // insertion order, breaks created_at ties
+}
+
+type memoryData struct {
+	players     map[string]db.Player
+	roles       map[string]string
+	wallets     map[string]db.Wallet
+	sessions    map[string]memorySession
+	states      map[string]db.Sessionstate
+	payments    map[string]db.Payment
+	configs     map[string]db.Config
+	history     []db.Confighistory
+	adjustments []db.Walletadjustment
+	ledger      []db.WalletTransaction
+	refunds     []db.PaymentRefund
+	runs        []db.WalletReconcileRun
+	mismatches  []db.WalletMismatch
+	seq         int64
+}
+
+func newMemoryData() *memoryData {
+	return &memoryData{
+		players:  map[string]db.Player{},
+		roles:    map[string]string{},
+		wallets:  map[string]db.Wallet{},
+		sessions: map[string]memorySession{},
+		states:   map[string]db.Sessionstate{},
+		payments: map[string]db.Payment{},
+		configs:  map[string]db.Config{},
+	}
+}
+
+func (d *memoryData) clone() *memoryData {
+	c := &memoryData{
+		players:     maps.Clone(d.players),
+		roles:       maps.Clone(d.roles),
+		wallets:     maps.Clone(d.wallets),
+		sessions:    maps.Clone(d.sessions),
+		states:      make(map[string]db.Sessionstate, len(d.states)),
+		payments:    maps.Clone(d.payments),
+		configs:     make(map[string]db.Config, len(d.configs)),
+		history:     slices.Clone(d.history),
+		adjustments: slices.Clone(d.adjustments),
+		ledger:      slices.Clone(d.ledger),
+		refunds:     slices.Clone(d.refunds),
+		runs:        slices.Clone(d.runs),
+		mismatches:  slices.Clone(d.mismatches),
+		seq:         d.seq,
+	}
+	for k, v := range d.states {
+		c.states[k] = db.Sessionstate{SessionID: v.SessionID, Boards: slices.Clone(v.Boards), IsAiMove: slices.Clone(v.IsAiMove)}
+	}
+	for k, v := range d.configs {
+		v.Value = slices.Clone(v.Value)
+		c.configs[k] = v
+	}
+	return c
+}
+
+// memoryQueries reads and writes tx when bound to a transaction, and
+// m.data under m.mu otherwise.
+type memoryQueries struct {
+	m  *Memory
+	tx *memoryData
+}
+
+func (q memoryQueries) acquire() (*memoryData, func()) {
+	if q.tx != nil {
+		return q.tx, func() {}
+	}
+	q.m.mu.Lock()
+	return q.m.data, q.m.mu.Unlock
+}
+
+func uidFrom(ctx context.Context) (string, error) {
+	uid, ok := contextkey.UIDFromContext(ctx)
+	if !ok || uid == "" {
+		return "", errors.New("missing or invalid uid in context")
+	}
+	return uid, nil
+}
+
+func violation(code string, format string, args ...any) error {
+	return &pgconn.PgError{Severity: "ERROR", Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func uniqueViolation(table string, key string) error {
+	return violation("23505", "duplicate key value violates unique constraint on %s (%s)", table, key)
+}
+
+func foreignKeyViolation(table string, parent string, key string) error {
+	return violation("23503", "insert on %s violates foreign key to %s (%s)", table, parent, key)
+}
+
+func (q memoryQueries) CreatePlayer(ctx context.Context, name string, email string, profilePic string) error {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return err
+	}
+	d, release := q.acquire()
+	defer release()
+	if _, ok := d.players[uid]; ok {
+		return uniqueViolation("player", uid)
+	}
+	for _, p := range d.players {
+		if p.Email == email {
+			return uniqueViolation("player", email)
+		}
+	}
+	d.players[uid] = db.Player{
+		Uid:        uid,
+		Name:       name,
+		Email:      email,
+		ProfilePic: pgtype.Text{String: profilePic, Valid: profilePic != ""},
+	}
+	return nil
+}
+
+func (q memoryQueries) GetPlayerById(ctx context.Context) (db.Player, error) {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return db.Player{}, err
+	}
+	d, release := q.acquire()
+	defer release()
+	player, ok := d.players[uid]
+	if !ok {
+		return db.Player{}, pgx.ErrNoRows
+	}
+	return player, nil
+}
+
+func (q memoryQueries) GetPlayerByEmail(ctx context.Context, email string) (db.Player, error) {
+	d, release := q.acquire()
+	defer release()
+	for _, p := range d.players {
+		if p.Email == email {
+			return p, nil
+		}
+	}
+	return db.Player{}, pgx.ErrNoRows
+}
+
+func (q memoryQueries) UpdatePlayerName(ctx context.Context, name string) (db.Player, error) {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return db.Player{}, err
+	}
+	d, release := q.acquire()
+	defer release()
+	player, ok := d.players[uid]
+	if !ok {
+		return db.Player{}, pgx.ErrNoRows
+	}
+	player.Name = name
+	d.players[uid] = player
+	return player, nil
+}
+
+func (q memoryQueries) GetPlayerRole(ctx context.Context) (string, error) {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return "", err
+	}
+	d, release := q.acquire()
+	defer release()
+	role, ok := d.roles[uid]
+	if !ok {
+		return "", pgx.ErrNoRows
+	}
+	return role, nil
+}
+
+func (q memoryQueries) CreateSession(ctx context.Context, boardSize int32, numberOfBoards int32, difficulty int32, strategyVersion string, seed int64, newSessionID string) error {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return err
+	}
+	d, release := q.acquire()
+	defer release()
+	if _, ok := d.sessions[newSessionID]; ok {
+		return uniqueViolation("session", newSessionID)
+	}
+	if _, ok := d.players[uid]; !ok {
+		return foreignKeyViolation("session", "player", uid)
+	}
+	d.seq++
+	d.sessions[newSessionID] = memorySession{
+		Session: db.Session{
+			SessionID:       newSessionID,
+			Uid:             uid,
+			CreatedAt:       pgtype.Timestamp{Time: time.Now().UTC().Truncate(time.Microsecond), Valid: true},
+			Gameover:        pgtype.Bool{Bool: false, Valid: true},
+			BoardSize:       pgtype.Int4{Int32: boardSize, Valid: true},
+			NumberOfBoards:  pgtype.Int4{Int32: numberOfBoards, Valid: true},
+			Difficulty:      pgtype.Int4{Int32: difficulty, Valid: true},
+			StrategyVersion: pgtype.Text{String: strategyVersion, Valid: true},
+			Seed:            seed,
+		},
+		seq: d.seq,
+	}
+	return nil
+}
+
+func (q memoryQueries) CreateInitialSessionState(ctx context.Context, newSessionID string) error {
+	d, release := q.acquire()
+	defer release()
+	if _, ok := d.states[newSessionID]; ok {
+		return uniqueViolation("sessionstate", newSessionID)
+	}
+	if _, ok := d.sessions[newSessionID]; !ok {
+		return foreignKeyViolation("sessionstate", "session", newSessionID)
+	}
+	d.states[newSessionID] = db.Sessionstate{SessionID: newSessionID, Boards: []int32{}, IsAiMove: []bool{}}
+	return nil
+}
+
+// latestSession is the joined session/sessionstate row with the newest
+// created_at for uid.
+func (d *memoryData) latestSession(uid string) (db.GetSessionStateBySessionIdRow, error) {
+	var latest *memorySession
+	for _, s := range d.sessions {
+		if s.Uid != uid {
+			continue
+		}
+		if _, ok := d.states[s.SessionID]; !ok {
+			continue
+		}
+		if latest == nil || s.CreatedAt.Time.After(latest.CreatedAt.Time) ||
+			(s.CreatedAt.Time.Equal(latest.CreatedAt.Time) && s.seq > latest.seq) {
+			s := s
+			latest = &s
+		}
+	}
+	if latest == nil {
+		return db.GetSessionStateBySessionIdRow{}, pgx.ErrNoRows
+	}
+	return d.sessionRow(latest.SessionID)
+}
+
+func (d *memoryData) sessionRow(sessionID string) (db.GetSessionStateBySessionIdRow, error) {
+	s, ok := d.sessions[sessionID]
+	if !ok {
+		return db.GetSessionStateBySessionIdRow{}, pgx.ErrNoRows
+	}
+	state, ok := d.states[sessionID]
+	if !ok {
+		return db.GetSessionStateBySessionIdRow{}, pgx.ErrNoRows
+	}
+	return db.GetSessionStateBySessionIdRow{
+		SessionID:       s.SessionID,
+		Uid:             s.Uid,
+		CreatedAt:       s.CreatedAt,
+		Gameover:        s.Gameover,
+		Winner:          s.Winner,
+		BoardSize:       s.BoardSize,
+		NumberOfBoards:  s.NumberOfBoards,
+		Difficulty:      s.Difficulty,
+		StrategyVersion: s.StrategyVersion,
+		Seed:            s.Seed,
+		Boards:          slices.Clone(state.Boards),
+		IsAiMove:        slices.Clone(state.IsAiMove),
+	}, nil
+}
+
+func (q memoryQueries) GetLatestSessionStateByPlayerId(ctx context.Context) (db.GetLatestSessionStateByPlayerIdRow, error) {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return db.GetLatestSessionStateByPlayerIdRow{}, err
+	}
+	d, release := q.acquire()
+	defer release()
+	row, err := d.latestSession(uid)
+	return db.GetLatestSessionStateByPlayerIdRow(row), err
+}
+
+func (q memoryQueries) GetLatestSessionStateByPlayerIdWithLock(ctx context.Context) (db.GetLatestSessionStateByPlayerIdWithLockRow, error) {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return db.GetLatestSessionStateByPlayerIdWithLockRow{}, err
+	}
+	d, release := q.acquire()
+	defer release()
+	row, err := d.latestSession(uid)
+	return db.GetLatestSessionStateByPlayerIdWithLockRow(row), err
+}
+
+func (q memoryQueries) GetSessionStateBySessionId(ctx context.Context, sessionID string) (db.GetSessionStateBySessionIdRow, error) {
+	d, release := q.acquire()
+	defer release()
+	return d.sessionRow(sessionID)
+}
+
+func (q memoryQueries) GetSessionsByPlayerId(ctx context.Context, limit int32) ([]db.Session, error) {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d, release := q.acquire()
+	defer release()
+	var sessions []memorySession
+	for _, s := range d.sessions {
+		if s.Uid == uid {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		a, b := sessions[i], sessions[j]
+		if !a.CreatedAt.Time.Equal(b.CreatedAt.Time) {
+			return a.CreatedAt.Time.After(b.CreatedAt.Time)
+		}
+		return a.seq > b.seq
+	})
+	result := []db.Session{}
+	for _, s := range sessions {
+		if int32(len(result)) >= limit {
+			break
+		}
+		result = append(result, s.Session)
+	}
+	return result, nil
+}
+
+func (q memoryQueries) ListFinishedSessionRewardsByPlayerId(ctx context.Context) ([]db.ListFinishedSessionRewardsByPlayerIdRow, error) {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d, release := q.acquire()
+	defer release()
+	start, ok := d.ledgerStart(uid)
+	if !ok {
+		return []db.ListFinishedSessionRewardsByPlayerIdRow{}, nil
+	}
+	rows := []db.ListFinishedSessionRewardsByPlayerIdRow{}
+	for _, s := range d.sessions {
+		if s.Uid != uid || !s.Gameover.Bool || s.CreatedAt.Time.Before(start) {
+			continue
+		}
+		state := d.states[s.SessionID]
+		row := db.ListFinishedSessionRewardsByPlayerIdRow{
+			SessionID:      s.SessionID,
+			BoardSize:      s.BoardSize,
+			NumberOfBoards: s.NumberOfBoards,
+			Difficulty:     s.Difficulty,
+			Seed:           s.Seed,
+			Boards:         slices.Clone(state.Boards),
+			IsAiMove:       slices.Clone(state.IsAiMove),
+		}
+		for _, t := range d.ledger {
+			if t.Uid == uid && t.Reason == db.WalletTransactionReasonGameReward && t.ReferenceID.String == s.SessionID {
+				row.CoinsRewarded += t.CoinsDelta
+				row.XpRewarded += t.XpDelta
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (q memoryQueries) UpdateSessionState(ctx context.Context, sessionID string, boards []int32, isAiMove []bool) error {
+	d, release := q.acquire()
+	defer release()
+	if _, ok := d.states[sessionID]; ok {
+		d.states[sessionID] = db.Sessionstate{SessionID: sessionID, Boards: slices.Clone(boards), IsAiMove: slices.Clone(isAiMove)}
+	}
+	return nil
+}
+
+func (q memoryQueries) UpdateSessionAfterGameover(ctx context.Context, sessionID string, winner pgtype.Bool) error {
+	d, release := q.acquire()
+	defer release()
+	if s, ok := d.sessions[sessionID]; ok {
+		s.Gameover = pgtype.Bool{Bool: true, Valid: true}
+		s.Winner = winner
+		d.sessions[sessionID] = s
+	}
+	return nil
+}
+
+func (q memoryQueries) QuitGameSession(ctx context.Context, sessionID string) error {
+	return q.UpdateSessionAfterGameover(ctx, sessionID, pgtype.Bool{Bool: false, Valid: true})
+}
+
+func (q memoryQueries) CreateWallet(ctx context.Context, signUp config.SignUpConfig) error {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return err
+	}
+	d, release := q.acquire()
+	defer release()
+	if _, ok := d.wallets[uid]; ok {
+		return uniqueViolation("wallet", uid)
+	}
+	if _, ok := d.players[uid]; !ok {
+		return foreignKeyViolation("wallet", "player", uid)
+	}
+	wallet := db.Wallet{
+		Uid:   uid,
+		Coins: int64(signUp.InitialCoins),
+		Xp:    int64(signUp.InitialXP),
+	}
+	if err := checkWallet(wallet); err != nil {
+		return err
+	}
+	d.wallets[uid] = wallet
+	d.record(wallet, db.WalletTransactionReasonSignUp, wallet.Coins, wallet.Xp, "")
+	return nil
+}
+
+func (q memoryQueries) GetWalletByPlayerId(ctx context.Context) (db.Wallet, error) {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return db.Wallet{}, err
+	}
+	d, release := q.acquire()
+	defer release()
+	wallet, ok := d.wallets[uid]
+	if !ok {
+		return db.Wallet{}, pgx.ErrNoRows
+	}
+	return wallet, nil
+}
+
+func (q memoryQueries) GetWalletByPlayerIdWithLock(ctx context.Context) (db.Wallet, error) {
+	return q.GetWalletByPlayerId(ctx)
+}
+
+// addToWallet applies coins and xp deltas to uid's wallet if it exists and
+// records them in the ledger. Credited coins repay any debt first. Like the
+// wallet CHECK constraints, it refuses to leave a negative balance.
+func (d *memoryData) addToWallet(uid string, coins int64, xp int64, reason db.WalletTransactionReason, referenceID string) error {
+	wallet, ok := d.wallets[uid]
+	if !ok {
+		return nil
+	}
+	if coins > 0 && wallet.Debt > 0 {
+		repaid := min(coins, wallet.Debt)
+		wallet.Debt -= repaid
+		wallet.Coins += coins - repaid
+	} else {
+		wallet.Coins += coins
+	}
+	wallet.Xp += xp
+	if err := checkWallet(wallet); err != nil {
+		return err
+	}
+	d.wallets[uid] = wallet
+	d.record(wallet, reason, coins, xp, referenceID)
+	return nil
+}
+
+func checkWallet(wallet db.Wallet) error {
+	if wallet.Coins < 0 || wallet.Xp < 0 || wallet.Debt < 0 {
+		return violation("23514", "new row for wallet violates check constraint (non-negative balance)")
+	}
+	if wallet.Debt > 0 && wallet.Coins > 0 {
+		return violation("23514", "new row for wallet violates check constraint wallet_debt_without_coins")
+	}
+	return nil
+}
+
+// record appends a ledger row for a change that left wallet at its current
+// balance.
+func (d *memoryData) record(wallet db.Wallet, reason db.WalletTransactionReason, coins int64, xp int64, referenceID string) {
+	d.ledger = append(d.ledger, db.WalletTransaction{
+		ID:          int64(len(d.ledger) + 1),
+		Uid:         wallet.Uid,
+		Reason:      reason,
+		CoinsDelta:  coins,
+		XpDelta:     xp,
+		CoinsAfter:  wallet.Coins,
+		XpAfter:     wallet.Xp,
+		DebtAfter:   wallet.Debt,
+		ReferenceID: pgtype.Text{String: referenceID, Valid: referenceID != ""},
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+	})
+}
+
+func (q memoryQueries) UpdateWalletCoinsAndXpReward(ctx context.Context, coinsReward int64, xpReward int64, reason db.WalletTransactionReason, referenceID string) error {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return err
+	}
+	d, release := q.acquire()
+	defer release()
+	return d.addToWallet(uid, coinsReward, xpReward, reason, referenceID)
+}
+
+func (q memoryQueries) UpdateWalletXpReward(ctx context.Context, xpReward int64, reason db.WalletTransactionReason, referenceID string) error {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return err
+	}
+	d, release := q.acquire()
+	defer release()
+	return d.addToWallet(uid, 0, xpReward, reason, referenceID)
+}
+
+func (q memoryQueries) UpdateWalletReduceCoins(ctx context.Context, coins int64, reason db.WalletTransactionReason, referenceID string) error {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return err
+	}
+	d, release := q.acquire()
+	defer release()
+	return d.addToWallet(uid, -coins, 0, reason, referenceID)
+}
+
+func (q memoryQueries) CreditWalletCoins(ctx context.Context, uid string, coins int64, reason db.WalletTransactionReason, referenceID string) error {
+	d, release := q.acquire()
+	defer release()
+	return d.addToWallet(uid, coins, 0, reason, referenceID)
+}
+
+func (q memoryQueries) RefundWalletCoins(ctx context.Context, uid string, coins int64, reason db.WalletTransactionReason, referenceID string) error {
+	d, release := q.acquire()
+	defer release()
+	wallet, ok := d.wallets[uid]
+	if !ok {
+		return nil
+	}
+	clawedBack := min(coins, wallet.Coins)
+	wallet.Coins -= clawedBack
+	wallet.Debt += coins - clawedBack
+	d.wallets[uid] = wallet
+	d.record(wallet, reason, -coins, 0, referenceID)
+	return nil
+}
+
+func (q memoryQueries) CreateWalletAdjustment(ctx context.Context, adminUID string, coins int64, xp int64, reason string) (int64, error) {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return 0, err
+	}
+	d, release := q.acquire()
+	defer release()
+	if _, ok := d.players[uid]; !ok {
+		return 0, foreignKeyViolation("walletadjustment", "player", uid)
+	}
+	if strings.TrimSpace(reason) == "" {
+		return 0, violation("23514", "new row for walletadjustment violates check constraint (reason not blank)")
+	}
+	id := int64(len(d.adjustments) + 1)
+	d.adjustments = append(d.adjustments, db.Walletadjustment{
+		ID:        id,
+		Uid:       uid,
+		AdminUid:  adminUID,
+		Coins:     coins,
+		Xp:        xp,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	})
+	return id, nil
+}
+
+func (q memoryQueries) ListWalletTransactions(ctx context.Context, beforeID int64, reasons []db.WalletTransactionReason, limit int32) ([]db.WalletTransaction, error) {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d, release := q.acquire()
+	defer release()
+	out := []db.WalletTransaction{}
+	for i := len(d.ledger) - 1; i >= 0 && int32(len(out)) < limit; i-- {
+		row := d.ledger[i]
+		if row.Uid != uid || (beforeID != 0 && row.ID >= beforeID) {
+			continue
+		}
+		if len(reasons) > 0 && !slices.Contains(reasons, row.Reason) {
+			continue
+		}
+		out = append(out, row)
+	}
+	return out, nil
+}
+
+func (q memoryQueries) GetWalletTransactionTotals(ctx context.Context) (db.GetWalletTransactionTotalsRow, error) {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return db.GetWalletTransactionTotalsRow{}, err
+	}
+	d, release := q.acquire()
+	defer release()
+	var totals db.GetWalletTransactionTotalsRow
+	for _, t := range d.ledger {
+		if t.Uid != uid {
+			continue
+		}
+		totals.Coins += t.CoinsDelta
+		totals.Xp += t.XpDelta
+		if t.Reason == db.WalletTransactionReasonPurchase {
+			totals.PurchaseCoins += t.CoinsDelta
+		}
+	}
+	return totals, nil
+}
+
+func (q memoryQueries) ListWalletsAfterUid(ctx context.Context, afterUID string, limit int32) ([]db.Wallet, error) {
+	d, release := q.acquire()
+	defer release()
+	uids := slices.Sorted(maps.Keys(d.wallets))
+	wallets := []db.Wallet{}
+	for _, uid := range uids {
+		if uid > afterUID && int32(len(wallets)) < limit {
+			wallets = append(wallets, d.wallets[uid])
+		}
+	}
+	return wallets, nil
+}
+
+// ledgerStart returns when uid's first ledger row was written.
+func (d *memoryData) ledgerStart(uid string) (time.Time, bool) {
+	for _, t := range d.ledger {
+		if t.Uid == uid {
+			return t.CreatedAt, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (q memoryQueries) CreatePayment(ctx context.Context, id string, uid string, packageID string, coins int32, amountCents int32, status string, hostedURL string, invoiceID string) error {
+	d, release := q.acquire()
+	defer release()
+	if _, ok := d.payments[id]; ok {
+		return uniqueViolation("payment", id)
+	}
+	if _, ok := d.players[uid]; !ok {
+		return foreignKeyViolation("payment", "player", uid)
+	}
+	if coins <= 0 || amountCents <= 0 {
+		return violation("23514", "new row for payment violates check constraint (coins > 0, amount_cents > 0)")
+	}
+	if !slices.Contains(paymentStatuses, status) {
+		return violation("23514", "new row for payment violates check constraint payment_status_valid")
+	}
+	now := time.Now()
+	d.payments[id] = db.Payment{
+		ID:          id,
+		Uid:         uid,
+		PackageID:   packageID,
+		Coins:       coins,
+		AmountCents: amountCents,
+		Status:      status,
+		HostedUrl:   hostedURL,
+		InvoiceID:   pgtype.Text{String: invoiceID, Valid: invoiceID != ""},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	return nil
+}
+
+func (q memoryQueries) GetPaymentById(ctx context.Context, id string) (db.Payment, error) {
+	d, release := q.acquire()
+	defer release()
+	payment, ok := d.payments[id]
+	if !ok {
+		return db.Payment{}, pgx.ErrNoRows
+	}
+	return payment, nil
+}
+
+func (q memoryQueries) GetPaymentByIdWithLock(ctx context.Context, id string) (db.Payment, error) {
+	return q.GetPaymentById(ctx, id)
+}
+
+func (q memoryQueries) GetPaymentsByUid(ctx context.Context) ([]db.Payment, error) {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d, release := q.acquire()
+	defer release()
+	var payments []db.Payment
+	for _, p := range d.payments {
+		if p.Uid == uid {
+			payments = append(payments, p)
+		}
+	}
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].CreatedAt.After(payments[j].CreatedAt)
+	})
+	return payments, nil
+}
+
+func (q memoryQueries) GetCreditedPaymentCoinsByUid(ctx context.Context) (int64, error) {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return 0, err
+	}
+	d, release := q.acquire()
+	defer release()
+	start, ok := d.ledgerStart(uid)
+	if !ok {
+		return 0, nil
+	}
+	var coins int64
+	for _, p := range d.payments {
+		credited := p.Status == "confirmed" || p.Status == "refunded"
+		if p.Uid == uid && credited && p.ConfirmedAt.Valid && !p.ConfirmedAt.Time.Before(start) {
+			coins += int64(p.Coins)
+		}
+	}
+	return coins, nil
+}
+
+// paymentStatuses are the values the payment_status_valid CHECK allows.
+var paymentStatuses = []string{"created", "pending", "confirmed", "failed", "expired", "refunded"}
+
+func (q memoryQueries) UpdatePaymentStatus(ctx context.Context, id string, status string, fromStatuses []string) (int64, error) {
+	d, release := q.acquire()
+	defer release()
+	payment, ok := d.payments[id]
+	if !ok || !slices.Contains(fromStatuses, payment.Status) {
+		return 0, nil
+	}
+	if !slices.Contains(paymentStatuses, status) {
+		return 0, violation("23514", "new row for payment violates check constraint payment_status_valid")
+	}
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	payment.Status = status
+	payment.UpdatedAt = now
+	if status == "confirmed" {
+		payment.ConfirmedAt = pgtype.Timestamptz{Time: now, Valid: true}
+	}
+	d.payments[id] = payment
+	return 1, nil
+}
+
+func (q memoryQueries) UpdatePaymentProviderPaymentId(ctx context.Context, id string, providerPaymentID string) error {
+	d, release := q.acquire()
+	defer release()
+	payment, ok := d.payments[id]
+	if !ok || (payment.Status != "created" && payment.Status != "pending") {
+		return nil
+	}
+	payment.ProviderPaymentID = pgtype.Text{String: providerPaymentID, Valid: true}
+	d.payments[id] = payment
+	return nil
+}
+
+func (q memoryQueries) ListStalePaymentsAfterId(ctx context.Context, createdBefore time.Time, afterID string, limit int32) ([]db.Payment, error) {
+	d, release := q.acquire()
+	defer release()
+	ids := slices.Sorted(maps.Keys(d.payments))
+	payments := []db.Payment{}
+	for _, id := range ids {
+		p := d.payments[id]
+		waiting := p.Status == "created" || p.Status == "pending"
+		if waiting && p.CreatedAt.Before(createdBefore) && id > afterID && int32(len(payments)) < limit {
+			payments = append(payments, p)
+		}
+	}
+	return payments, nil
+}
+
+func (q memoryQueries) CreatePaymentRefund(ctx context.Context, paymentID string, uid string, coins int32, coinsClawedBack int64, debtAdded int64, providerStatus string) error {
+	d, release := q.acquire()
+	defer release()
+	for _, r := range d.refunds {
+		if r.PaymentID == paymentID {
+			return uniqueViolation("payment_refund", paymentID)
+		}
+	}
+	if _, ok := d.payments[paymentID]; !ok {
+		return foreignKeyViolation("payment_refund", "payment", paymentID)
+	}
+	if _, ok := d.players[uid]; !ok {
+		return foreignKeyViolation("payment_refund", "player", uid)
+	}
+	if coinsClawedBack < 0 || debtAdded < 0 {
+		return violation("23514", "new row for payment_refund violates check constraint (coins_clawed_back >= 0, debt_added >= 0)")
+	}
+	d.refunds = append(d.refunds, db.PaymentRefund{
+		PaymentID:       paymentID,
+		Uid:             uid,
+		Coins:           coins,
+		CoinsClawedBack: coinsClawedBack,
+		DebtAdded:       debtAdded,
+		ProviderStatus:  providerStatus,
+		CreatedAt:       time.Now(),
+	})
+	return nil
+}
+
+func (q memoryQueries) ListPaymentRefundsByUid(ctx context.Context) ([]db.PaymentRefund, error) {
+	uid, err := uidFrom(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d, release := q.acquire()
+	defer release()
+	refunds := []db.PaymentRefund{}
+	for i := len(d.refunds) - 1; i >= 0; i-- {
+		if d.refunds[i].Uid == uid {
+			refunds = append(refunds, d.refunds[i])
+		}
+	}
+	return refunds, nil
+}
+
+func (q memoryQueries) CreateWalletReconcileRun(ctx context.Context, startedAt time.Time, finishedAt time.Time, wallets int32, mismatched int32, failed int32) error {
+	d, release := q.acquire()
+	defer release()
+	d.runs = append(d.runs, db.WalletReconcileRun{
+		ID:         int64(len(d.runs) + 1),
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		Wallets:    wallets,
+		Mismatched: mismatched,
+		Failed:     failed,
+	})
+	return nil
+}
+
+func (q memoryQueries) GetLatestWalletReconcileRun(ctx context.Context) (db.WalletReconcileRun, error) {
+	d, release := q.acquire()
+	defer release()
+	if len(d.runs) == 0 {
+		return db.WalletReconcileRun{}, pgx.ErrNoRows
+	}
+	return d.runs[len(d.runs)-1], nil
+}
+
+func (q memoryQueries) CreateWalletMismatch(ctx context.Context, uid string, check string, reference string, expectedCoins int64, actualCoins int64, expectedXP int64, actualXP int64, detectedAt time.Time) error {
+	d, release := q.acquire()
+	defer release()
+	if _, ok := d.players[uid]; !ok {
+		return foreignKeyViolation("wallet_mismatch", "player", uid)
+	}
+	id := int64(1)
+	if n := len(d.mismatches); n > 0 {
+		id = d.mismatches[n-1].ID + 1
+	}
+	d.mismatches = append(d.mismatches, db.WalletMismatch{
+		ID:            id,
+		Uid:           uid,
+		CheckName:     check,
+		Reference:     reference,
+		ExpectedCoins: expectedCoins,
+		ActualCoins:   actualCoins,
+		ExpectedXp:    expectedXP,
+		ActualXp:      actualXP,
+		DetectedAt:    detectedAt,
+	})
+	return nil
+}
+
+func (q memoryQueries) DeleteWalletMismatches(ctx context.Context) error {
+	d, release := q.acquire()
+	defer release()
+	d.mismatches = nil
+	return nil
+}
+
+func (q memoryQueries) DeleteWalletMismatchesByUid(ctx context.Context, uid string) error {
+	d, release := q.acquire()
+	defer release()
+	d.mismatches = slices.DeleteFunc(d.mismatches, func(m db.WalletMismatch) bool { return m.Uid == uid })
+	return nil
+}
+
+func (q memoryQueries) ListWalletMismatches(ctx context.Context) ([]db.WalletMismatch, error) {
+	d, release := q.acquire()
+	defer release()
+	mismatches := append([]db.WalletMismatch{}, d.mismatches...)
+	slices.SortStableFunc(mismatches, func(a, b db.WalletMismatch) int { return strings.Compare(a.Uid, b.Uid) })
+	return mismatches, nil
+}
+
+func (q memoryQueries) GetConfigValueByKey(ctx context.Context, key string) ([]byte, error) {
+	d, release := q.acquire()
+	defer release()
+	cfg, ok := d.configs[key]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return slices.Clone(cfg.Value), nil
+}
+
+func (q memoryQueries) GetConfigHistoryValueByValueVersion(ctx context.Context, key string, valueVersion string) ([]byte, error) {
+	d, release := q.acquire()
+	defer release()
+	var newest *db.Confighistory
+	for i, h := range d.history {
+		if h.Key != key || (newest != nil && h.Version < newest.Version) {
+			continue
+		}
+		var labelled struct {
+			Version *string `json:"version"`
+		}
+		if json.Unmarshal(h.Value, &labelled) != nil || labelled.Version == nil || *labelled.Version != valueVersion {
+			continue
+		}
+		newest = &d.history[i]
+	}
+	if newest == nil {
+		return nil, pgx.ErrNoRows
+	}
+	return slices.Clone(newest.Value), nil
+}
+
+func (q memoryQueries) ListConfigs(ctx context.Context) ([]db.Config, error) {
+	d, release := q.acquire()
+	defer release()
+	configs := []db.Config{}
+	for _, cfg := range d.configs {
+		cfg.Value = slices.Clone(cfg.Value)
+		configs = append(configs, cfg)
+	}
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Key < configs[j].Key
+	})
+	return configs, nil
+}
+
+func (q memoryQueries) GetConfigByKeyWithLock(ctx context.Context, key string) (db.Config, error) {
+	d, release := q.acquire()
+	defer release()
+	cfg, ok := d.configs[key]
+	if !ok {
+		return db.Config{}, pgx.ErrNoRows
+	}
+	cfg.Value = slices.Clone(cfg.Value)
+	return cfg, nil
+}
+
+func (q memoryQueries) UpsertConfig(ctx context.Context, key string, value []byte, version int32) error {
+	d, release := q.acquire()
+	defer release()
+	now := time.Now()
+	cfg, ok := d.configs[key]
+	if !ok {
+		cfg = db.Config{Key: key, CreatedAt: now}
+	}
+	cfg.Value = slices.Clone(value)
+	cfg.Version = version
+	cfg.UpdatedAt = now
+	d.configs[key] = cfg
+	return nil
+}
+
+func (q memoryQueries) GetLatestConfigVersion(ctx context.Context, key string) (int32, error) {
+	d, release := q.acquire()
+	defer release()
+	var latest int32
+	for _, h := range d.history {
+		if h.Key == key && h.Version > latest {
+			latest = h.Version
+		}
+	}
+	return latest, nil
+}
+
+func (q memoryQueries) CreateConfigHistory(ctx context.Context, key string, version int32, value []byte, adminUID string) error {
+	d, release := q.acquire()
+	defer release()
+	if version <= 0 {
+		return violation("23514", "new row for confighistory violates check constraint (version > 0)")
+	}
+	for _, h := range d.history {
+		if h.Key == key && h.Version == version {
+			return uniqueViolation("confighistory", fmt.Sprintf("%s, %d", key, version))
+		}
+	}
+	d.history = append(d.history, db.Confighistory{
+		Key:       key,
+		Version:   version,
+		Value:     slices.Clone(value),
+		AdminUid:  pgtype.Text{String: adminUID, Valid: adminUID != ""},
+		CreatedAt: time.Now(),
+	})
+	return nil
+}
+
+func (q memoryQueries) GetConfigHistoryByKey(ctx context.Context, key string) ([]db.Confighistory, error) {
+	d, release := q.acquire()
+	defer release()
+	history := []db.Confighistory{}
+	for _, h := range d.history {
+		if h.Key == key {
+			h.Value = slices.Clone(h.Value)
+			history = append(history, h)
+		}
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Version > history[j].Version
+	})
+	return history, nil
+}
+
+func (q memoryQueries) GetConfigHistoryVersion(ctx context.Context, key string, version int32) (db.Confighistory, error) {
+	d, release := q.acquire()
+	defer release()
+	for _, h := range d.history {
+		if h.Key == key && h.Version == version {
+			h.Value = slices.Clone(h.Value)
+			return h, nil
+		}
+	}
+	return db.Confighistory{}, pgx.ErrNoRows
+}
@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rakshitg600/notakto-solo/config"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+	"github.com/rakshitg600/notakto-solo/store"
+)
+
+// Postgres implements Repository on top of store and the sqlc queries.
+type Postgres struct {
+	postgresQueries
+	pool *pgxpool.Pool
+}
+
+func NewPostgres(pool *pgxpool.Pool) *Postgres {
+	return &Postgres{
+		postgresQueries: postgresQueries{q: db.New(pool)},
+		pool:            pool,
+	}
+}
+
+func (p *Postgres) InTx(ctx context.Context, fn func(qtx Queries) error) error {
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.Serializable,
+		AccessMode: pgx.ReadWrite,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(postgresQueries{q: p.q.WithTx(tx)}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (p *Postgres) InSnapshot(ctx context.Context, fn func(qtx Queries) error) error {
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(postgresQueries{q: p.q.WithTx(tx)}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListenConfigChanges subscribes on its own connection rather than one from
+// the pool, since it holds it for as long as it runs.
+func (p *Postgres) ListenConfigChanges(ctx context.Context, onListen func(), onChange func(key string)) error {
+	conn, err := pgx.ConnectConfig(ctx, p.pool.Config().ConnConfig.Copy())
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if err := store.ListenConfigChanged(ctx, db.New(conn)); err != nil {
+		return err
+	}
+	onListen()
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		onChange(notification.Payload)
+	}
+}
+
+// postgresQueries adapts the store functions to Queries for one *db.Queries,
+// which is either pool- or transaction-bound.
+type postgresQueries struct {
+	q *db.Queries
+}
+
+func (p postgresQueries) CreatePlayer(ctx context.Context, name string, email string, profilePic string) error {
+	return store.CreatePlayer(ctx, p.q, name, email, profilePic)
+}
+
+func (p postgresQueries) GetPlayerById(ctx context.Context) (db.Player, error) {
+	return store.GetPlayerById(ctx, p.q)
+}
+
+func (p postgresQueries) GetPlayerByEmail(ctx context.Context, email string) (db.Player, error) {
+	return store.GetPlayerByEmail(ctx, p.q, email)
+}
+
+func (p postgresQueries) UpdatePlayerName(ctx context.Context, name string) (db.Player, error) {
+	return store.UpdatePlayerName(ctx, p.q, name)
+}
+
+func (p postgresQueries) GetPlayerRole(ctx context.Context) (string, error) {
+	return store.GetPlayerRole(ctx, p.q)
+}
+
+func (p postgresQueries) CreateSession(ctx context.Context, boardSize int32, numberOfBoards int32, difficulty int32, strategyVersion string, seed int64, newSessionID string) error {
+	return store.CreateSession(ctx, p.q, boardSize, numberOfBoards, difficulty, strategyVersion, seed, newSessionID)
+}
+
+func (p postgresQueries) CreateInitialSessionState(ctx context.Context, newSessionID string) error {
+	return store.CreateInitialSessionState(ctx, p.q, newSessionID)
+}
+
+func (p postgresQueries) GetLatestSessionStateByPlayerId(ctx context.Context) (db.GetLatestSessionStateByPlayerIdRow, error) {
+	return store.GetLatestSessionStateByPlayerId(ctx, p.q)
+}
+
+func (p postgresQueries) GetLatestSessionStateByPlayerIdWithLock(ctx context.Context) (db.GetLatestSessionStateByPlayerIdWithLockRow, error) {
+	return store.GetLatestSessionStateByPlayerIdWithLock(ctx, p.q)
+}
+
+func (p postgresQueries) GetSessionStateBySessionId(ctx context.Context, sessionID string) (db.GetSessionStateBySessionIdRow, error) {
+	return store.GetSessionStateBySessionId(ctx, p.q, sessionID)
+}
+
+func (p postgresQueries) GetSessionsByPlayerId(ctx context.Context, limit int32) ([]db.Session, error) {
+	return store.GetSessionsByPlayerId(ctx, p.q, limit)
+}
+
+func (p postgresQueries) ListFinishedSessionRewardsByPlayerId(ctx context.Context) ([]db.ListFinishedSessionRewardsByPlayerIdRow, error) {
+	return store.ListFinishedSessionRewardsByPlayerId(ctx, p.q)
+}
+
+func (p postgresQueries) UpdateSessionState(ctx context.Context, sessionID string, boards []int32, isAiMove []bool) error {
+	return store.UpdateSessionState(ctx, p.q, sessionID, boards, isAiMove)
+}
+
+func (p postgresQueries) UpdateSessionAfterGameover(ctx context.Context, sessionID string, winner pgtype.Bool) error {
+	return store.UpdateSessionAfterGameover(ctx, p.q, sessionID, winner)
+}
+
+func (p postgresQueries) QuitGameSession(ctx context.Context, sessionID string) error {
+	return store.QuitGameSession(ctx, p.q, sessionID)
+}
+
+func (p postgresQueries) CreateWallet(ctx context.Context, signUp config.SignUpConfig) error {
+	return store.CreateWallet(ctx, p.q, signUp)
+}
+
+func (p postgresQueries) GetWalletByPlayerId(ctx context.Context) (db.Wallet, error) {
+	return store.GetWalletByPlayerId(ctx, p.q)
+}
+
+func (p postgresQueries) GetWalletByPlayerIdWithLock(ctx context.Context) (db.Wallet, error) {
+	return store.GetWalletByPlayerIdWithLock(ctx, p.q)
+}
+
+func (p postgresQueries) UpdateWalletCoinsAndXpReward(ctx context.Context, coinsReward int64, xpReward int64, reason db.WalletTransactionReason, referenceID string) error {
+	return store.UpdateWalletCoinsAndXpReward(ctx, p.q, coinsReward, xpReward, reason, referenceID)
+}
+
+func (p postgresQueries) UpdateWalletXpReward(ctx context.Context, xpReward int64, reason db.WalletTransactionReason, referenceID string) error {
+	return store.UpdateWalletXpReward(ctx, p.q, xpReward, reason, referenceID)
+}
+
+func (p postgresQueries) UpdateWalletReduceCoins(ctx context.Context, coins int64, reason db.WalletTransactionReason, referenceID string) error {
+	return store.UpdateWalletReduceCoins(ctx, p.q, coins, reason, referenceID)
+}
+
+func (p postgresQueries) CreditWalletCoins(ctx context.Context, uid string, coins int64, reason db.WalletTransactionReason, referenceID string) error {
+	return store.CreditWalletCoins(ctx, p.q, uid, coins, reason, referenceID)
+}
+
+func (p postgresQueries) RefundWalletCoins(ctx context.Context, uid string, coins int64, reason db.WalletTransactionReason, referenceID string) error {
+	return store.RefundWalletCoins(ctx, p.q, uid, coins, reason, referenceID)
+}
+
+func (p postgresQueries) CreateWalletAdjustment(ctx context.Context, adminUID string, coins int64, xp int64, reason string) (int64, error) {
+	return store.CreateWalletAdjustment(ctx, p.q, adminUID, coins, xp, reason)
+}
+
+func (p postgresQueries) ListWalletTransactions(ctx context.Context, beforeID int64, reasons []db.WalletTransactionReason, limit int32) ([]db.WalletTransaction, error) {
+	return store.ListWalletTransactions(ctx, p.q, beforeID, reasons, limit)
+}
+
+func (p postgresQueries) GetWalletTransactionTotals(ctx context.Context) (db.GetWalletTransactionTotalsRow, error) {
+	return store.GetWalletTransactionTotals(ctx, p.q)
+}
+
+func (p postgresQueries) ListWalletsAfterUid(ctx context.Context, afterUID string, limit int32) ([]db.Wallet, error) {
+	return store.ListWalletsAfterUid(ctx, p.q, afterUID, limit)
+}
+
+func (p postgresQueries) CreatePayment(ctx context.Context, id string, uid string, packageID string, coins int32, amountCents int32, status string, hostedURL string, invoiceID string) error {
+	return store.CreatePayment(ctx, p.q, id, uid, packageID, coins, amountCents, status, hostedURL, invoiceID)
+}
+
+func (p postgresQueries) GetPaymentById(ctx context.Context, id string) (db.Payment, error) {
+	return store.GetPaymentById(ctx, p.q, id)
+}
+
+func (p postgresQueries) GetPaymentByIdWithLock(ctx context.Context, id string) (db.Payment, error) {
+	return store.GetPaymentByIdWithLock(ctx, p.q, id)
+}
+
+func (p postgresQueries) GetPaymentsByUid(ctx context.Context) ([]db.Payment, error) {
+	return store.GetPaymentsByUid(ctx, p.q)
+}
+
+func (p postgresQueries) GetCreditedPaymentCoinsByUid(ctx context.Context) (int64, error) {
+	return store.GetCreditedPaymentCoinsByUid(ctx, p.q)
+}
+
+func (p postgresQueries) UpdatePaymentStatus(ctx context.Context, id string, status string, fromStatuses []string) (int64, error) {
+	return store.UpdatePaymentStatus(ctx, p.q, id, status, fromStatuses)
+}
+
+func (p postgresQueries) UpdatePaymentProviderPaymentId(ctx context.Context, id string, providerPaymentID string) error {
+	return store.UpdatePaymentProviderPaymentId(ctx, p.q, id, providerPaymentID)
+}
+
+func (p postgresQueries) ListStalePaymentsAfterId(ctx context.Context, createdBefore time.Time, afterID string, limit int32) ([]db.Payment, error) {
+	return store.ListStalePaymentsAfterId(ctx, p.q, createdBefore, afterID, limit)
+}
+
+func (p postgresQueries) CreatePaymentRefund(ctx context.Context, paymentID string, uid string, coins int32, coinsClawedBack int64, debtAdded int64, providerStatus string) error {
+	return store.CreatePaymentRefund(ctx, p.q, paymentID, uid, coins, coinsClawedBack, debtAdded, providerStatus)
+}
+
+func (p postgresQueries) ListPaymentRefundsByUid(ctx context.Context) ([]db.PaymentRefund, error) {
+	return store.ListPaymentRefundsByUid(ctx, p.q)
+}
+
+func (p postgresQueries) CreateWalletReconcileRun(ctx context.Context, startedAt time.Time, finishedAt time.Time, wallets int32, mismatched int32, failed int32) error {
+	return store.CreateWalletReconcileRun(ctx, p.q, startedAt, finishedAt, wallets, mismatched, failed)
+}
+
+func (p postgresQueries) GetLatestWalletReconcileRun(ctx context.Context) (db.WalletReconcileRun, error) {
+	return store.GetLatestWalletReconcileRun(ctx, p.q)
+}
+
+func (p postgresQueries) CreateWalletMismatch(ctx context.Context, uid string, check string, reference string, expectedCoins int64, actualCoins int64, expectedXP int64, actualXP int64, detectedAt time.Time) error {
+	return store.CreateWalletMismatch(ctx, p.q, uid, check, reference, expectedCoins, actualCoins, expectedXP, actualXP, detectedAt)
+}
+
+func (p postgresQueries) DeleteWalletMismatches(ctx context.Context) error {
+	return store.DeleteWalletMismatches(ctx, p.q)
+}
+
+func (p postgresQueries) DeleteWalletMismatchesByUid(ctx context.Context, uid string) error {
+	return store.DeleteWalletMismatchesByUid(ctx, p.q, uid)
+}
+
+func (p postgresQueries) ListWalletMismatches(ctx context.Context) ([]db.WalletMismatch, error) {
+	return store.ListWalletMismatches(ctx, p.q)
+}
+
+func (p postgresQueries) GetConfigValueByKey(ctx context.Context, key string) ([]byte, error) {
+	return store.GetConfigValueByKey(ctx, p.q, key)
+}
+
+func (p postgresQueries) GetConfigHistoryValueByValueVersion(ctx context.Context, key string, valueVersion string) ([]byte, error) {
+	return store.GetConfigHistoryValueByValueVersion(ctx, p.q, key, valueVersion)
+}
+
+func (p postgresQueries) ListConfigs(ctx context.Context) ([]db.Config, error) {
+	return store.ListConfigs(ctx, p.q)
+}
+
+func (p postgresQueries) GetConfigByKeyWithLock(ctx context.Context, key string) (db.Config, error) {
+	return store.GetConfigByKeyWithLock(ctx, p.q, key)
+}
+
+func (p postgresQueries) UpsertConfig(ctx context.Context, key string, value []byte, version int32) error {
+	return store.UpsertConfig(ctx, p.q, key, value, version)
+}
+
+func (p postgresQueries) GetLatestConfigVersion(ctx context.Context, key string) (int32, error) {
+	return store.GetLatestConfigVersion(ctx, p.q, key)
+}
+
+func (p postgresQueries) CreateConfigHistory(ctx context.Context, key string, version int32, value []byte, adminUID string) error {
+	return store.CreateConfigHistory(ctx, p.q, key, version, value, adminUID)
+}
+
+func (p postgresQueries) GetConfigHistoryByKey(ctx context.Context, key string) ([]db.Confighistory, error) {
+	return store.GetConfigHistoryByKey(ctx, p.q, key)
+}
+
+func (p postgresQueries) GetConfigHistoryVersion(ctx context.Context, key string, version int32) (db.Confighistory, error) {
+	return store.GetConfigHistoryVersion(ctx, p.q, key, version)
+}
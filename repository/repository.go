@@ -0,0 +1,171 @@
+// Package repository defines the data access surface the usecase layer
+// depends on. Postgres is the production implementation (backed by store and
+// the sqlc-generated queries); Memory keeps everything in process so business
+// logic can be exercised without a database.
+//
+// Like store, methods that act on "the current player" read the UID from the
+// context via contextkey.UIDFromContext. Missing rows are reported as
+// pgx.ErrNoRows by every implementation.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rakshitg600/notakto-solo/config"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+type PlayerRepository interface {
+	CreatePlayer(ctx context.Context, name string, email string, profilePic string) error
+	GetPlayerById(ctx context.Context) (db.Player, error)
+	GetPlayerByEmail(ctx context.Context, email string) (db.Player, error)
+	UpdatePlayerName(ctx context.Context, name string) (db.Player, error)
+}
+
+type RoleRepository interface {
+	// GetPlayerRole returns the role granted to the current player, or
+	// pgx.ErrNoRows if none was.
+	GetPlayerRole(ctx context.Context) (string, error)
+}
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, boardSize int32, numberOfBoards int32, difficulty int32, strategyVersion string, seed int64, newSessionID string) error
+	CreateInitialSessionState(ctx context.Context, newSessionID string) error
+	GetLatestSessionStateByPlayerId(ctx context.Context) (db.GetLatestSessionStateByPlayerIdRow, error)
+	GetLatestSessionStateByPlayerIdWithLock(ctx context.Context) (db.GetLatestSessionStateByPlayerIdWithLockRow, error)
+	GetSessionStateBySessionId(ctx context.Context, sessionID string) (db.GetSessionStateBySessionIdRow, error)
+	// GetSessionsByPlayerId returns the current player's newest sessions first.
+	GetSessionsByPlayerId(ctx context.Context, limit int32) ([]db.Session, error)
+	UpdateSessionState(ctx context.Context, sessionID string, boards []int32, isAiMove []bool) error
+	UpdateSessionAfterGameover(ctx context.Context, sessionID string, winner pgtype.Bool) error
+	QuitGameSession(ctx context.Context, sessionID string) error
+	// ListFinishedSessionRewardsByPlayerId returns the current player's
+	// finished sessions since their first ledger row, with the game_reward
+	// totals the ledger holds for each.
+	ListFinishedSessionRewardsByPlayerId(ctx context.Context) ([]db.ListFinishedSessionRewardsByPlayerIdRow, error)
+}
+
+type WalletRepository interface {
+	CreateWallet(ctx context.Context, signUp config.SignUpConfig) error
+	GetWalletByPlayerId(ctx context.Context) (db.Wallet, error)
+	GetWalletByPlayerIdWithLock(ctx context.Context) (db.Wallet, error)
+	// The wallet updates below also append a wallet_transaction row tagged
+	// with reason and referenceID (a session, payment or adjustment id, or
+	// "" for none) in the same statement. Coins credited to a wallet in debt
+	// repay the debt first.
+	UpdateWalletCoinsAndXpReward(ctx context.Context, coinsReward int64, xpReward int64, reason db.WalletTransactionReason, referenceID string) error
+	UpdateWalletXpReward(ctx context.Context, xpReward int64, reason db.WalletTransactionReason, referenceID string) error
+	UpdateWalletReduceCoins(ctx context.Context, coins int64, reason db.WalletTransactionReason, referenceID string) error
+	CreditWalletCoins(ctx context.Context, uid string, coins int64, reason db.WalletTransactionReason, referenceID string) error
+	// RefundWalletCoins takes coins back from uid's wallet. The part its
+	// balance cannot cover is added to its debt.
+	RefundWalletCoins(ctx context.Context, uid string, coins int64, reason db.WalletTransactionReason, referenceID string) error
+	// CreateWalletAdjustment records that adminUID changed the current
+	// player's wallet by coins and xp and returns the adjustment id.
+	CreateWalletAdjustment(ctx context.Context, adminUID string, coins int64, xp int64, reason string) (int64, error)
+	// ListWalletTransactions returns up to limit ledger rows of the current
+	// player, newest first, with an id below beforeID (0 for the newest
+	// page). An empty reasons slice matches every reason.
+	ListWalletTransactions(ctx context.Context, beforeID int64, reasons []db.WalletTransactionReason, limit int32) ([]db.WalletTransaction, error)
+	// GetWalletTransactionTotals sums the current player's ledger.
+	GetWalletTransactionTotals(ctx context.Context) (db.GetWalletTransactionTotalsRow, error)
+	// ListWalletsAfterUid pages through every wallet in uid order.
+	ListWalletsAfterUid(ctx context.Context, afterUID string, limit int32) ([]db.Wallet, error)
+}
+
+type PaymentRepository interface {
+	CreatePayment(ctx context.Context, id string, uid string, packageID string, coins int32, amountCents int32, status string, hostedURL string, invoiceID string) error
+	GetPaymentById(ctx context.Context, id string) (db.Payment, error)
+	GetPaymentByIdWithLock(ctx context.Context, id string) (db.Payment, error)
+	GetPaymentsByUid(ctx context.Context) ([]db.Payment, error)
+	// UpdatePaymentStatus moves payment id to status if its current status
+	// is one of fromStatuses, and reports whether it did (1) or not (0).
+	UpdatePaymentStatus(ctx context.Context, id string, status string, fromStatuses []string) (int64, error)
+	// UpdatePaymentProviderPaymentId records the NOWPayments payment made
+	// against payment id's invoice, unless the payment is no longer created
+	// or pending.
+	UpdatePaymentProviderPaymentId(ctx context.Context, id string, providerPaymentID string) error
+	// ListStalePaymentsAfterId returns up to limit created or pending
+	// payments of any player created before createdBefore, ordered by id and
+	// starting after afterID ("" for the first batch).
+	ListStalePaymentsAfterId(ctx context.Context, createdBefore time.Time, afterID string, limit int32) ([]db.Payment, error)
+	// GetCreditedPaymentCoinsByUid sums the coins of the current player's
+	// payments confirmed since their first ledger row, refunded or not.
+	GetCreditedPaymentCoinsByUid(ctx context.Context) (int64, error)
+	CreatePaymentRefund(ctx context.Context, paymentID string, uid string, coins int32, coinsClawedBack int64, debtAdded int64, providerStatus string) error
+	// ListPaymentRefundsByUid returns the current player's refunds, newest
+	// first.
+	ListPaymentRefundsByUid(ctx context.Context) ([]db.PaymentRefund, error)
+}
+
+// ReconcileRepository keeps what the wallet reconciler found, so every
+// instance reports the same mismatches.
+type ReconcileRepository interface {
+	CreateWalletReconcileRun(ctx context.Context, startedAt time.Time, finishedAt time.Time, wallets int32, mismatched int32, failed int32) error
+	// GetLatestWalletReconcileRun returns the last full run, or
+	// pgx.ErrNoRows if there has been none.
+	GetLatestWalletReconcileRun(ctx context.Context) (db.WalletReconcileRun, error)
+	CreateWalletMismatch(ctx context.Context, uid string, check string, reference string, expectedCoins int64, actualCoins int64, expectedXP int64, actualXP int64, detectedAt time.Time) error
+	DeleteWalletMismatches(ctx context.Context) error
+	DeleteWalletMismatchesByUid(ctx context.Context, uid string) error
+	// ListWalletMismatches returns every stored mismatch, ordered by uid.
+	ListWalletMismatches(ctx context.Context) ([]db.WalletMismatch, error)
+}
+
+type ConfigRepository interface {
+	GetConfigValueByKey(ctx context.Context, key string) ([]byte, error)
+	// GetConfigHistoryValueByValueVersion returns the newest recorded value of
+	// key whose "version" field is valueVersion.
+	GetConfigHistoryValueByValueVersion(ctx context.Context, key string, valueVersion string) ([]byte, error)
+}
+
+// ConfigHistoryRepository is how admins edit configs. Every value written is
+// kept as a numbered version of its key.
+type ConfigHistoryRepository interface {
+	ListConfigs(ctx context.Context) ([]db.Config, error)
+	GetConfigByKeyWithLock(ctx context.Context, key string) (db.Config, error)
+	UpsertConfig(ctx context.Context, key string, value []byte, version int32) error
+	// GetLatestConfigVersion returns the highest version recorded for key,
+	// or 0 if there is none.
+	GetLatestConfigVersion(ctx context.Context, key string) (int32, error)
+	CreateConfigHistory(ctx context.Context, key string, version int32, value []byte, adminUID string) error
+	// GetConfigHistoryByKey returns key's versions, newest first.
+	GetConfigHistoryByKey(ctx context.Context, key string) ([]db.Confighistory, error)
+	GetConfigHistoryVersion(ctx context.Context, key string, version int32) (db.Confighistory, error)
+}
+
+// ConfigListener reports writes to the configs table, from any instance.
+type ConfigListener interface {
+	// ListenConfigChanges blocks until ctx is done or the subscription
+	// fails. It calls onListen once the subscription is in place, then
+	// onChange with the key of each config row inserted, updated or deleted.
+	ListenConfigChanges(ctx context.Context, onListen func(), onChange func(key string)) error
+}
+
+// Queries is every repository, bound either to the database directly or to
+// an open transaction.
+type Queries interface {
+	PlayerRepository
+	RoleRepository
+	SessionRepository
+	WalletRepository
+	PaymentRepository
+	ReconcileRepository
+	ConfigRepository
+	ConfigHistoryRepository
+}
+
+// Repository is the entry point usecases receive.
+type Repository interface {
+	Queries
+	// InTx runs fn in one serializable read-write transaction and commits
+	// if fn returns nil. Any error from fn, or from the commit, rolls the
+	// whole transaction back. InTx does not retry; callers decide that.
+	InTx(ctx context.Context, fn func(qtx Queries) error) error
+	// InSnapshot runs fn in one read-only repeatable-read transaction, so
+	// every read in fn sees the same snapshot without taking part in
+	// serializable conflict checks. Writes through qtx fail.
+	InSnapshot(ctx context.Context, fn func(qtx Queries) error) error
+}
@@ -1,44 +1,66 @@
 package routes
 
 import (
-	"firebase.google.com/go/v4/auth"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/rakshitg600/notakto-solo/handlers"
+	"github.com/rakshitg600/notakto-solo/identity"
 	"github.com/rakshitg600/notakto-solo/middleware"
 	"github.com/rakshitg600/notakto-solo/nowpayments"
+	"github.com/rakshitg600/notakto-solo/repository"
+	"github.com/rakshitg600/notakto-solo/usecase"
 )
 
-func SetupRoutes(e *echo.Echo, pool *pgxpool.Pool, authClient *auth.Client, valkeyClient *redis.Client, npClient *nowpayments.Client, ipnSecret string, keepaliveToken string) {
+func SetupRoutes(e *echo.Echo, pool *pgxpool.Pool, repo repository.Repository, configs *usecase.ConfigCache, reconciler *usecase.WalletReconciler, identityProvider identity.Provider, valkeyClient *redis.Client, npClient *nowpayments.Client, ipnSecret string, keepaliveToken string) {
 
 	ipRateLimit := middleware.IPRateLimitMiddleware(valkeyClient, 120)
-	firebaseAuth := middleware.FirebaseAuthMiddleware(authClient)
 	uidRateLimit := middleware.UIDRateLimitMiddleware(valkeyClient, 60)
 	uidLock := middleware.UIDLockMiddleware(valkeyClient)
 
-	handler := handlers.NewHandler(pool, authClient, valkeyClient, npClient, ipnSecret)
+	handler := handlers.NewHandler(pool, repo, configs, reconciler, identityProvider, valkeyClient, npClient, ipnSecret)
+	tokenAuth := middleware.AuthMiddleware(identityProvider, handler.Repo)
+	e.HTTPErrorHandler = handlers.ErrorHandler
 
 	e.HEAD("/v1/health-head", handler.HealthHeadHandler)
 	e.GET("/v1/health-get", handler.HealthGetHandler)
 	e.POST("/v1/keepalive", handler.KeepaliveHandler, ipRateLimit, middleware.KeepaliveAuthMiddleware(keepaliveToken))
+	e.GET("/v1/metrics", handler.MetricsHandler, ipRateLimit, middleware.KeepaliveAuthMiddleware(keepaliveToken))
 
 	// ── Authenticated routes ──
-	e.POST("/v1/sign-in", handler.SignInHandler, ipRateLimit, firebaseAuth, uidRateLimit, uidLock)
-	e.POST("/v1/create-game", handler.CreateGameHandler, ipRateLimit, firebaseAuth, uidRateLimit, uidLock)
-	e.POST("/v1/make-move", handler.MakeMoveHandler, ipRateLimit, firebaseAuth, uidRateLimit, uidLock)
-	e.POST("/v1/skip-move", handler.SkipMoveHandler, ipRateLimit, firebaseAuth, uidRateLimit, uidLock)
-	e.POST("/v1/undo-move", handler.UndoMoveHandler, ipRateLimit, firebaseAuth, uidRateLimit, uidLock)
-	e.POST("/v1/quit-game", handler.QuitGameHandler, ipRateLimit, firebaseAuth, uidRateLimit, uidLock)
-	e.GET("/v1/get-wallet", handler.GetWalletHandler, ipRateLimit, firebaseAuth, uidRateLimit, uidLock)
-	e.POST("/v1/update-name", handler.UpdateNameHandler, ipRateLimit, firebaseAuth, uidRateLimit, uidLock)
+	// Each group checks the token and the caller's role before the UID rate
+	// limit; most routes also take the per-UID lock.
+	player := e.Group("/v1", ipRateLimit, tokenAuth, middleware.RequireRole(identity.RolePlayer), uidRateLimit)
+	player.POST("/sign-in", handler.SignInHandler, uidLock)
+	player.POST("/create-game", handler.CreateGameHandler, uidLock)
+	player.POST("/make-move", handler.MakeMoveHandler, uidLock)
+	player.POST("/skip-move", handler.SkipMoveHandler, uidLock)
+	player.POST("/undo-move", handler.UndoMoveHandler, uidLock)
+	player.POST("/hint", handler.GetHintHandler, uidLock)
+	player.GET("/game-analysis", handler.GetGameAnalysisHandler)
+	player.POST("/quit-game", handler.QuitGameHandler, uidLock)
+	player.GET("/get-wallet", handler.GetWalletHandler, uidLock)
+	player.GET("/wallet-history", handler.GetWalletHistoryHandler)
+	player.POST("/update-name", handler.UpdateNameHandler, uidLock)
 
 	// ── Payment routes ──
-	e.GET("/v1/all-packages", handler.GetAllPackagesHandler, ipRateLimit, firebaseAuth, uidRateLimit, uidLock)
-	e.POST("/v1/create-charge", handler.CreateChargeHandler, ipRateLimit, firebaseAuth, uidRateLimit, uidLock)
-	e.GET("/v1/payment-status", handler.PaymentStatusHandler, ipRateLimit, firebaseAuth, uidRateLimit)
+	player.GET("/all-packages", handler.GetAllPackagesHandler, uidLock)
+	player.POST("/create-charge", handler.CreateChargeHandler, uidLock)
+	player.GET("/payment-status", handler.PaymentStatusHandler)
 
-	// ── Webhook (no Firebase auth, IP rate limit only) ──
+	// ── Admin routes ──
+	admin := e.Group("/v1/admin", ipRateLimit, tokenAuth, middleware.RequireRole(identity.RoleAdmin), uidRateLimit)
+	admin.GET("/player", handler.AdminGetPlayerHandler)
+	admin.POST("/adjust-wallet", handler.AdminAdjustWalletHandler, uidLock)
+	admin.GET("/wallet-mismatches", handler.AdminWalletMismatchesHandler)
+	admin.POST("/reconcile-wallet", handler.AdminReconcileWalletHandler)
+	admin.GET("/configs", handler.AdminListConfigsHandler)
+	admin.GET("/config", handler.AdminGetConfigHandler)
+	admin.POST("/validate-config", handler.AdminValidateConfigHandler)
+	admin.POST("/upsert-config", handler.AdminUpsertConfigHandler, uidLock)
+	admin.POST("/rollback-config", handler.AdminRollbackConfigHandler, uidLock)
+
+	// ── Webhook (no token auth, IP rate limit only) ──
 	e.POST("/v1/nowpayments-webhook", handler.WebhookHandler, ipRateLimit)
 }
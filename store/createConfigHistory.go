@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func CreateConfigHistory(ctx context.Context, q *db.Queries, key string, version int32, value []byte, adminUID string) error {
+	start := time.Now()
+	err := q.CreateConfigHistory(ctx, db.CreateConfigHistoryParams{
+		Key:      key,
+		Version:  version,
+		Value:    string(value),
+		AdminUid: pgtype.Text{String: adminUID, Valid: adminUID != ""},
+	})
+	if time.Since(start) > 2*time.Second {
+		log.Printf("CreateConfigHistory took %v, err: %v", time.Since(start), err)
+	}
+	return err
+}
@@ -5,10 +5,11 @@ import (
 	"log"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/rakshitg600/notakto-solo/db/generated"
 )
 
-func CreatePayment(ctx context.Context, q *db.Queries, id string, uid string, packageID string, coins int32, amountCents int32, status string, hostedURL string) error {
+func CreatePayment(ctx context.Context, q *db.Queries, id string, uid string, packageID string, coins int32, amountCents int32, status string, hostedURL string, invoiceID string) error {
 	start := time.Now()
 	err := q.CreatePayment(ctx, db.CreatePaymentParams{
 		ID:          id,
@@ -18,6 +19,7 @@ func CreatePayment(ctx context.Context, q *db.Queries, id string, uid string, pa
 		AmountCents: amountCents,
 		Status:      status,
 		HostedUrl:   hostedURL,
+		InvoiceID:   pgtype.Text{String: invoiceID, Valid: invoiceID != ""},
 	})
 	if time.Since(start) > 2*time.Second {
 		log.Printf("CreatePayment took %v, err: %v", time.Since(start), err)
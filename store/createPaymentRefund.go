@@ -0,0 +1,25 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func CreatePaymentRefund(ctx context.Context, q *db.Queries, paymentID string, uid string, coins int32, coinsClawedBack int64, debtAdded int64, providerStatus string) error {
+	start := time.Now()
+	err := q.CreatePaymentRefund(ctx, db.CreatePaymentRefundParams{
+		PaymentID:       paymentID,
+		Uid:             uid,
+		Coins:           coins,
+		CoinsClawedBack: coinsClawedBack,
+		DebtAdded:       debtAdded,
+		ProviderStatus:  providerStatus,
+	})
+	if time.Since(start) > 2*time.Second {
+		log.Printf("CreatePaymentRefund took %v, err: %v", time.Since(start), err)
+	}
+	return err
+}
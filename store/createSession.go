@@ -11,18 +11,20 @@ import (
 	"github.com/rakshitg600/notakto-solo/contextkey"
 )
 
-func CreateSession(ctx context.Context, q *db.Queries, boardSize int32, numberOfBoards int32, difficulty int32, newSessionID string) (err error) {
+func CreateSession(ctx context.Context, q *db.Queries, boardSize int32, numberOfBoards int32, difficulty int32, strategyVersion string, seed int64, newSessionID string) (err error) {
 	uid, ok := contextkey.UIDFromContext(ctx)
 	if !ok || uid == "" {
 		return errors.New("missing or invalid uid in context")
 	}
 	start := time.Now()
 	err = q.CreateSession(ctx, db.CreateSessionParams{
-		SessionID:      newSessionID,
-		Uid:            uid,
-		BoardSize:      pgtype.Int4{Int32: boardSize, Valid: true},
-		NumberOfBoards: pgtype.Int4{Int32: numberOfBoards, Valid: true},
-		Difficulty:     pgtype.Int4{Int32: difficulty, Valid: true},
+		SessionID:       newSessionID,
+		Uid:             uid,
+		BoardSize:       pgtype.Int4{Int32: boardSize, Valid: true},
+		NumberOfBoards:  pgtype.Int4{Int32: numberOfBoards, Valid: true},
+		Difficulty:      pgtype.Int4{Int32: difficulty, Valid: true},
+		StrategyVersion: pgtype.Text{String: strategyVersion, Valid: true},
+		Seed:            seed,
 	})
 	if time.Since(start) > 2*time.Second {
 		//logging slow DB calls
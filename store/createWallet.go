@@ -6,7 +6,6 @@ import (
 	"log"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rakshitg600/notakto-solo/config"
 	"github.com/rakshitg600/notakto-solo/contextkey"
 	db "github.com/rakshitg600/notakto-solo/db/generated"
@@ -19,15 +18,9 @@ func CreateWallet(ctx context.Context, q *db.Queries, signUp config.SignUpConfig
 	}
 	start := time.Now()
 	err = q.CreateWallet(ctx, db.CreateWalletParams{
-		Uid: uid,
-		Coins: pgtype.Int4{
-			Int32: signUp.InitialCoins,
-			Valid: true,
-		},
-		Xp: pgtype.Int4{
-			Int32: signUp.InitialXP,
-			Valid: true,
-		},
+		Uid:   uid,
+		Coins: int64(signUp.InitialCoins),
+		Xp:    int64(signUp.InitialXP),
 	})
 	if time.Since(start) > 2*time.Second {
 		//logging slow DB calls
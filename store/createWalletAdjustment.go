@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func CreateWalletAdjustment(ctx context.Context, q *db.Queries, adminUID string, coins int64, xp int64, reason string) (int64, error) {
+	uid, ok := contextkey.UIDFromContext(ctx)
+	if !ok || uid == "" {
+		return 0, errors.New("missing or invalid uid in context")
+	}
+	start := time.Now()
+	id, err := q.CreateWalletAdjustment(ctx, db.CreateWalletAdjustmentParams{
+		Uid:      uid,
+		AdminUid: adminUID,
+		Coins:    coins,
+		Xp:       xp,
+		Reason:   reason,
+	})
+	if time.Since(start) > 2*time.Second {
+		log.Printf("CreateWalletAdjustment took %v, err: %v", time.Since(start), err)
+	}
+	return id, err
+}
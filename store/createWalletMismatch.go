@@ -0,0 +1,27 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func CreateWalletMismatch(ctx context.Context, q *db.Queries, uid string, check string, reference string, expectedCoins int64, actualCoins int64, expectedXP int64, actualXP int64, detectedAt time.Time) error {
+	start := time.Now()
+	err := q.CreateWalletMismatch(ctx, db.CreateWalletMismatchParams{
+		Uid:           uid,
+		CheckName:     check,
+		Reference:     reference,
+		ExpectedCoins: expectedCoins,
+		ActualCoins:   actualCoins,
+		ExpectedXp:    expectedXP,
+		ActualXp:      actualXP,
+		DetectedAt:    detectedAt,
+	})
+	if time.Since(start) > 2*time.Second {
+		log.Printf("CreateWalletMismatch took %v, err: %v", time.Since(start), err)
+	}
+	return err
+}
@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func CreateWalletReconcileRun(ctx context.Context, q *db.Queries, startedAt time.Time, finishedAt time.Time, wallets int32, mismatched int32, failed int32) error {
+	start := time.Now()
+	err := q.CreateWalletReconcileRun(ctx, db.CreateWalletReconcileRunParams{
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		Wallets:    wallets,
+		Mismatched: mismatched,
+		Failed:     failed,
+	})
+	if time.Since(start) > 2*time.Second {
+		log.Printf("CreateWalletReconcileRun took %v, err: %v", time.Since(start), err)
+	}
+	return err
+}
@@ -5,16 +5,17 @@ import (
 	"log"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgtype"
 	db "github.com/rakshitg600/notakto-solo/db/generated"
 )
 
-func CreditWalletCoins(ctx context.Context, q *db.Queries, uid string, coins int32) error {
+func CreditWalletCoins(ctx context.Context, q *db.Queries, uid string, coins int64, reason db.WalletTransactionReason, referenceID string) error {
 	start := time.Now()
 	err := q.UpdateWalletCoinsAndXpReward(ctx, db.UpdateWalletCoinsAndXpRewardParams{
-		Uid:   uid,
-		Coins: pgtype.Int4{Int32: coins, Valid: true},
-		Xp:    pgtype.Int4{Int32: 0, Valid: true},
+		Uid:         uid,
+		Coins:       coins,
+		Xp:          0,
+		Reason:      reason,
+		ReferenceID: referenceID,
 	})
 	if time.Since(start) > 2*time.Second {
 		log.Printf("CreditWalletCoins took %v, err: %v", time.Since(start), err)
@@ -0,0 +1,18 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func DeleteWalletMismatches(ctx context.Context, q *db.Queries) error {
+	start := time.Now()
+	err := q.DeleteWalletMismatches(ctx)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("DeleteWalletMismatches took %v, err: %v", time.Since(start), err)
+	}
+	return err
+}
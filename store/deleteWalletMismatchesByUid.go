@@ -0,0 +1,18 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func DeleteWalletMismatchesByUid(ctx context.Context, q *db.Queries, uid string) error {
+	start := time.Now()
+	err := q.DeleteWalletMismatchesByUid(ctx, uid)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("DeleteWalletMismatchesByUid took %v, err: %v", time.Since(start), err)
+	}
+	return err
+}
@@ -0,0 +1,18 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func GetConfigByKeyWithLock(ctx context.Context, q *db.Queries, key string) (db.Config, error) {
+	start := time.Now()
+	cfg, err := q.GetConfigByKeyWithLock(ctx, key)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("GetConfigByKeyWithLock took %v, err: %v", time.Since(start), err)
+	}
+	return cfg, err
+}
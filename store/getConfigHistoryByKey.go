@@ -0,0 +1,18 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func GetConfigHistoryByKey(ctx context.Context, q *db.Queries, key string) ([]db.Confighistory, error) {
+	start := time.Now()
+	history, err := q.GetConfigHistoryByKey(ctx, key)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("GetConfigHistoryByKey took %v, err: %v", time.Since(start), err)
+	}
+	return history, err
+}
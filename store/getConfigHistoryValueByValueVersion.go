@@ -0,0 +1,21 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func GetConfigHistoryValueByValueVersion(ctx context.Context, q *db.Queries, key string, valueVersion string) ([]byte, error) {
+	start := time.Now()
+	value, err := q.GetConfigHistoryValueByValueVersion(ctx, db.GetConfigHistoryValueByValueVersionParams{
+		Key:          key,
+		ValueVersion: valueVersion,
+	})
+	if time.Since(start) > 2*time.Second {
+		log.Printf("GetConfigHistoryValueByValueVersion took %v, err: %v", time.Since(start), err)
+	}
+	return value, err
+}
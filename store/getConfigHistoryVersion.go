@@ -0,0 +1,21 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func GetConfigHistoryVersion(ctx context.Context, q *db.Queries, key string, version int32) (db.Confighistory, error) {
+	start := time.Now()
+	entry, err := q.GetConfigHistoryVersion(ctx, db.GetConfigHistoryVersionParams{
+		Key:     key,
+		Version: version,
+	})
+	if time.Since(start) > 2*time.Second {
+		log.Printf("GetConfigHistoryVersion took %v, err: %v", time.Since(start), err)
+	}
+	return entry, err
+}
@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func GetCreditedPaymentCoinsByUid(ctx context.Context, q *db.Queries) (int64, error) {
+	uid, ok := contextkey.UIDFromContext(ctx)
+	if !ok || uid == "" {
+		return 0, errors.New("missing or invalid uid in context")
+	}
+	start := time.Now()
+	coins, err := q.GetCreditedPaymentCoinsByUid(ctx, uid)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("GetCreditedPaymentCoinsByUid took %v, err: %v", time.Since(start), err)
+	}
+	return coins, err
+}
@@ -0,0 +1,18 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func GetLatestConfigVersion(ctx context.Context, q *db.Queries, key string) (int32, error) {
+	start := time.Now()
+	version, err := q.GetLatestConfigVersion(ctx, key)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("GetLatestConfigVersion took %v, err: %v", time.Since(start), err)
+	}
+	return version, err
+}
@@ -0,0 +1,18 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func GetLatestWalletReconcileRun(ctx context.Context, q *db.Queries) (db.WalletReconcileRun, error) {
+	start := time.Now()
+	run, err := q.GetLatestWalletReconcileRun(ctx)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("GetLatestWalletReconcileRun took %v, err: %v", time.Since(start), err)
+	}
+	return run, err
+}
@@ -0,0 +1,18 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func GetPlayerByEmail(ctx context.Context, q *db.Queries, email string) (db.Player, error) {
+	start := time.Now()
+	player, err := q.GetPlayerByEmail(ctx, email)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("GetPlayerByEmail took %v, err: %v", time.Since(start), err)
+	}
+	return player, err
+}
@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func GetPlayerRole(ctx context.Context, q *db.Queries) (string, error) {
+	uid, ok := contextkey.UIDFromContext(ctx)
+	if !ok || uid == "" {
+		return "", errors.New("missing or invalid uid in context")
+	}
+	start := time.Now()
+	role, err := q.GetPlayerRole(ctx, uid)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("GetPlayerRole took %v, err: %v", time.Since(start), err)
+	}
+	return role, err
+}
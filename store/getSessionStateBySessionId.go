@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func GetSessionStateBySessionId(ctx context.Context, q *db.Queries, sessionID string) (db.GetSessionStateBySessionIdRow, error) {
+	start := time.Now()
+	sessionState, err := q.GetSessionStateBySessionId(ctx, sessionID)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("GetSessionStateBySessionId took %v, err: %v", time.Since(start), err)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.GetSessionStateBySessionIdRow{}, pgx.ErrNoRows
+		}
+		return db.GetSessionStateBySessionIdRow{}, err
+	}
+	return sessionState, nil
+}
@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func GetSessionsByPlayerId(ctx context.Context, q *db.Queries, limit int32) ([]db.Session, error) {
+	uid, ok := contextkey.UIDFromContext(ctx)
+	if !ok || uid == "" {
+		return nil, errors.New("missing or invalid uid in context")
+	}
+	start := time.Now()
+	sessions, err := q.GetSessionsByPlayerId(ctx, db.GetSessionsByPlayerIdParams{
+		Uid:   uid,
+		Limit: limit,
+	})
+	if time.Since(start) > 2*time.Second {
+		log.Printf("GetSessionsByPlayerId took %v, err: %v", time.Since(start), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func GetWalletTransactionTotals(ctx context.Context, q *db.Queries) (db.GetWalletTransactionTotalsRow, error) {
+	uid, ok := contextkey.UIDFromContext(ctx)
+	if !ok || uid == "" {
+		return db.GetWalletTransactionTotalsRow{}, errors.New("missing or invalid uid in context")
+	}
+	start := time.Now()
+	totals, err := q.GetWalletTransactionTotals(ctx, uid)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("GetWalletTransactionTotals took %v, err: %v", time.Since(start), err)
+	}
+	return totals, err
+}
@@ -0,0 +1,18 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func ListConfigs(ctx context.Context, q *db.Queries) ([]db.Config, error) {
+	start := time.Now()
+	configs, err := q.ListConfigs(ctx)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("ListConfigs took %v, err: %v", time.Since(start), err)
+	}
+	return configs, err
+}
@@ -0,0 +1,27 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func ListPaymentRefundsByUid(ctx context.Context, q *db.Queries) ([]db.PaymentRefund, error) {
+	uid, ok := contextkey.UIDFromContext(ctx)
+	if !ok || uid == "" {
+		return nil, errors.New("missing or invalid uid in context")
+	}
+	start := time.Now()
+	refunds, err := q.ListPaymentRefundsByUid(ctx, uid)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("ListPaymentRefundsByUid took %v, err: %v", time.Since(start), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
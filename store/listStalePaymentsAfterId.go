@@ -0,0 +1,28 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+// ListStalePaymentsAfterId returns up to limit created or pending payments
+// created before createdBefore, ordered by id and starting after afterID
+// ("" for the first batch).
+func ListStalePaymentsAfterId(ctx context.Context, q *db.Queries, createdBefore time.Time, afterID string, limit int32) ([]db.Payment, error) {
+	start := time.Now()
+	payments, err := q.ListStalePaymentsAfterId(ctx, db.ListStalePaymentsAfterIdParams{
+		CreatedBefore: createdBefore,
+		AfterID:       afterID,
+		PageSize:      limit,
+	})
+	if time.Since(start) > 2*time.Second {
+		log.Printf("ListStalePaymentsAfterId took %v, err: %v", time.Since(start), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
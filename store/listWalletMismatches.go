@@ -0,0 +1,21 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func ListWalletMismatches(ctx context.Context, q *db.Queries) ([]db.WalletMismatch, error) {
+	start := time.Now()
+	mismatches, err := q.ListWalletMismatches(ctx)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("ListWalletMismatches took %v, err: %v", time.Since(start), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+// ListWalletTransactions returns up to limit ledger rows of the current
+// player, newest first, with an id below beforeID (0 for the newest page).
+// An empty reasons slice matches every reason.
+func ListWalletTransactions(ctx context.Context, q *db.Queries, beforeID int64, reasons []db.WalletTransactionReason, limit int32) ([]db.WalletTransaction, error) {
+	uid, ok := contextkey.UIDFromContext(ctx)
+	if !ok || uid == "" {
+		return nil, errors.New("missing or invalid uid in context")
+	}
+	// A nil slice is sent as NULL, which would match nothing.
+	names := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		names = append(names, string(reason))
+	}
+	start := time.Now()
+	transactions, err := q.ListWalletTransactions(ctx, db.ListWalletTransactionsParams{
+		Uid:      uid,
+		BeforeID: beforeID,
+		Reasons:  names,
+		PageSize: limit,
+	})
+	if time.Since(start) > 2*time.Second {
+		log.Printf("ListWalletTransactions took %v, err: %v", time.Since(start), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+// ListWalletsAfterUid returns up to limit wallets ordered by uid, starting
+// after afterUID ("" for the first batch).
+func ListWalletsAfterUid(ctx context.Context, q *db.Queries, afterUID string, limit int32) ([]db.Wallet, error) {
+	start := time.Now()
+	wallets, err := q.ListWalletsAfterUid(ctx, db.ListWalletsAfterUidParams{
+		Uid:   afterUID,
+		Limit: limit,
+	})
+	if time.Since(start) > 2*time.Second {
+		log.Printf("ListWalletsAfterUid took %v, err: %v", time.Since(start), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return wallets, nil
+}
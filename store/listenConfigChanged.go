@@ -0,0 +1,20 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+// ListenConfigChanged must run on a connection of its own, not a pooled one,
+// since the subscription lasts as long as the connection.
+func ListenConfigChanged(ctx context.Context, q *db.Queries) error {
+	start := time.Now()
+	err := q.ListenConfigChanged(ctx)
+	if time.Since(start) > 2*time.Second {
+		log.Printf("ListenConfigChanged took %v, err: %v", time.Since(start), err)
+	}
+	return err
+}
@@ -0,0 +1,23 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func RefundWalletCoins(ctx context.Context, q *db.Queries, uid string, coins int64, reason db.WalletTransactionReason, referenceID string) error {
+	start := time.Now()
+	err := q.RefundWalletCoins(ctx, db.RefundWalletCoinsParams{
+		Coins:       coins,
+		Uid:         uid,
+		Reason:      reason,
+		ReferenceID: referenceID,
+	})
+	if time.Since(start) > 2*time.Second {
+		log.Printf("RefundWalletCoins took %v, err: %v", time.Since(start), err)
+	}
+	return err
+}
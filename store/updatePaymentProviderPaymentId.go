@@ -0,0 +1,22 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func UpdatePaymentProviderPaymentId(ctx context.Context, q *db.Queries, id string, providerPaymentID string) error {
+	start := time.Now()
+	err := q.UpdatePaymentProviderPaymentId(ctx, db.UpdatePaymentProviderPaymentIdParams{
+		ID:                id,
+		ProviderPaymentID: pgtype.Text{String: providerPaymentID, Valid: true},
+	})
+	if time.Since(start) > 2*time.Second {
+		log.Printf("UpdatePaymentProviderPaymentId took %v, err: %v", time.Since(start), err)
+	}
+	return err
+}
@@ -8,14 +8,15 @@ import (
 	db "github.com/rakshitg600/notakto-solo/db/generated"
 )
 
-func UpdatePaymentStatusIfNotConfirmed(ctx context.Context, q *db.Queries, id string, status string) (int64, error) {
+func UpdatePaymentStatus(ctx context.Context, q *db.Queries, id string, status string, fromStatuses []string) (int64, error) {
 	start := time.Now()
-	rowsAffected, err := q.UpdatePaymentStatusIfNotConfirmed(ctx, db.UpdatePaymentStatusIfNotConfirmedParams{
-		ID:     id,
-		Status: status,
+	rowsAffected, err := q.UpdatePaymentStatus(ctx, db.UpdatePaymentStatusParams{
+		Status:       status,
+		ID:           id,
+		FromStatuses: fromStatuses,
 	})
 	if time.Since(start) > 2*time.Second {
-		log.Printf("UpdatePaymentStatusIfNotConfirmed took %v, err: %v", time.Since(start), err)
+		log.Printf("UpdatePaymentStatus took %v, err: %v", time.Since(start), err)
 	}
 	return rowsAffected, err
 }
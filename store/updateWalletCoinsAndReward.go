@@ -6,21 +6,22 @@ import (
 	"log"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgtype"
-	db "github.com/rakshitg600/notakto-solo/db/generated"
 	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
 )
 
-func UpdateWalletCoinsAndXpReward(ctx context.Context, q *db.Queries, coinsReward int32, xpReward int32) (err error) {
+func UpdateWalletCoinsAndXpReward(ctx context.Context, q *db.Queries, coinsReward int64, xpReward int64, reason db.WalletTransactionReason, referenceID string) (err error) {
 	uid, ok := contextkey.UIDFromContext(ctx)
 	if !ok || uid == "" {
 		return errors.New("missing or invalid uid in context")
 	}
 	start := time.Now()
 	err = q.UpdateWalletCoinsAndXpReward(ctx, db.UpdateWalletCoinsAndXpRewardParams{
-		Uid:   uid,
-		Coins: pgtype.Int4{Int32: coinsReward, Valid: true},
-		Xp:    pgtype.Int4{Int32: xpReward, Valid: true},
+		Uid:         uid,
+		Coins:       coinsReward,
+		Xp:          xpReward,
+		Reason:      reason,
+		ReferenceID: referenceID,
 	})
 	if time.Since(start) > 2*time.Second {
 		//logging slow DB calls
@@ -6,20 +6,21 @@ import (
 	"log"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgtype"
-	db "github.com/rakshitg600/notakto-solo/db/generated"
 	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
 )
 
-func UpdateWalletReduceCoins(ctx context.Context, q *db.Queries, coins int32) (err error) {
+func UpdateWalletReduceCoins(ctx context.Context, q *db.Queries, coins int64, reason db.WalletTransactionReason, referenceID string) (err error) {
 	uid, ok := contextkey.UIDFromContext(ctx)
 	if !ok || uid == "" {
 		return errors.New("missing or invalid uid in context")
 	}
 	start := time.Now()
 	err = q.UpdateWalletReduceCoins(ctx, db.UpdateWalletReduceCoinsParams{
-		Uid:   uid,
-		Coins: pgtype.Int4{Int32: coins, Valid: true},
+		Uid:         uid,
+		Coins:       coins,
+		Reason:      reason,
+		ReferenceID: referenceID,
 	})
 	if time.Since(start) > 2*time.Second {
 		//logging slow DB calls
@@ -6,20 +6,21 @@ import (
 	"log"
 	"time"
 
-	"github.com/jackc/pgx/v5/pgtype"
-	db "github.com/rakshitg600/notakto-solo/db/generated"
 	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
 )
 
-func UpdateWalletXpReward(ctx context.Context, q *db.Queries, xpReward int32) (err error) {
+func UpdateWalletXpReward(ctx context.Context, q *db.Queries, xpReward int64, reason db.WalletTransactionReason, referenceID string) (err error) {
 	uid, ok := contextkey.UIDFromContext(ctx)
 	if !ok || uid == "" {
 		return errors.New("missing or invalid uid in context")
 	}
 	start := time.Now()
 	err = q.UpdateWalletXpReward(ctx, db.UpdateWalletXpRewardParams{
-		Uid: uid,
-		Xp:  pgtype.Int4{Int32: xpReward, Valid: true},
+		Uid:         uid,
+		Xp:          xpReward,
+		Reason:      reason,
+		ReferenceID: referenceID,
 	})
 	if time.Since(start) > 2*time.Second {
 		//logging slow DB calls
@@ -0,0 +1,22 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func UpsertConfig(ctx context.Context, q *db.Queries, key string, value []byte, version int32) error {
+	start := time.Now()
+	err := q.UpsertConfig(ctx, db.UpsertConfigParams{
+		Key:     key,
+		Value:   string(value),
+		Version: version,
+	})
+	if time.Since(start) > 2*time.Second {
+		log.Printf("UpsertConfig took %v, err: %v", time.Since(start), err)
+	}
+	return err
+}
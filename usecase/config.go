@@ -1,19 +1,22 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"reflect"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/rakshitg600/notakto-solo/config"
-	"github.com/rakshitg600/notakto-solo/db/generated"
-	"github.com/rakshitg600/notakto-solo/store"
+	"github.com/rakshitg600/notakto-solo/logic"
+	"github.com/rakshitg600/notakto-solo/repository"
 )
 
-func loadCoinPackages(ctx context.Context, q *db.Queries) ([]config.CoinPackage, error) {
-	value, err := store.GetConfigValueByKey(ctx, q, config.CoinPackagesKey)
+func loadCoinPackages(ctx context.Context, q repository.ConfigRepository) ([]config.CoinPackage, error) {
+	value, err := q.GetConfigValueByKey(ctx, config.CoinPackagesKey)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return config.DefaultCoinPackages(), nil
@@ -27,8 +30,8 @@ func loadCoinPackages(ctx context.Context, q *db.Queries) ([]config.CoinPackage,
 	return packages, nil
 }
 
-func loadSignUpConfig(ctx context.Context, q *db.Queries) (config.SignUpConfig, error) {
-	value, err := store.GetConfigValueByKey(ctx, q, config.SignUpKey)
+func loadSignUpConfig(ctx context.Context, q repository.ConfigRepository) (config.SignUpConfig, error) {
+	value, err := q.GetConfigValueByKey(ctx, config.SignUpKey)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return config.DefaultSignUpConfig(), nil
@@ -42,3 +45,168 @@ func loadSignUpConfig(ctx context.Context, q *db.Queries) (config.SignUpConfig,
 	}
 	return signUp, nil
 }
+
+func loadStrategyRegistry(ctx context.Context, q repository.ConfigRepository) (*logic.StrategyRegistry, error) {
+	strategies := config.DefaultAIStrategyConfig()
+	value, err := q.GetConfigValueByKey(ctx, config.AIStrategiesKey)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("get %q config: %w", config.AIStrategiesKey, err)
+	}
+	if err == nil {
+		strategies = config.AIStrategyConfig{}
+		if err := json.Unmarshal(value, &strategies); err != nil {
+			return nil, fmt.Errorf("decode %s config: %w", config.AIStrategiesKey, err)
+		}
+	}
+
+	registry, err := config.NewStrategyRegistry(strategies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s config: %w", config.AIStrategiesKey, err)
+	}
+	return registry, nil
+}
+
+// configSchema describes a key admins may write: the value that applies
+// while the key has no row, and how to check a new value. validate gets the
+// live value, or nil, for checks that compare the two, and q for checks
+// against earlier versions.
+type configSchema struct {
+	defaultValue func() any
+	validate     func(ctx context.Context, q repository.ConfigRepository, value []byte, live []byte) error
+}
+
+// configLookupError is how validate reports that it could not read an
+// earlier version, as opposed to rejecting the value.
+type configLookupError struct{ err error }
+
+func (e configLookupError) Error() string { return e.err.Error() }
+func (e configLookupError) Unwrap() error { return e.err }
+
+// check runs validate, returning a rejected value as ErrInvalidConfig.
+func (s configSchema) check(ctx context.Context, q repository.ConfigRepository, value []byte, live []byte) error {
+	err := s.validate(ctx, q, value, live)
+	var lookup configLookupError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &lookup):
+		return lookup.err
+	default:
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+}
+
+var configSchemas = map[string]configSchema{
+	config.CoinPackagesKey: {
+		defaultValue: func() any { return config.DefaultCoinPackages() },
+		validate:     validateCoinPackages,
+	},
+	config.SignUpKey: {
+		defaultValue: func() any { return config.DefaultSignUpConfig() },
+		validate:     validateSignUpConfig,
+	},
+	config.AIStrategiesKey: {
+		defaultValue: func() any { return config.DefaultAIStrategyConfig() },
+		validate:     validateAIStrategies,
+	},
+}
+
+// decodeConfigStrict decodes a single JSON value into v, rejecting fields v
+// does not have so a misspelt field is not silently dropped.
+func decodeConfigStrict(value []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(value))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
+
+func validateCoinPackages(ctx context.Context, q repository.ConfigRepository, value []byte, live []byte) error {
+	var packages []config.CoinPackage
+	if err := decodeConfigStrict(value, &packages); err != nil {
+		return err
+	}
+	if len(packages) == 0 {
+		return errors.New("at least one package is required")
+	}
+	seen := make(map[string]bool, len(packages))
+	defaults := 0
+	for i, pkg := range packages {
+		switch {
+		case pkg.PackageID == "":
+			return fmt.Errorf("package %d: packageId is required", i)
+		case seen[pkg.PackageID]:
+			return fmt.Errorf("package %d: duplicate packageId %q", i, pkg.PackageID)
+		case pkg.PackageName == "":
+			return fmt.Errorf("package %q: packageName is required", pkg.PackageID)
+		case pkg.Coins <= 0:
+			return fmt.Errorf("package %q: coins must be positive", pkg.PackageID)
+		case pkg.AmountCents <= 0:
+			return fmt.Errorf("package %q: amountCents must be positive", pkg.PackageID)
+		case pkg.Currency == "":
+			return fmt.Errorf("package %q: currency is required", pkg.PackageID)
+		case pkg.VisualCoins < 0:
+			return fmt.Errorf("package %q: visualCoins must not be negative", pkg.PackageID)
+		}
+		seen[pkg.PackageID] = true
+		if pkg.DefaultPackage {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		return errors.New("at most one package can be the default")
+	}
+	return nil
+}
+
+func validateSignUpConfig(ctx context.Context, q repository.ConfigRepository, value []byte, live []byte) error {
+	var signUp config.SignUpConfig
+	if err := decodeConfigStrict(value, &signUp); err != nil {
+		return err
+	}
+	if signUp.InitialCoins < 0 || signUp.InitialXP < 0 {
+		return errors.New("initial_coins and initial_xp must not be negative")
+	}
+	return nil
+}
+
+// validateAIStrategies also requires that a version string, once used, always
+// names the same mix: sessions are replayed by the version they recorded, so
+// reusing one for different levels would change the AI of games already
+// played under it. The live value is in the history too, so it needs no
+// separate check.
+func validateAIStrategies(ctx context.Context, q repository.ConfigRepository, value []byte, live []byte) error {
+	var strategies config.AIStrategyConfig
+	if err := decodeConfigStrict(value, &strategies); err != nil {
+		return err
+	}
+	if strategies.Version == "" {
+		return errors.New("version is required")
+	}
+	if _, err := config.NewStrategyRegistry(strategies); err != nil {
+		return err
+	}
+
+	previous := config.DefaultAIStrategyConfig()
+	if strategies.Version != previous.Version {
+		recorded, err := q.GetConfigHistoryValueByValueVersion(ctx, config.AIStrategiesKey, strategies.Version)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return configLookupError{fmt.Errorf("get %s version %q: %w", config.AIStrategiesKey, strategies.Version, err)}
+		}
+		previous = config.AIStrategyConfig{}
+		if err := json.Unmarshal(recorded, &previous); err != nil {
+			return nil
+		}
+	}
+	if !reflect.DeepEqual(previous, strategies) {
+		return fmt.Errorf("version %q was already used for different levels", strategies.Version)
+	}
+	return nil
+}
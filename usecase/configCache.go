@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rakshitg600/notakto-solo/config"
+	"github.com/rakshitg600/notakto-solo/logic"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+const (
+	configReloadTimeout  = 5 * time.Second
+	configListenRetryMin = time.Second
+	configListenRetryMax = 30 * time.Second
+)
+
+// ConfigCache holds the decoded value of every config key the server reads,
+// so requests do not query the configs table. Keys without a row hold their
+// built-in defaults. Run keeps it current; values returned are shared and
+// must not be modified.
+type ConfigCache struct {
+	repo repository.ConfigRepository
+
+	mu           sync.RWMutex
+	coinPackages []config.CoinPackage
+	signUp       config.SignUpConfig
+	registry     *logic.StrategyRegistry
+	// pastRegistries holds registries for strategy versions older sessions
+	// recorded, by version.
+	pastRegistries map[string]*logic.StrategyRegistry
+}
+
+// NewConfigCache loads every known key from repo. It fails if a stored value
+// cannot be decoded, rather than starting with a config nobody chose.
+func NewConfigCache(ctx context.Context, repo repository.ConfigRepository) (*ConfigCache, error) {
+	c := &ConfigCache{repo: repo}
+	for key := range configSchemas {
+		if err := c.reload(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *ConfigCache) CoinPackages() []config.CoinPackage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.coinPackages
+}
+
+func (c *ConfigCache) SignUp() config.SignUpConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.signUp
+}
+
+func (c *ConfigCache) StrategyRegistry() *logic.StrategyRegistry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.registry
+}
+
+// StrategyRegistryFor returns the registry a session that recorded version
+// plays with: the live one when version is unset or current, otherwise the
+// ai_strategies value that carried version, looked up in q's config history.
+// A version with no recorded value is logged and played with the live
+// registry, so the session can still finish.
+func (c *ConfigCache) StrategyRegistryFor(ctx context.Context, q repository.ConfigRepository, version pgtype.Text) (*logic.StrategyRegistry, error) {
+	c.mu.RLock()
+	live := c.registry
+	past, ok := c.pastRegistries[version.String]
+	c.mu.RUnlock()
+	if !version.Valid || version.String == live.Version() {
+		return live, nil
+	}
+	if ok {
+		return past, nil
+	}
+
+	strategies := config.DefaultAIStrategyConfig()
+	if version.String != strategies.Version {
+		value, err := q.GetConfigHistoryValueByValueVersion(ctx, config.AIStrategiesKey, version.String)
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("%s version %q not found in config history, using %q", config.AIStrategiesKey, version.String, live.Version())
+			return live, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get %s version %q: %w", config.AIStrategiesKey, version.String, err)
+		}
+		strategies = config.AIStrategyConfig{}
+		if err := json.Unmarshal(value, &strategies); err != nil {
+			return nil, fmt.Errorf("decode %s version %q: %w", config.AIStrategiesKey, version.String, err)
+		}
+	}
+	registry, err := config.NewStrategyRegistry(strategies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s version %q: %w", config.AIStrategiesKey, version.String, err)
+	}
+
+	c.mu.Lock()
+	if c.pastRegistries == nil {
+		c.pastRegistries = make(map[string]*logic.StrategyRegistry)
+	}
+	c.pastRegistries[version.String] = registry
+	c.mu.Unlock()
+	return registry, nil
+}
+
+// Run reloads keys as listener reports changes to them, until ctx is done.
+// Each time the subscription is (re)established every key is reloaded, so
+// changes made while it was down are not missed. A value that fails to
+// decode is logged and the previous one kept.
+func (c *ConfigCache) Run(ctx context.Context, listener repository.ConfigListener) {
+	retry := configListenRetryMin
+	for {
+		err := listener.ListenConfigChanges(ctx,
+			func() {
+				retry = configListenRetryMin
+				for key := range configSchemas {
+					c.reloadLogged(ctx, key)
+				}
+			},
+			func(key string) {
+				c.reloadLogged(ctx, key)
+			},
+		)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("config listener stopped, retrying in %v: %v", retry, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+		retry = min(retry*2, configListenRetryMax)
+	}
+}
+
+func (c *ConfigCache) reloadLogged(ctx context.Context, key string) {
+	if err := c.reload(ctx, key); err != nil {
+		log.Printf("config %s not reloaded, keeping previous value: %v", key, err)
+	}
+}
+
+// reload reads key from the repository; keys the server does not read are
+// ignored.
+func (c *ConfigCache) reload(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, configReloadTimeout)
+	defer cancel()
+
+	switch key {
+	case config.CoinPackagesKey:
+		packages, err := loadCoinPackages(ctx, c.repo)
+		if err != nil {
+			return err
+		}
+		c.mu.Lock()
+		c.coinPackages = packages
+		c.mu.Unlock()
+	case config.SignUpKey:
+		signUp, err := loadSignUpConfig(ctx, c.repo)
+		if err != nil {
+			return err
+		}
+		c.mu.Lock()
+		c.signUp = signUp
+		c.mu.Unlock()
+	case config.AIStrategiesKey:
+		registry, err := loadStrategyRegistry(ctx, c.repo)
+		if err != nil {
+			return err
+		}
+		c.mu.Lock()
+		c.registry = registry
+		c.mu.Unlock()
+	}
+	return nil
+}
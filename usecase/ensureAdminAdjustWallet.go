@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+const maxAdjustmentReasonLength = 500
+
+// EnsureAdminAdjustWallet credits (positive) or debits (negative) a player's
+// coins and XP on behalf of the admin in ctx, and records the change with
+// the admin's uid and reason. It returns the player's updated wallet.
+func EnsureAdminAdjustWallet(ctx context.Context, repo repository.Repository, uid string, coins int64, xp int64, reason string) (
+	wallet db.Wallet,
+	err error,
+) {
+	adminUID, ok := contextkey.UIDFromContext(ctx)
+	if !ok || adminUID == "" {
+		return db.Wallet{}, ErrUnauthenticated
+	}
+	reason = strings.TrimSpace(reason)
+	switch {
+	case uid == "":
+		return db.Wallet{}, fmt.Errorf("%w: uid is required", ErrInvalidAdjustment)
+	case reason == "":
+		return db.Wallet{}, fmt.Errorf("%w: reason is required", ErrInvalidAdjustment)
+	case len(reason) > maxAdjustmentReasonLength:
+		return db.Wallet{}, fmt.Errorf("%w: reason is longer than %d characters", ErrInvalidAdjustment, maxAdjustmentReasonLength)
+	case coins == 0 && xp == 0:
+		return db.Wallet{}, fmt.Errorf("%w: coins or xp must be non-zero", ErrInvalidAdjustment)
+	}
+
+	// The store reads "the current player" from the context
+	playerCtx := context.WithValue(ctx, contextkey.UID, uid)
+	err = runInTx(ctx, repo, "admin_adjust_wallet", func(qtx repository.Queries) error {
+		// STEP 1: Lock the wallet
+		wallet, err = qtx.GetWalletByPlayerIdWithLock(playerCtx)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPlayerNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// STEP 2: Validate the resulting balances
+		if (coins > 0 && wallet.Coins > math.MaxInt64-coins) || (xp > 0 && wallet.Xp > math.MaxInt64-xp) {
+			return fmt.Errorf("%w: balance would overflow", ErrInvalidAdjustment)
+		}
+		newCoins := wallet.Coins + coins
+		newXP := wallet.Xp + xp
+		newDebt := wallet.Debt
+		if newCoins < 0 || newXP < 0 {
+			return ErrNegativeBalance
+		}
+		if coins > 0 && wallet.Debt > 0 {
+			// A credit repays the debt first
+			repaid := min(coins, wallet.Debt)
+			newCoins -= repaid
+			newDebt -= repaid
+		}
+
+		// STEP 3: Audit the adjustment and apply it under the audit row's id
+		adjustmentID, err := qtx.CreateWalletAdjustment(playerCtx, adminUID, coins, xp, reason)
+		if err != nil {
+			return err
+		}
+		if err := qtx.UpdateWalletCoinsAndXpReward(playerCtx, coins, xp, db.WalletTransactionReasonAdminAdjust, strconv.FormatInt(adjustmentID, 10)); err != nil {
+			return err
+		}
+		wallet.Coins = newCoins
+		wallet.Xp = newXP
+		wallet.Debt = newDebt
+		return nil
+	})
+	if err != nil {
+		return db.Wallet{}, err
+	}
+	log.Printf("admin %s adjusted wallet of %s by %d coins, %d xp: %s", adminUID, uid, coins, xp, reason)
+	return wallet, nil
+}
@@ -0,0 +1,51 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+)
+
+func TestEnsureAdminAdjustWallet(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := signUp(t, repo, "player", 100)
+	admin := context.WithValue(context.Background(), contextkey.UID, "admin")
+
+	// Amounts past int32 reach the wallet unchanged
+	const large = int64(math.MaxInt32) * 2
+	got, err := EnsureAdminAdjustWallet(admin, repo, "player", large, large, "migration credit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Coins != 100+large || got.Xp != large {
+		t.Fatalf("after credit: coins=%d xp=%d, want %d and %d", got.Coins, got.Xp, 100+large, large)
+	}
+	if w := wallet(t, ctx, repo); w.Coins != got.Coins || w.Xp != got.Xp {
+		t.Fatalf("stored wallet coins=%d xp=%d, want %d and %d", w.Coins, w.Xp, got.Coins, got.Xp)
+	}
+
+	tests := []struct {
+		name    string
+		coins   int64
+		xp      int64
+		wantErr error
+	}{
+		{"coins overflow", math.MaxInt64, 0, ErrInvalidAdjustment},
+		{"xp overflow", 0, math.MaxInt64, ErrInvalidAdjustment},
+		{"debit past zero", -(101 + large), 0, ErrNegativeBalance},
+		{"nothing to change", 0, 0, ErrInvalidAdjustment},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := EnsureAdminAdjustWallet(admin, repo, "player", tt.coins, tt.xp, "test"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if w := wallet(t, ctx, repo); w.Coins != got.Coins || w.Xp != got.Xp {
+				t.Fatalf("wallet changed to coins=%d xp=%d", w.Coins, w.Xp)
+			}
+		})
+	}
+}
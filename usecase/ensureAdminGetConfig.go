@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+// EnsureAdminGetConfig returns the live value of key and all its versions,
+// newest first.
+func EnsureAdminGetConfig(ctx context.Context, repo repository.Repository, key string) (
+	entry ConfigEntry,
+	history []db.Confighistory,
+	err error,
+) {
+	adminUID, ok := contextkey.UIDFromContext(ctx)
+	if !ok || adminUID == "" {
+		return ConfigEntry{}, nil, ErrUnauthenticated
+	}
+
+	history, err = repo.GetConfigHistoryByKey(ctx, key)
+	if err != nil {
+		return ConfigEntry{}, nil, err
+	}
+	// The configs table holds a handful of rows
+	configs, err := repo.ListConfigs(ctx)
+	if err != nil {
+		return ConfigEntry{}, nil, err
+	}
+	for _, cfg := range configs {
+		if cfg.Key == key {
+			return ConfigEntry{Key: cfg.Key, Value: cfg.Value, Version: cfg.Version, UpdatedAt: cfg.UpdatedAt}, history, nil
+		}
+	}
+	if _, ok := configSchemas[key]; !ok {
+		return ConfigEntry{}, nil, ErrUnknownConfigKey
+	}
+	entry, err = defaultConfigEntry(key)
+	if err != nil {
+		return ConfigEntry{}, nil, err
+	}
+	return entry, history, nil
+}
@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+// adminSessionLimit caps how many of a player's sessions an admin lookup returns.
+const adminSessionLimit = 50
+
+// EnsureAdminGetPlayer looks up a player by uid, or by email when uid is
+// empty, along with their wallet, newest sessions, payments and refunds.
+func EnsureAdminGetPlayer(ctx context.Context, repo repository.Repository, uid string, email string) (
+	player db.Player,
+	wallet db.Wallet,
+	sessions []db.Session,
+	payments []db.Payment,
+	refunds []db.PaymentRefund,
+	err error,
+) {
+	adminUID, ok := contextkey.UIDFromContext(ctx)
+	if !ok || adminUID == "" {
+		return db.Player{}, db.Wallet{}, nil, nil, nil, ErrUnauthenticated
+	}
+
+	if uid != "" {
+		player, err = repo.GetPlayerById(context.WithValue(ctx, contextkey.UID, uid))
+	} else {
+		player, err = repo.GetPlayerByEmail(ctx, email)
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.Player{}, db.Wallet{}, nil, nil, nil, ErrPlayerNotFound
+	}
+	if err != nil {
+		return db.Player{}, db.Wallet{}, nil, nil, nil, err
+	}
+
+	// The store reads "the current player" from the context
+	playerCtx := context.WithValue(ctx, contextkey.UID, player.Uid)
+	wallet, err = repo.GetWalletByPlayerId(playerCtx)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return db.Player{}, db.Wallet{}, nil, nil, nil, err
+	}
+	sessions, err = repo.GetSessionsByPlayerId(playerCtx, adminSessionLimit)
+	if err != nil {
+		return db.Player{}, db.Wallet{}, nil, nil, nil, err
+	}
+	payments, err = repo.GetPaymentsByUid(playerCtx)
+	if err != nil {
+		return db.Player{}, db.Wallet{}, nil, nil, nil, err
+	}
+	refunds, err = repo.ListPaymentRefundsByUid(playerCtx)
+	if err != nil {
+		return db.Player{}, db.Wallet{}, nil, nil, nil, err
+	}
+	return player, wallet, sessions, payments, refunds, nil
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+// ConfigEntry is a config key as admins see it. Version 0 with Default set
+// means the key has no row and Value is the built-in default.
+type ConfigEntry struct {
+	Key       string
+	Value     []byte
+	Version   int32
+	Default   bool
+	UpdatedAt time.Time
+}
+
+// EnsureAdminListConfigs returns every stored config key, plus the keys the
+// server knows but that still run on their defaults, sorted by key.
+func EnsureAdminListConfigs(ctx context.Context, repo repository.Repository) ([]ConfigEntry, error) {
+	adminUID, ok := contextkey.UIDFromContext(ctx)
+	if !ok || adminUID == "" {
+		return nil, ErrUnauthenticated
+	}
+	configs, err := repo.ListConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]ConfigEntry, 0, len(configs)+len(configSchemas))
+	stored := make(map[string]bool, len(configs))
+	for _, cfg := range configs {
+		stored[cfg.Key] = true
+		entries = append(entries, ConfigEntry{Key: cfg.Key, Value: cfg.Value, Version: cfg.Version, UpdatedAt: cfg.UpdatedAt})
+	}
+	for key := range configSchemas {
+		if stored[key] {
+			continue
+		}
+		entry, err := defaultConfigEntry(key)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries, nil
+}
+
+func defaultConfigEntry(key string) (ConfigEntry, error) {
+	value, err := json.Marshal(configSchemas[key].defaultValue())
+	if err != nil {
+		return ConfigEntry{}, fmt.Errorf("encode default %s config: %w", key, err)
+	}
+	return ConfigEntry{Key: key, Value: value, Default: true}, nil
+}
@@ -0,0 +1,21 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+)
+
+// EnsureAdminListWalletMismatches returns the last full reconciliation run,
+// from any instance, and the mismatches stored since.
+func EnsureAdminListWalletMismatches(ctx context.Context, reconciler *WalletReconciler) (
+	run WalletReconcileRun,
+	mismatches []WalletMismatch,
+	err error,
+) {
+	adminUID, ok := contextkey.UIDFromContext(ctx)
+	if !ok || adminUID == "" {
+		return WalletReconcileRun{}, nil, ErrUnauthenticated
+	}
+	return reconciler.Mismatches(ctx)
+}
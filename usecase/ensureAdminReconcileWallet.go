@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"context"
+	"log"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+)
+
+// EnsureAdminReconcileWallet reconciles one player's wallet immediately.
+func EnsureAdminReconcileWallet(ctx context.Context, reconciler *WalletReconciler, uid string) ([]WalletMismatch, error) {
+	adminUID, ok := contextkey.UIDFromContext(ctx)
+	if !ok || adminUID == "" {
+		return nil, ErrUnauthenticated
+	}
+	if uid == "" {
+		return nil, ErrPlayerNotFound
+	}
+	mismatches, err := reconciler.ReconcileWallet(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("admin %s reconciled wallet of %s: %d mismatches", adminUID, uid, len(mismatches))
+	return mismatches, nil
+}
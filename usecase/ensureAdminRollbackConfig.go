@@ -0,0 +1,40 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+// EnsureAdminRollbackConfig makes the value key had at version live again.
+// The rollback is itself a new version, so history stays append-only and
+// can be rolled forward the same way. It returns the new version.
+func EnsureAdminRollbackConfig(ctx context.Context, repo repository.Repository, key string, version int32) (newVersion int32, err error) {
+	adminUID, ok := contextkey.UIDFromContext(ctx)
+	if !ok || adminUID == "" {
+		return 0, ErrUnauthenticated
+	}
+	err = runInTx(ctx, repo, "admin_rollback_config", func(qtx repository.Queries) error {
+		target, err := qtx.GetConfigHistoryVersion(ctx, key, version)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrConfigVersionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		newVersion, err = writeConfigVersion(ctx, qtx, adminUID, key, target.Value)
+		return err
+	})
+	if isUniqueViolation(err) {
+		return 0, ErrConfigConflict
+	}
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("admin %s rolled config %s back to version %d as version %d", adminUID, key, version, newVersion)
+	return newVersion, nil
+}
@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+// EnsureAdminUpsertConfig validates value and makes it the live value of key
+// as a new version. It returns that version.
+func EnsureAdminUpsertConfig(ctx context.Context, repo repository.Repository, key string, value []byte) (version int32, err error) {
+	adminUID, ok := contextkey.UIDFromContext(ctx)
+	if !ok || adminUID == "" {
+		return 0, ErrUnauthenticated
+	}
+	err = runInTx(ctx, repo, "admin_upsert_config", func(qtx repository.Queries) error {
+		version, err = writeConfigVersion(ctx, qtx, adminUID, key, value)
+		return err
+	})
+	if isUniqueViolation(err) {
+		return 0, ErrConfigConflict
+	}
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("admin %s set config %s to version %d", adminUID, key, version)
+	return version, nil
+}
+
+// writeConfigVersion validates value against key's schema and the live
+// value, then stores it as the next version of key.
+func writeConfigVersion(ctx context.Context, qtx repository.Queries, adminUID string, key string, value []byte) (int32, error) {
+	schema, ok := configSchemas[key]
+	if !ok {
+		return 0, ErrUnknownConfigKey
+	}
+
+	// STEP 1: Lock the live row, if any
+	var live []byte
+	current, err := qtx.GetConfigByKeyWithLock(ctx, key)
+	if err == nil {
+		live = current.Value
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+
+	// STEP 2: Validate
+	if err := schema.check(ctx, qtx, value, live); err != nil {
+		return 0, err
+	}
+
+	// STEP 3: Write the next version and make it live
+	latest, err := qtx.GetLatestConfigVersion(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	version := latest + 1
+	if err := qtx.CreateConfigHistory(ctx, key, version, value, adminUID); err != nil {
+		return 0, err
+	}
+	if err := qtx.UpsertConfig(ctx, key, value, version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+// EnsureAdminValidateConfig checks value against the schema for key without
+// writing it.
+func EnsureAdminValidateConfig(ctx context.Context, repo repository.Repository, key string, value []byte) error {
+	adminUID, ok := contextkey.UIDFromContext(ctx)
+	if !ok || adminUID == "" {
+		return ErrUnauthenticated
+	}
+	schema, ok := configSchemas[key]
+	if !ok {
+		return ErrUnknownConfigKey
+	}
+	live, err := repo.GetConfigValueByKey(ctx, key)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	if err := schema.check(ctx, repo, value, live); err != nil {
+		return err
+	}
+	return nil
+}
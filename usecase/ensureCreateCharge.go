@@ -2,37 +2,29 @@ package usecase
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rakshitg600/notakto-solo/config"
 	"github.com/rakshitg600/notakto-solo/contextkey"
-	db "github.com/rakshitg600/notakto-solo/db/generated"
 	"github.com/rakshitg600/notakto-solo/nowpayments"
-	"github.com/rakshitg600/notakto-solo/store"
+	"github.com/rakshitg600/notakto-solo/repository"
 )
 
-func EnsureCreateCharge(ctx context.Context, pool *pgxpool.Pool, npClient *nowpayments.Client, packageID string) (
+func EnsureCreateCharge(ctx context.Context, repo repository.Repository, configs *ConfigCache, npClient *nowpayments.Client, packageID string) (
 	chargeID string,
 	hostedURL string,
 	err error,
 ) {
 	uid, ok := contextkey.UIDFromContext(ctx)
 	if !ok || uid == "" {
-		return "", "", errors.New("missing or invalid uid in context")
+		return "", "", ErrUnauthenticated
 	}
 
-	queries := db.New(pool)
-	packages, err := loadCoinPackages(ctx, queries)
-	if err != nil {
-		return "", "", err
-	}
-	pkg, ok := config.CoinPackageByID(packages, packageID)
+	pkg, ok := config.CoinPackageByID(configs.CoinPackages(), packageID)
 	if !ok {
-		return "", "", fmt.Errorf("invalid package ID: %s", packageID)
+		return "", "", fmt.Errorf("%w: %s", ErrPackageNotFound, packageID)
 	}
 
 	// order_id is our internal identifier echoed back in IPN callbacks, so we
@@ -50,14 +42,15 @@ func EnsureCreateCharge(ctx context.Context, pool *pgxpool.Pool, npClient *nowpa
 		return "", "", fmt.Errorf("nowpayments create invoice failed: %w", err)
 	}
 
-	err = store.CreatePayment(ctx, queries,
+	err = repo.CreatePayment(ctx,
 		orderID,
 		uid,
 		pkg.PackageID,
 		pkg.Coins,
 		pkg.AmountCents,
-		"created",
+		paymentCreated,
 		invoice.InvoiceURL,
+		invoice.ID,
 	)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to save payment record: %w", err)
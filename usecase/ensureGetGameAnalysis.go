@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+	"github.com/rakshitg600/notakto-solo/logic"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+func EnsureGetGameAnalysis(ctx context.Context, repo repository.Repository, sessionID string) (
+	session db.GetSessionStateBySessionIdRow,
+	plies []logic.PlyAnalysis,
+	accuracy float64,
+	blunders int,
+	err error,
+) {
+	uid, ok := contextkey.UIDFromContext(ctx)
+	if !ok || uid == "" {
+		return db.GetSessionStateBySessionIdRow{}, nil, 0, 0, ErrUnauthenticated
+	}
+
+	session, err = repo.GetSessionStateBySessionId(ctx, sessionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.GetSessionStateBySessionIdRow{}, nil, 0, 0, ErrSessionNotFound
+	}
+	if err != nil {
+		return db.GetSessionStateBySessionIdRow{}, nil, 0, 0, err
+	}
+	if session.Uid != uid {
+		return db.GetSessionStateBySessionIdRow{}, nil, 0, 0, ErrSessionForbidden
+	}
+	if !session.Gameover.Valid || !session.Gameover.Bool {
+		return db.GetSessionStateBySessionIdRow{}, nil, 0, 0, ErrGameNotOver
+	}
+	if len(session.IsAiMove) != len(session.Boards) {
+		return db.GetSessionStateBySessionIdRow{}, nil, 0, 0, errors.New("session state corrupted: IsAiMove and Boards length mismatch")
+	}
+
+	plies = logic.AnalyzeGame(session.Boards, session.IsAiMove, session.BoardSize.Int32, session.NumberOfBoards.Int32)
+	return session, plies, logic.GameAccuracy(plies), logic.CountBlunders(plies), nil
+}
@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+const hintCost = 150
+
+// EnsureGetHint searches the player's position outside any transaction, since
+// the search can take seconds, then charges for the hint in a short one that
+// fails with ErrSessionChanged if a move was played meanwhile.
+func EnsureGetHint(ctx context.Context, repo repository.Repository, sessionID string) (
+	boardIndex int32,
+	cellIndex int32,
+	winning bool,
+	exact bool,
+	err error,
+) {
+	uid, ok := contextkey.UIDFromContext(ctx)
+	if !ok || uid == "" {
+		return 0, 0, false, false, ErrUnauthenticated
+	}
+
+	// STEP 1: Validate sessionId and gameover without locking the session
+	existing, err := repo.GetLatestSessionStateByPlayerId(ctx)
+	if err != nil {
+		return 0, 0, false, false, err
+	}
+	if existing.SessionID != sessionID {
+		return 0, 0, false, false, ErrSessionNotFound
+	}
+	if existing.Gameover.Valid && existing.Gameover.Bool {
+		return 0, 0, false, false, ErrGameOver
+	}
+	g, err := loadGame(db.GetLatestSessionStateByPlayerIdWithLockRow(existing))
+	if err != nil {
+		return 0, 0, false, false, err
+	}
+
+	// STEP 2: Refuse early if the wallet cannot pay, before the search
+	wallet, err := repo.GetWalletByPlayerId(ctx)
+	if err != nil {
+		return 0, 0, false, false, err
+	}
+	if err := checkHintWallet(wallet); err != nil {
+		return 0, 0, false, false, err
+	}
+
+	// STEP 3: Find the hint before charging for it
+	boardIndex, cellIndex, winning, exact, err = g.Hint()
+	if err != nil {
+		return 0, 0, false, false, fromGameError(err)
+	}
+
+	err = runInTx(ctx, repo, "get_hint", func(qtx repository.Queries) error {
+		// STEP 4: Check the session is still at the searched position
+		current, err := qtx.GetLatestSessionStateByPlayerIdWithLock(ctx)
+		if err != nil {
+			return err
+		}
+		if current.SessionID != sessionID {
+			return ErrSessionNotFound
+		}
+		if current.Gameover.Valid && current.Gameover.Bool {
+			return ErrGameOver
+		}
+		if !slices.Equal(current.Boards, g.Boards()) {
+			return ErrSessionChanged
+		}
+
+		// STEP 5: Check wallet for sufficient coins
+		wallet, err := qtx.GetWalletByPlayerIdWithLock(ctx)
+		if err != nil {
+			return err
+		}
+		if err := checkHintWallet(wallet); err != nil {
+			return err
+		}
+
+		// STEP 6: Deduct coins
+		return qtx.UpdateWalletReduceCoins(ctx, hintCost, db.WalletTransactionReasonHintCost, sessionID)
+	})
+	if err != nil {
+		return 0, 0, false, false, err
+	}
+	return boardIndex, cellIndex, winning, exact, nil
+}
+
+func checkHintWallet(wallet db.Wallet) error {
+	if wallet.Debt > 0 {
+		return ErrWalletInDebt
+	}
+	if wallet.Coins < hintCost {
+		return fmt.Errorf("%w for hint", ErrInsufficientCoins)
+	}
+	return nil
+}
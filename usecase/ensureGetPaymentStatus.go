@@ -4,22 +4,22 @@ import (
 	"context"
 	"errors"
 
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 	"github.com/rakshitg600/notakto-solo/contextkey"
 	db "github.com/rakshitg600/notakto-solo/db/generated"
-	"github.com/rakshitg600/notakto-solo/store"
+	"github.com/rakshitg600/notakto-solo/repository"
 )
 
-var ErrPaymentForbidden = errors.New("payment access denied")
-
-func EnsureGetPaymentStatus(ctx context.Context, pool *pgxpool.Pool, chargeID string) (db.Payment, error) {
+func EnsureGetPaymentStatus(ctx context.Context, repo repository.Repository, chargeID string) (db.Payment, error) {
 	uid, ok := contextkey.UIDFromContext(ctx)
 	if !ok || uid == "" {
-		return db.Payment{}, errors.New("missing or invalid uid in context")
+		return db.Payment{}, ErrUnauthenticated
 	}
 
-	queries := db.New(pool)
-	payment, err := store.GetPaymentById(ctx, queries, chargeID)
+	payment, err := repo.GetPaymentById(ctx, chargeID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.Payment{}, ErrPaymentNotFound
+	}
 	if err != nil {
 		return db.Payment{}, err
 	}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+const (
+	defaultWalletHistoryLimit = 20
+	maxWalletHistoryLimit     = 100
+)
+
+// walletHistoryFilters maps the player-facing history types to the ledger
+// reasons they cover. An empty type returns every reason.
+var walletHistoryFilters = map[string][]db.WalletTransactionReason{
+	"rewards":   {db.WalletTransactionReasonGameReward},
+	"purchases": {db.WalletTransactionReasonPurchase, db.WalletTransactionReasonRefund},
+	"spends": {
+		db.WalletTransactionReasonSkipCost,
+		db.WalletTransactionReasonUndoCost,
+		db.WalletTransactionReasonHintCost,
+	},
+}
+
+// EnsureGetWalletHistory returns a page of the caller's wallet changes,
+// newest first. cursor is empty for the first page and otherwise the
+// nextCursor of the previous page; nextCursor is empty on the last page.
+func EnsureGetWalletHistory(ctx context.Context, repo repository.Repository, historyType string, cursor string, limit int) (
+	transactions []db.WalletTransaction,
+	nextCursor string,
+	err error,
+) {
+	uid, ok := contextkey.UIDFromContext(ctx)
+	if !ok || uid == "" {
+		return nil, "", ErrUnauthenticated
+	}
+
+	var reasons []db.WalletTransactionReason
+	if historyType != "" {
+		reasons, ok = walletHistoryFilters[historyType]
+		if !ok {
+			return nil, "", ErrInvalidHistoryType
+		}
+	}
+	var beforeID int64
+	if cursor != "" {
+		beforeID, err = strconv.ParseInt(cursor, 10, 64)
+		if err != nil || beforeID <= 0 {
+			return nil, "", ErrInvalidCursor
+		}
+	}
+	if limit <= 0 {
+		limit = defaultWalletHistoryLimit
+	}
+	limit = min(limit, maxWalletHistoryLimit)
+
+	// Fetch one extra row to learn whether another page follows.
+	transactions, err = repo.ListWalletTransactions(ctx, beforeID, reasons, int32(limit+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		nextCursor = strconv.FormatInt(transactions[limit-1].ID, 10)
+	}
+	return transactions, nextCursor, nil
+}
@@ -4,22 +4,19 @@ import (
 	"context"
 	"errors"
 
-	"firebase.google.com/go/v4/auth"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rakshitg600/notakto-solo/contextkey"
-	db "github.com/rakshitg600/notakto-solo/db/generated"
-	"github.com/rakshitg600/notakto-solo/store"
+	"github.com/rakshitg600/notakto-solo/identity"
+	"github.com/rakshitg600/notakto-solo/repository"
 )
 
-func EnsureLogin(ctx context.Context, pool *pgxpool.Pool, authClient *auth.Client) (profilePic string, name string, email string, isNew bool, err error) {
+func EnsureLogin(ctx context.Context, repo repository.Repository, configs *ConfigCache, provider identity.Provider) (profilePic string, name string, email string, isNew bool, err error) {
 	uid, ok := contextkey.UIDFromContext(ctx)
 	if !ok || uid == "" {
-		return "", "", "", false, errors.New("missing or invalid uid in context")
+		return "", "", "", false, ErrUnauthenticated
 	}
 	// STEP 1: Try existing session
-	queries := db.New(pool)
-	existing, err := store.GetPlayerById(ctx, queries)
+	existing, err := repo.GetPlayerById(ctx)
 	if err == nil && existing.Uid != "" {
 		name = existing.Name
 		email = existing.Email
@@ -36,38 +33,23 @@ func EnsureLogin(ctx context.Context, pool *pgxpool.Pool, authClient *auth.Clien
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return "", "", "", false, err
 	}
-	// STEP 2: Fetch profile from Firebase
-	name, email, profilePic, err = GetFirebaseUserProfile(ctx, authClient)
+	// STEP 2: Fetch profile from the identity provider
+	name, email, profilePic, err = GetUserProfile(ctx, provider)
 	if err != nil {
 		return "", "", "", true, err
 	}
-	signUp, err := loadSignUpConfig(ctx, queries)
-	if err != nil {
-		return "", "", "", true, err
-	}
-	tx, err := pool.BeginTx(ctx, pgx.TxOptions{
-		IsoLevel:   pgx.Serializable,
-		AccessMode: pgx.ReadWrite,
+	signUp := configs.SignUp()
+	err = runInTx(ctx, repo, "login", func(qtx repository.Queries) error {
+		// STEP 3: Create new player
+		if err := qtx.CreatePlayer(ctx, name, email, profilePic); err != nil {
+			return err
+		}
+		// STEP 4: Create Wallet for player
+		return qtx.CreateWallet(ctx, signUp)
 	})
 	if err != nil {
 		return "", "", "", true, err
 	}
-	defer tx.Rollback(ctx)
-
-	qtx := queries.WithTx(tx)
-	// STEP 3: Create new player
-	err = store.CreatePlayer(ctx, qtx, name, email, profilePic)
-	if err != nil {
-		return "", "", "", true, err
-	}
-	// STEP 4: Create Wallet for player
-	err = store.CreateWallet(ctx, qtx, signUp)
-	if err != nil {
-		return "", "", "", true, err
-	}
-	if err := tx.Commit(ctx); err != nil {
-		return "", "", "", true, err
-	}
 	// STEP 5: Return values
 	return profilePic, name, email, true, nil
 }
@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestEnsureMakeMove(t *testing.T) {
+	repo, configs := newTestRepo(t)
+	ctx := signUp(t, repo, "player", 0)
+	sessionID := startSession(t, ctx, repo, configs, 2, 3, 1)
+
+	boards, isAiMove, gameOver, _, _, _, err := EnsureMakeMove(ctx, repo, configs, sessionID, 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(boards) != 2 || boards[0] != 4 || isAiMove[0] || !isAiMove[1] || gameOver {
+		t.Fatalf("after one move: boards %v, isAiMove %v, gameOver %v", boards, isAiMove, gameOver)
+	}
+	stored := session(t, repo, sessionID)
+	if len(stored.Boards) != 2 || stored.Boards[1] != boards[1] {
+		t.Fatalf("stored boards %v, returned %v", stored.Boards, boards)
+	}
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		sessionID  string
+		boardIndex int32
+		cellIndex  int32
+		wantErr    error
+	}{
+		{"unauthenticated", context.Background(), sessionID, 0, 0, ErrUnauthenticated},
+		{"other session", ctx, "not-" + sessionID, 0, 0, ErrSessionNotFound},
+		{"board out of range", ctx, sessionID, 2, 0, ErrInvalidBoard},
+		{"cell out of range", ctx, sessionID, 0, 9, ErrInvalidCell},
+		{"marked cell", ctx, sessionID, 0, 4, ErrCellOccupied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, _, _, _, err := EnsureMakeMove(tt.ctx, repo, configs, tt.sessionID, tt.boardIndex, tt.cellIndex)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("EnsureMakeMove error = %v, want %v", err, tt.wantErr)
+			}
+			if after := session(t, repo, sessionID); len(after.Boards) != 2 {
+				t.Fatalf("rejected move changed the session to %v", after.Boards)
+			}
+		})
+	}
+}
+
+func TestEnsureMakeMovePaysOutWhenTheGameEnds(t *testing.T) {
+	repo, configs := newTestRepo(t)
+	ctx := signUp(t, repo, "player", 0)
+	sessionID := startSession(t, ctx, repo, configs, 1, 3, 1)
+
+	var coins, xp int64
+	for {
+		boardIndex, cellIndex := firstLegalMove(t, session(t, repo, sessionID))
+		_, _, gameOver, _, coinsRewarded, xpRewarded, err := EnsureMakeMove(ctx, repo, configs, sessionID, boardIndex, cellIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gameOver {
+			coins, xp = coinsRewarded, xpRewarded
+			break
+		}
+	}
+	if !session(t, repo, sessionID).Gameover.Bool {
+		t.Fatal("session not marked over")
+	}
+	if w := wallet(t, ctx, repo); w.Coins != coins || w.Xp != xp || xp == 0 {
+		t.Fatalf("wallet %d coins %d xp, want the rewards %d coins %d xp", w.Coins, w.Xp, coins, xp)
+	}
+	if _, _, _, _, _, _, err := EnsureMakeMove(ctx, repo, configs, sessionID, 0, 0); !errors.Is(err, ErrGameOver) {
+		t.Fatalf("move after the game ended: error = %v, want ErrGameOver", err)
+	}
+}
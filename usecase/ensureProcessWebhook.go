@@ -7,105 +7,213 @@ import (
 	"log"
 
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rakshitg600/notakto-solo/contextkey"
 	db "github.com/rakshitg600/notakto-solo/db/generated"
-	"github.com/rakshitg600/notakto-solo/store"
+	"github.com/rakshitg600/notakto-solo/repository"
 )
 
 // EnsureProcessWebhook maps a NOWPayments IPN payment_status to an internal
 // Payment row status and credits coins when the payment is finalized.
+// providerPaymentID, when known, is recorded in the same transaction so the
+// payment poller can ask NOWPayments about this payment later; only while
+// the payment is created or pending, before the status changes.
 //
 // Status semantics (from NOWPayments docs):
 //   - waiting / confirming / confirmed / sending → payment in-flight, mark pending
 //   - finished → funds settled in our wallet, credit coins
-//   - failed / refunded / expired → terminal failure
+//   - refunded → funds returned to the payer; take back credited coins
+//   - failed → terminal failure
+//   - expired → the player never paid within the provider's window
 //   - partially_paid → user underpaid; terminal, logged for manual review
-func EnsureProcessWebhook(ctx context.Context, pool *pgxpool.Pool, paymentStatus string, orderID string) error {
+func EnsureProcessWebhook(ctx context.Context, repo repository.Repository, paymentStatus string, orderID string, providerPaymentID string) error {
 	switch paymentStatus {
 	case "waiting", "confirming", "confirmed", "sending":
-		return processPaymentPending(ctx, pool, orderID)
+		return processPaymentPending(ctx, repo, orderID, providerPaymentID)
 	case "finished":
-		return processPaymentFinished(ctx, pool, orderID)
-	case "failed", "refunded", "expired":
-		return processPaymentFailed(ctx, pool, orderID, paymentStatus)
+		return processPaymentFinished(ctx, repo, orderID, providerPaymentID)
+	case "refunded":
+		return processPaymentRefunded(ctx, repo, orderID, providerPaymentID, paymentStatus)
+	case "failed":
+		return processPaymentFailed(ctx, repo, orderID, providerPaymentID, paymentStatus)
+	case "expired":
+		return processPaymentExpired(ctx, repo, orderID, providerPaymentID)
 	case "partially_paid":
 		log.Printf("webhook: order %s partially_paid — marking failed, needs manual review", orderID)
-		return processPaymentFailed(ctx, pool, orderID, paymentStatus)
+		return processPaymentFailed(ctx, repo, orderID, providerPaymentID, paymentStatus)
 	default:
 		log.Printf("ignoring unhandled nowpayments payment_status: %s", paymentStatus)
 		return nil
 	}
 }
 
-func processPaymentPending(ctx context.Context, pool *pgxpool.Pool, orderID string) error {
-	queries := db.New(pool)
-	rowsAffected, err := store.UpdatePaymentStatusIfNotConfirmed(ctx, queries, orderID, "pending")
+// recordProviderPayment notes the NOWPayments payment made against orderID.
+// It must run before the status transition in the same transaction, as the
+// query skips payments that are no longer created or pending.
+func recordProviderPayment(ctx context.Context, qtx repository.Queries, orderID string, providerPaymentID string) error {
+	if providerPaymentID == "" {
+		return nil
+	}
+	if err := qtx.UpdatePaymentProviderPaymentId(ctx, orderID, providerPaymentID); err != nil {
+		return fmt.Errorf("failed to record provider payment id: %w", err)
+	}
+	return nil
+}
+
+// transitionWithProviderPayment records providerPaymentID and moves orderID
+// to status in one transaction.
+func transitionWithProviderPayment(ctx context.Context, repo repository.Repository, orderID string, providerPaymentID string, status string) (bool, error) {
+	var moved bool
+	err := runInTx(ctx, repo, "payment_"+status, func(qtx repository.Queries) error {
+		if err := recordProviderPayment(ctx, qtx, orderID, providerPaymentID); err != nil {
+			return err
+		}
+		var err error
+		moved, err = transitionPayment(ctx, qtx, orderID, status)
+		return err
+	})
+	return moved, err
+}
+
+func processPaymentPending(ctx context.Context, repo repository.Repository, orderID string, providerPaymentID string) error {
+	moved, err := transitionWithProviderPayment(ctx, repo, orderID, providerPaymentID, paymentPending)
 	if err != nil {
 		return fmt.Errorf("failed to update payment to pending: %w", err)
 	}
-	if rowsAffected == 0 {
-		log.Printf("order %s already confirmed or not found, skipping pending update", orderID)
+	if !moved {
+		log.Printf("order %s already credited or not found, skipping pending update", orderID)
 	}
 	return nil
 }
 
-func processPaymentFinished(ctx context.Context, pool *pgxpool.Pool, orderID string) error {
-	queries := db.New(pool)
+func processPaymentFinished(ctx context.Context, repo repository.Repository, orderID string, providerPaymentID string) error {
+	var credited *db.Payment
+	err := runInTx(ctx, repo, "payment_finished", func(qtx repository.Queries) error {
+		credited = nil
+		payment, err := qtx.GetPaymentByIdWithLock(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("payment not found for order: %s", orderID)
+			}
+			return fmt.Errorf("failed to lock payment row: %w", err)
+		}
+		if err := recordProviderPayment(ctx, qtx, orderID, providerPaymentID); err != nil {
+			return err
+		}
+
+		moved, err := transitionPayment(ctx, qtx, orderID, paymentConfirmed)
+		if err != nil {
+			return fmt.Errorf("failed to update payment to confirmed: %w", err)
+		}
+		if !moved {
+			log.Printf("order %s is already %s, skipping", orderID, payment.Status)
+			return nil
+		}
 
-	tx, err := pool.BeginTx(ctx, pgx.TxOptions{
-		IsoLevel:   pgx.Serializable,
-		AccessMode: pgx.ReadWrite,
+		err = qtx.CreditWalletCoins(ctx, payment.Uid, int64(payment.Coins), db.WalletTransactionReasonPurchase, payment.ID)
+		if err != nil {
+			return fmt.Errorf("failed to credit wallet coins: %w", err)
+		}
+		credited = &payment
+		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return err
 	}
-	defer tx.Rollback(ctx)
 
-	qtx := queries.WithTx(tx)
+	if credited != nil {
+		log.Printf("order %s finished: credited %d coins to uid %s", orderID, credited.Coins, credited.Uid)
+	}
+	return nil
+}
 
-	payment, err := store.GetPaymentByIdWithLock(ctx, qtx, orderID)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("payment not found for order: %s", orderID)
+// processPaymentRefunded takes back the coins of a confirmed payment. What
+// the player has already spent becomes debt on their wallet, and the refund
+// is recorded for support review. A payment refunded before it was confirmed
+// was never credited and is marked failed instead.
+func processPaymentRefunded(ctx context.Context, repo repository.Repository, orderID string, providerPaymentID string, providerStatus string) error {
+	var refund *db.PaymentRefund
+	err := runInTx(ctx, repo, "payment_refunded", func(qtx repository.Queries) error {
+		refund = nil
+		// STEP 1: Lock the payment
+		payment, err := qtx.GetPaymentByIdWithLock(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("payment not found for order: %s", orderID)
+			}
+			return fmt.Errorf("failed to lock payment row: %w", err)
+		}
+		switch payment.Status {
+		case paymentConfirmed:
+		case paymentRefunded:
+			log.Printf("order %s already refunded, skipping", orderID)
+			return nil
+		default:
+			if err := recordProviderPayment(ctx, qtx, orderID, providerPaymentID); err != nil {
+				return err
+			}
+			if _, err := transitionPayment(ctx, qtx, orderID, paymentFailed); err != nil {
+				return fmt.Errorf("failed to update payment to failed (%s): %w", providerStatus, err)
+			}
+			return nil
 		}
-		return fmt.Errorf("failed to lock payment row: %w", err)
-	}
 
-	if payment.Status == "confirmed" {
-		log.Printf("order %s already confirmed, skipping", orderID)
-		return nil
-	}
+		// STEP 2: Mark it refunded
+		if _, err := transitionPayment(ctx, qtx, orderID, paymentRefunded); err != nil {
+			return fmt.Errorf("failed to update payment to refunded: %w", err)
+		}
 
-	rowsAffected, err := store.UpdatePaymentStatusIfNotConfirmed(ctx, qtx, orderID, "confirmed")
+		// STEP 3: Take the coins back, as debt where the wallet cannot cover them
+		playerCtx := context.WithValue(ctx, contextkey.UID, payment.Uid)
+		wallet, err := qtx.GetWalletByPlayerIdWithLock(playerCtx)
+		if err != nil {
+			return fmt.Errorf("failed to lock wallet of %s: %w", payment.Uid, err)
+		}
+		coins := int64(payment.Coins)
+		clawedBack := min(coins, wallet.Coins)
+		if err := qtx.RefundWalletCoins(ctx, payment.Uid, coins, db.WalletTransactionReasonRefund, payment.ID); err != nil {
+			return fmt.Errorf("failed to take back wallet coins: %w", err)
+		}
+
+		// STEP 4: Record the refund for support
+		refund = &db.PaymentRefund{
+			PaymentID:       payment.ID,
+			Uid:             payment.Uid,
+			Coins:           payment.Coins,
+			CoinsClawedBack: clawedBack,
+			DebtAdded:       coins - clawedBack,
+			ProviderStatus:  providerStatus,
+		}
+		return qtx.CreatePaymentRefund(ctx, refund.PaymentID, refund.Uid, refund.Coins, refund.CoinsClawedBack, refund.DebtAdded, refund.ProviderStatus)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update payment to confirmed: %w", err)
+		return err
 	}
-	if rowsAffected == 0 {
-		log.Printf("order %s status update returned 0 rows, already confirmed", orderID)
-		return nil
+
+	if refund != nil {
+		log.Printf("order %s refunded: took back %d of %d coins from uid %s, %d left as debt",
+			orderID, refund.CoinsClawedBack, refund.Coins, refund.Uid, refund.DebtAdded)
 	}
+	return nil
+}
 
-	err = store.CreditWalletCoins(ctx, qtx, payment.Uid, payment.Coins)
+func processPaymentFailed(ctx context.Context, repo repository.Repository, orderID string, providerPaymentID string, reason string) error {
+	moved, err := transitionWithProviderPayment(ctx, repo, orderID, providerPaymentID, paymentFailed)
 	if err != nil {
-		return fmt.Errorf("failed to credit wallet coins: %w", err)
+		return fmt.Errorf("failed to update payment to failed (%s): %w", reason, err)
 	}
-
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	if !moved {
+		log.Printf("order %s already credited or not found, skipping failed update (reason: %s)", orderID, reason)
 	}
-
-	log.Printf("order %s finished: credited %d coins to uid %s", orderID, payment.Coins, payment.Uid)
 	return nil
 }
 
-func processPaymentFailed(ctx context.Context, pool *pgxpool.Pool, orderID string, reason string) error {
-	queries := db.New(pool)
-	rowsAffected, err := store.UpdatePaymentStatusIfNotConfirmed(ctx, queries, orderID, "failed")
+func processPaymentExpired(ctx context.Context, repo repository.Repository, orderID string, providerPaymentID string) error {
+	moved, err := transitionWithProviderPayment(ctx, repo, orderID, providerPaymentID, paymentExpired)
 	if err != nil {
-		return fmt.Errorf("failed to update payment to failed (%s): %w", reason, err)
+		return fmt.Errorf("failed to update payment to expired: %w", err)
 	}
-	if rowsAffected == 0 {
-		log.Printf("order %s already confirmed or not found, skipping failed update (reason: %s)", orderID, reason)
+	if !moved {
+		log.Printf("order %s already settled or not found, skipping expired update", orderID)
 	}
 	return nil
 }
@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestEnsureProcessWebhook(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []string
+		wantStatus   string
+		wantCoins    int64
+		wantDebt     int64
+		wantProvider string
+	}{
+		{"in flight", []string{"waiting", "confirming"}, paymentPending, 0, 0, "np-1"},
+		{"finished", []string{"waiting", "finished"}, paymentConfirmed, 500, 0, "np-1"},
+		{"finished twice", []string{"finished", "finished"}, paymentConfirmed, 500, 0, "np-1"},
+		{"late waiting after finished", []string{"finished", "waiting"}, paymentConfirmed, 500, 0, "np-1"},
+		{"failed", []string{"failed"}, paymentFailed, 0, 0, "np-1"},
+		{"partially paid", []string{"partially_paid"}, paymentFailed, 0, 0, "np-1"},
+		{"expired", []string{"waiting", "expired"}, paymentExpired, 0, 0, "np-1"},
+		{"finished after expiry", []string{"expired", "finished"}, paymentConfirmed, 500, 0, "np-1"},
+		{"expired after finished", []string{"finished", "expired"}, paymentConfirmed, 500, 0, "np-1"},
+		{"refunded", []string{"finished", "refunded"}, paymentRefunded, 0, 0, "np-1"},
+		{"refunded before finished", []string{"waiting", "refunded"}, paymentFailed, 0, 0, "np-1"},
+		{"unknown status", []string{"who_knows"}, paymentCreated, 0, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newTestRepo(t)
+			ctx := signUp(t, repo, "player", 0)
+			createPayment(t, repo, "order-1", "player", 500)
+			for _, status := range tt.statuses {
+				if err := EnsureProcessWebhook(context.Background(), repo, status, "order-1", "np-1"); err != nil {
+					t.Fatalf("IPN %s: %v", status, err)
+				}
+			}
+			p := payment(t, repo, "order-1")
+			if p.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", p.Status, tt.wantStatus)
+			}
+			if p.ProviderPaymentID.String != tt.wantProvider {
+				t.Errorf("provider payment id = %q, want %q", p.ProviderPaymentID.String, tt.wantProvider)
+			}
+			if w := wallet(t, ctx, repo); w.Coins != tt.wantCoins || w.Debt != tt.wantDebt {
+				t.Errorf("wallet %d coins %d debt, want %d and %d", w.Coins, w.Debt, tt.wantCoins, tt.wantDebt)
+			}
+		})
+	}
+}
+
+func TestEnsureProcessWebhookProviderPayment(t *testing.T) {
+	tests := []struct {
+		name string
+		ipns [][2]string // status, provider payment id
+		want string
+	}{
+		{"replaced while pending", [][2]string{{"waiting", "np-1"}, {"waiting", "np-2"}}, "np-2"},
+		{"kept after finished", [][2]string{{"finished", "np-1"}, {"finished", "np-2"}}, "np-1"},
+		{"kept after refunded", [][2]string{{"finished", "np-1"}, {"refunded", "np-2"}}, "np-1"},
+		{"kept after failed", [][2]string{{"failed", "np-1"}, {"waiting", "np-2"}}, "np-1"},
+		{"kept after expired", [][2]string{{"expired", "np-1"}, {"finished", "np-2"}}, "np-1"},
+		{"recorded by a later IPN", [][2]string{{"waiting", ""}, {"finished", "np-2"}}, "np-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newTestRepo(t)
+			signUp(t, repo, "player", 0)
+			createPayment(t, repo, "order-1", "player", 500)
+			for _, ipn := range tt.ipns {
+				if err := EnsureProcessWebhook(context.Background(), repo, ipn[0], "order-1", ipn[1]); err != nil {
+					t.Fatalf("IPN %s: %v", ipn[0], err)
+				}
+			}
+			if got := payment(t, repo, "order-1").ProviderPaymentID.String; got != tt.want {
+				t.Fatalf("provider payment id = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureProcessWebhookRefundAfterSpending(t *testing.T) {
+	repo, configs := newTestRepo(t)
+	ctx := signUp(t, repo, "player", 0)
+	createPayment(t, repo, "order-1", "player", 500)
+	if err := EnsureProcessWebhook(context.Background(), repo, "finished", "order-1", "np-1"); err != nil {
+		t.Fatal(err)
+	}
+	sessionID := startSession(t, ctx, repo, configs, 2, 3, 1)
+	if _, _, _, _, _, _, err := EnsureMakeMove(ctx, repo, configs, sessionID, 0, 4); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, _, _, _, err := EnsureSkipMove(ctx, repo, configs, sessionID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 200 of the 500 coins were spent on the skip; they become debt.
+	for range 2 {
+		if err := EnsureProcessWebhook(context.Background(), repo, "refunded", "order-1", "np-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w := wallet(t, ctx, repo); w.Coins != 0 || w.Debt != 200 {
+		t.Fatalf("wallet %d coins %d debt after the refund, want 0 and 200", w.Coins, w.Debt)
+	}
+	refunds, err := repo.ListPaymentRefundsByUid(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refunds) != 1 || refunds[0].CoinsClawedBack != 300 || refunds[0].DebtAdded != 200 {
+		t.Fatalf("refunds = %+v, want one taking back 300 coins and adding 200 debt", refunds)
+	}
+	if _, _, _, _, _, _, err := EnsureSkipMove(ctx, repo, configs, sessionID); !errors.Is(err, ErrWalletInDebt) {
+		t.Fatalf("skip while in debt: error = %v, want ErrWalletInDebt", err)
+	}
+}
+
+func TestEnsureProcessWebhookUnknownOrder(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	if err := EnsureProcessWebhook(context.Background(), repo, "finished", "missing", ""); err == nil {
+		t.Fatal("finished IPN for an unknown order succeeded")
+	}
+	if err := EnsureProcessWebhook(context.Background(), repo, "waiting", "missing", "np-1"); err != nil {
+		t.Fatalf("waiting IPN for an unknown order: %v", err)
+	}
+}
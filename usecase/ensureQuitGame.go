@@ -2,53 +2,37 @@ package usecase
 
 import (
 	"context"
-	"errors"
 
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
-	db "github.com/rakshitg600/notakto-solo/db/generated"
 	"github.com/rakshitg600/notakto-solo/contextkey"
-	"github.com/rakshitg600/notakto-solo/store"
+	"github.com/rakshitg600/notakto-solo/repository"
 )
 
-func EnsureQuitGame(ctx context.Context, pool *pgxpool.Pool, sessionID string) (
+func EnsureQuitGame(ctx context.Context, repo repository.Repository, sessionID string) (
 	success bool,
 	err error,
 ) {
 	uid, ok := contextkey.UIDFromContext(ctx)
 	if !ok || uid == "" {
-		return false, errors.New("missing or invalid uid in context")
+		return false, ErrUnauthenticated
 	}
-	queries := db.New(pool)
-	tx, err := pool.BeginTx(ctx, pgx.TxOptions{
-		IsoLevel:   pgx.Serializable,
-		AccessMode: pgx.ReadWrite,
+	err = runInTx(ctx, repo, "quit_game", func(qtx repository.Queries) error {
+		// STEP 1: Validate sessionId
+		existing, err := qtx.GetLatestSessionStateByPlayerIdWithLock(ctx)
+		if err != nil {
+			return err
+		}
+		if existing.SessionID != sessionID {
+			return ErrSessionNotFound
+		}
+		// STEP 2: Validate gameover
+		if existing.Gameover.Valid && existing.Gameover.Bool {
+			return nil
+		}
+		// STEP 3: Update gameover to true
+		return qtx.QuitGameSession(ctx, sessionID)
 	})
 	if err != nil {
 		return false, err
 	}
-	defer tx.Rollback(ctx)
-
-	qtx := queries.WithTx(tx)
-	// STEP 1: Validate sessionId
-	existing, err := store.GetLatestSessionStateByPlayerIdWithLock(ctx, qtx)
-	if err != nil {
-		return false, err
-	}
-	if existing.SessionID != sessionID {
-		return false, errors.New("session expired or not found")
-	}
-	// STEP 2: Validate gameover
-	if existing.Gameover.Valid && existing.Gameover.Bool {
-		return true, nil
-	}
-	// STEP 3: Update gameover to true
-	err = store.QuitGameSession(ctx, qtx, sessionID)
-	if err != nil {
-		return false, err
-	}
-	if err := tx.Commit(ctx); err != nil {
-		return false, err
-	}
 	return true, nil
 }
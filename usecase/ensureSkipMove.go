@@ -2,143 +2,84 @@ package usecase
 
 import (
 	"context"
-	"errors"
+	"fmt"
 
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/jackc/pgx/v5/pgxpool"
-	db "github.com/rakshitg600/notakto-solo/db/generated"
 	"github.com/rakshitg600/notakto-solo/contextkey"
-	"github.com/rakshitg600/notakto-solo/logic"
-	"github.com/rakshitg600/notakto-solo/store"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+	"github.com/rakshitg600/notakto-solo/repository"
 )
 
-func EnsureSkipMove(ctx context.Context, pool *pgxpool.Pool, sessionID string) (
+func EnsureSkipMove(ctx context.Context, repo repository.Repository, configs *ConfigCache, sessionID string) (
 	boards []int32,
 	isAiMove []bool,
 	gameOver bool,
 	winner bool,
-	coinsRewarded int32,
-	xpRewarded int32,
+	coinsRewarded int64,
+	xpRewarded int64,
 	err error,
 ) {
 	uid, ok := contextkey.UIDFromContext(ctx)
 	if !ok || uid == "" {
-		return nil, nil, false, false, 0, 0, errors.New("missing or invalid uid in context")
-	}
-	queries := db.New(pool)
-	tx, err := pool.BeginTx(ctx, pgx.TxOptions{
-		IsoLevel:   pgx.Serializable,
-		AccessMode: pgx.ReadWrite,
-	})
-	if err != nil {
-		return nil, nil, false, false, 0, 0, err
-	}
-	defer tx.Rollback(ctx)
-
-	qtx := queries.WithTx(tx)
-	// STEP 1: Validate sessionId
-	existing, err := store.GetLatestSessionStateByPlayerIdWithLock(ctx, qtx)
-	if err != nil {
-		return nil, nil, false, false, 0, 0, err
-	}
-	if existing.SessionID != sessionID {
-		return nil, nil, false, false, 0, 0, errors.New("session expired or not found")
-	}
-	// Validate IsAiMove and Boards length alignment
-	if len(existing.IsAiMove) != len(existing.Boards) {
-		return nil, nil, false, false, 0, 0, errors.New("session state corrupted: IsAiMove and Boards length mismatch")
+		return nil, nil, false, false, 0, 0, ErrUnauthenticated
 	}
-	// STEP 2: Validate gameover
-	if existing.Gameover.Valid && existing.Gameover.Bool {
-		return nil, nil, true, existing.Winner.Bool, 0, 0, errors.New("game is already over")
-	}
-	// STEP 3: Verify if game is over before skipping move
-	existing.Gameover = pgtype.Bool{Bool: true, Valid: true}
-	for i := int32(0); i < existing.NumberOfBoards.Int32; i++ {
-		if !logic.IsBoardDead(i, existing.Boards, existing.BoardSize.Int32) {
-			existing.Gameover = pgtype.Bool{Bool: false, Valid: true}
-			break
+	err = runInTx(ctx, repo, "skip_move", func(qtx repository.Queries) error {
+		// STEP 1: Validate sessionId
+		existing, err := qtx.GetLatestSessionStateByPlayerIdWithLock(ctx)
+		if err != nil {
+			return err
+		}
+		if existing.SessionID != sessionID {
+			return ErrSessionNotFound
+		}
+		g, err := loadGame(existing)
+		if err != nil {
+			return err
+		}
+		// STEP 2: Validate gameover
+		if existing.Gameover.Valid && existing.Gameover.Bool {
+			return ErrGameOver
+		}
+		// STEP 3: Verify if game is over before skipping move
+		if g.IsOver() {
+			//TODO: Update session state in DB to reflect gameover
+			return ErrGameOver
 		}
-	}
-	if existing.Gameover.Valid && existing.Gameover.Bool {
-		//TODO: Update session state in DB to reflect gameover
-		return nil, nil, true, existing.Winner.Bool, 0, 0, errors.New("game is already over")
-	}
-
-	// STEP 4: Check wallet for sufficient coins
-	const skipMoveCost = 200
-	wallet, err := store.GetWalletByPlayerIdWithLock(ctx, qtx)
-	if err != nil {
-		return nil, nil, false, false, 0, 0, err
-	}
-	if wallet.Coins.Valid == false || wallet.Xp.Valid == false {
-		return nil, nil, false, false, 0, 0, errors.New("invalid wallet response from db")
-	}
-	if wallet.Coins.Int32 < skipMoveCost {
-		return nil, nil, false, false, 0, 0, errors.New("insufficient coins to skip move")
-	}
-
-	// STEP 5: Deduct coins
-	err = store.UpdateWalletReduceCoins(ctx, qtx, skipMoveCost)
-	if err != nil {
-		return nil, nil, existing.Gameover.Valid && existing.Gameover.Bool, existing.Winner.Valid && existing.Winner.Bool, 0, 0, err
-	}
-
-	// STEP 6: AI makes a move
-	aiMoveIndex := logic.GetAIMove(existing.Boards, existing.BoardSize.Int32, existing.NumberOfBoards.Int32, existing.Difficulty.Int32)
-	if aiMoveIndex == -1 {
-		// No valid moves for AI - this shouldn't happen if game is not over
-		return existing.Boards, existing.IsAiMove, false, false, 0, 0, errors.New("AI could not find a valid move")
-	}
 
-	existing.Boards = append(existing.Boards, aiMoveIndex)
-	existing.IsAiMove = append(existing.IsAiMove, true) // AI move (skip move only adds AI move)
-	// Check for gameover after AI move
-	existing.Gameover = pgtype.Bool{Bool: true, Valid: true}
-	for i := int32(0); i < existing.NumberOfBoards.Int32; i++ {
-		if !logic.IsBoardDead(i, existing.Boards, existing.BoardSize.Int32) {
-			existing.Gameover = pgtype.Bool{Bool: false, Valid: true}
-			break
+		// STEP 4: Check wallet for sufficient coins
+		const skipMoveCost = 200
+		wallet, err := qtx.GetWalletByPlayerIdWithLock(ctx)
+		if err != nil {
+			return err
 		}
-	}
-	if existing.Gameover.Valid && existing.Gameover.Bool {
-		existing.Winner = pgtype.Bool{Bool: true, Valid: true}
-	} else if existing.Gameover.Valid && !existing.Gameover.Bool {
-		existing.Winner = pgtype.Bool{Bool: false, Valid: false}
-	}
-	// Update session state after AI move
-	err = store.UpdateSessionState(ctx, qtx, sessionID, existing.Boards, existing.IsAiMove)
-	if err != nil {
-		return nil, nil, existing.Gameover.Valid && existing.Gameover.Bool, existing.Winner.Valid && existing.Winner.Bool, 0, 0, err
-	}
-	if existing.Gameover.Valid && !existing.Gameover.Bool {
-		if err := tx.Commit(ctx); err != nil {
-			return nil, nil, false, false, 0, 0, err
+		if wallet.Debt > 0 {
+			return ErrWalletInDebt
 		}
-		return existing.Boards,
-			existing.IsAiMove,
-			false,
-			false,
-			0,
-			0,
-			nil
-	}
-	// If gameover after AI move, update session
-	if existing.Gameover.Valid && existing.Gameover.Bool {
-		err = store.UpdateSessionAfterGameover(ctx, qtx, sessionID, existing.Winner)
+		if wallet.Coins < skipMoveCost {
+			return fmt.Errorf("%w to skip move", ErrInsufficientCoins)
+		}
+
+		// STEP 5: Deduct coins
+		err = qtx.UpdateWalletReduceCoins(ctx, skipMoveCost, db.WalletTransactionReasonSkipCost, sessionID)
 		if err != nil {
-			return nil, nil, existing.Gameover.Valid && existing.Gameover.Bool, existing.Winner.Valid && existing.Winner.Bool, 0, 0, err
+			return err
 		}
-		coinsReward, xpReward := logic.CalculateRewards(existing.NumberOfBoards.Int32, existing.BoardSize.Int32, existing.Difficulty.Int32, existing.Winner.Valid && existing.Winner.Bool)
-		err = store.UpdateWalletCoinsAndXpReward(ctx, qtx, coinsReward, xpReward)
+
+		// STEP 6: AI makes a move
+		registry, err := configs.StrategyRegistryFor(ctx, qtx, existing.StrategyVersion)
 		if err != nil {
-			return nil, nil, existing.Gameover.Valid && existing.Gameover.Bool, existing.Winner.Valid && existing.Winner.Bool, 0, 0, err
+			return err
 		}
-		if err := tx.Commit(ctx); err != nil {
-			return nil, nil, false, false, 0, 0, err
+		if _, err := g.ApplyAI(registry); err != nil {
+			return err
 		}
-		return existing.Boards, existing.IsAiMove, existing.Gameover.Valid && existing.Gameover.Bool, existing.Winner.Valid && existing.Winner.Bool, coinsReward, xpReward, nil
+
+		// STEP 7: Persist moves, and settle the game if it ended
+		gameOver, winner, coinsRewarded, xpRewarded, err = saveTurn(ctx, qtx, sessionID, g)
+		boards, isAiMove = g.Boards(), g.IsAiMove()
+		return err
+	})
+	if err != nil {
+		return nil, nil, false, false, 0, 0, err
 	}
-	return nil, nil, false, false, 0, 0, errors.New("invalid gameover state obtained from db")
+	return boards, isAiMove, gameOver, winner, coinsRewarded, xpRewarded, nil
 }
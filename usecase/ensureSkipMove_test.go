@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEnsureSkipMove(t *testing.T) {
+	repo, configs := newTestRepo(t)
+	ctx := signUp(t, repo, "player", 300)
+	sessionID := startSession(t, ctx, repo, configs, 2, 3, 1)
+	if _, _, _, _, _, _, err := EnsureMakeMove(ctx, repo, configs, sessionID, 0, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	boards, isAiMove, _, _, _, _, err := EnsureSkipMove(ctx, repo, configs, sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(boards) != 3 || !isAiMove[1] || !isAiMove[2] {
+		t.Fatalf("after a skip: boards %v, isAiMove %v, want two AI moves in a row", boards, isAiMove)
+	}
+	if w := wallet(t, ctx, repo); w.Coins != 100 {
+		t.Fatalf("wallet holds %d coins after a skip, want 100", w.Coins)
+	}
+
+	// 100 coins do not pay for another skip, and nothing changes.
+	_, _, _, _, _, _, err = EnsureSkipMove(ctx, repo, configs, sessionID)
+	if !errors.Is(err, ErrInsufficientCoins) {
+		t.Fatalf("skip without coins: error = %v, want ErrInsufficientCoins", err)
+	}
+	if len(session(t, repo, sessionID).Boards) != 3 || wallet(t, ctx, repo).Coins != 100 {
+		t.Fatal("failed skip changed the session or wallet")
+	}
+
+	if _, _, _, _, _, _, err := EnsureSkipMove(ctx, repo, configs, "not-"+sessionID); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("skip on another session: error = %v, want ErrSessionNotFound", err)
+	}
+}
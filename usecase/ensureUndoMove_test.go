@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestEnsureUndoMove(t *testing.T) {
+	repo, configs := newTestRepo(t)
+	ctx := signUp(t, repo, "player", 500)
+	sessionID := startSession(t, ctx, repo, configs, 2, 3, 1)
+
+	if _, _, err := EnsureUndoMove(ctx, repo, sessionID); !errors.Is(err, ErrNoMovesToUndo) {
+		t.Fatalf("undo with no moves: error = %v, want ErrNoMovesToUndo", err)
+	}
+	if w := wallet(t, ctx, repo); w.Coins != 500 {
+		t.Fatalf("failed undo charged the wallet: %d coins", w.Coins)
+	}
+
+	// A player move and the AI reply are taken back together.
+	if _, _, _, _, _, _, err := EnsureMakeMove(ctx, repo, configs, sessionID, 0, 4); err != nil {
+		t.Fatal(err)
+	}
+	boards, isAiMove, err := EnsureUndoMove(ctx, repo, sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(boards) != 0 || len(isAiMove) != 0 {
+		t.Fatalf("after undoing a turn: boards %v, isAiMove %v, want none", boards, isAiMove)
+	}
+
+	// After a skip only the AI's extra move is taken back.
+	if _, _, _, _, _, _, err := EnsureMakeMove(ctx, repo, configs, sessionID, 0, 4); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, _, _, _, err := EnsureSkipMove(ctx, repo, configs, sessionID); err != nil {
+		t.Fatal(err)
+	}
+	before := session(t, repo, sessionID)
+	boards, isAiMove, err = EnsureUndoMove(ctx, repo, sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(boards, before.Boards[:2]) || !slices.Equal(isAiMove, []bool{false, true}) {
+		t.Fatalf("after undoing a skip: boards %v, isAiMove %v, want %v", boards, isAiMove, before.Boards[:2])
+	}
+	if stored := session(t, repo, sessionID); !slices.Equal(stored.Boards, boards) {
+		t.Fatalf("stored boards %v, returned %v", stored.Boards, boards)
+	}
+
+	// Two undos and a skip cost 100 + 100 + 200.
+	if w := wallet(t, ctx, repo); w.Coins != 100 {
+		t.Fatalf("wallet holds %d coins, want 100", w.Coins)
+	}
+	if _, _, err := EnsureUndoMove(ctx, repo, sessionID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := EnsureUndoMove(ctx, repo, sessionID); !errors.Is(err, ErrInsufficientCoins) {
+		t.Fatalf("undo without coins: error = %v, want ErrInsufficientCoins", err)
+	}
+}
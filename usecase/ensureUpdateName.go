@@ -2,21 +2,17 @@ package usecase
 
 import (
 	"context"
-	"errors"
 
-	"github.com/jackc/pgx/v5/pgxpool"
-	db "github.com/rakshitg600/notakto-solo/db/generated"
 	"github.com/rakshitg600/notakto-solo/contextkey"
-	"github.com/rakshitg600/notakto-solo/store"
+	"github.com/rakshitg600/notakto-solo/repository"
 )
 
-func EnsureUpdateName(ctx context.Context, pool *pgxpool.Pool, name string) (string, error) {
+func EnsureUpdateName(ctx context.Context, repo repository.Repository, name string) (string, error) {
 	uid, ok := contextkey.UIDFromContext(ctx)
 	if !ok || uid == "" {
-		return "", errors.New("missing or invalid uid in context")
+		return "", ErrUnauthenticated
 	}
-	queries := db.New(pool)
-	player, err := store.UpdatePlayerName(ctx, queries, name)
+	player, err := repo.UpdatePlayerName(ctx, name)
 	if err != nil {
 		return "", err
 	}
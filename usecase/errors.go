@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/rakshitg600/notakto-solo/game"
+)
+
+// Error is a failure the client can act on. Code is stable across releases and
+// is what clients should match on; Message is for humans and may change.
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(code string, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+var (
+	ErrUnauthenticated       = newError("unauthenticated", "missing or invalid uid in context")
+	ErrSessionNotFound       = newError("session_not_found", "session expired or not found")
+	ErrSessionForbidden      = newError("session_forbidden", "session access denied")
+	ErrGameOver              = newError("game_over", "game is already over")
+	ErrGameNotOver           = newError("game_not_over", "game is not over yet")
+	ErrInvalidBoard          = newError("invalid_board", "invalid board index")
+	ErrInvalidCell           = newError("invalid_cell", "invalid cell index")
+	ErrBoardDead             = newError("board_dead", "selected board is already dead")
+	ErrCellOccupied          = newError("cell_occupied", "cell is already marked")
+	ErrNoMovesToUndo         = newError("no_moves_to_undo", "no moves to undo")
+	ErrSessionChanged        = newError("session_changed", "session changed while the hint was computed")
+	ErrInsufficientCoins     = newError("insufficient_coins", "insufficient coins")
+	ErrWalletInDebt          = newError("wallet_in_debt", "wallet owes coins for a refunded payment")
+	ErrPackageNotFound       = newError("package_not_found", "invalid package ID")
+	ErrPaymentNotFound       = newError("payment_not_found", "payment not found")
+	ErrPaymentForbidden      = newError("payment_forbidden", "payment access denied")
+	ErrPlayerNotFound        = newError("player_not_found", "player not found")
+	ErrInvalidAdjustment     = newError("invalid_adjustment", "invalid wallet adjustment")
+	ErrNegativeBalance       = newError("negative_balance", "adjustment would leave a negative balance")
+	ErrUnknownConfigKey      = newError("unknown_config_key", "unknown config key")
+	ErrInvalidConfig         = newError("invalid_config", "invalid config value")
+	ErrConfigVersionNotFound = newError("config_version_not_found", "config version not found")
+	ErrConfigConflict        = newError("config_conflict", "config was changed concurrently")
+	ErrInvalidHistoryType    = newError("invalid_history_type", "history type must be rewards, purchases or spends")
+	ErrInvalidCursor         = newError("invalid_cursor", "invalid pagination cursor")
+)
+
+// gameErrors maps rule violations reported by the game package to API errors.
+var gameErrors = map[error]*Error{
+	game.ErrGameOver:     ErrGameOver,
+	game.ErrInvalidBoard: ErrInvalidBoard,
+	game.ErrInvalidCell:  ErrInvalidCell,
+	game.ErrBoardDead:    ErrBoardDead,
+	game.ErrCellMarked:   ErrCellOccupied,
+	game.ErrNoMoves:      ErrNoMovesToUndo,
+}
+
+func fromGameError(err error) error {
+	for gameErr, apiErr := range gameErrors {
+		if errors.Is(err, gameErr) {
+			return apiErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,16 @@
+package usecase
+
+import (
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+	"github.com/rakshitg600/notakto-solo/game"
+)
+
+// loadGame builds the rules engine for a locked session row.
+func loadGame(existing db.GetLatestSessionStateByPlayerIdWithLockRow) (*game.Game, error) {
+	return game.New(game.Config{
+		NumberOfBoards: existing.NumberOfBoards.Int32,
+		BoardSize:      existing.BoardSize.Int32,
+		Difficulty:     existing.Difficulty.Int32,
+		Seed:           existing.Seed,
+	}, existing.Boards, existing.IsAiMove)
+}
@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+// Payment row statuses.
+const (
+	paymentCreated   = "created"
+	paymentPending   = "pending"
+	paymentConfirmed = "confirmed"
+	paymentFailed    = "failed"
+	paymentExpired   = "expired"
+	paymentRefunded  = "refunded"
+)
+
+// paymentTransitions lists, for each status, the statuses a payment may move
+// to it from. Until its coins are credited a payment follows whatever
+// NOWPayments last reported. A charge abandoned before any of that only
+// expires from created or pending, so a late IPN saying otherwise still
+// wins. Once credited a payment can only be refunded, and a refund is final.
+var paymentTransitions = map[string][]string{
+	paymentPending:   {paymentCreated, paymentPending, paymentFailed, paymentExpired},
+	paymentConfirmed: {paymentCreated, paymentPending, paymentFailed, paymentExpired},
+	paymentFailed:    {paymentCreated, paymentPending, paymentFailed, paymentExpired},
+	paymentExpired:   {paymentCreated, paymentPending, paymentExpired},
+	paymentRefunded:  {paymentConfirmed},
+}
+
+// transitionPayment moves payment id to status if paymentTransitions allows
+// it from the current status, and reports whether it did. A missing payment
+// is reported as not moved.
+func transitionPayment(ctx context.Context, q repository.Queries, id string, status string) (bool, error) {
+	rowsAffected, err := q.UpdatePaymentStatus(ctx, id, status, paymentTransitions[status])
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+	"github.com/rakshitg600/notakto-solo/nowpayments"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+const paymentPollTimeout = 30 * time.Second
+
+// pollMetrics counts polling runs, runs left to another instance, payments
+// checked, the provider statuses they were found in, payments expired and
+// payments that could not be checked, plus the size of the last run.
+// Published through expvar under "payment_polling".
+var pollMetrics = expvar.NewMap("payment_polling")
+
+// PaymentPollRun summarises one pass over the payments still waiting for
+// the provider.
+type PaymentPollRun struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Payments   int
+	Expired    int
+	Failed     int
+}
+
+// RunGate reports whether this instance should start a run of a background
+// job, by taking a lock shared by all instances that lapses after ttl.
+type RunGate func(ctx context.Context, ttl time.Duration) (bool, error)
+
+// PaymentPoller asks NOWPayments about payments whose IPNs never arrived and
+// applies what it reports as if the IPN had. A charge the player abandoned
+// is expired once it is expireAfter old. Every update is a guarded status
+// transition, so a run that overlaps another, or races the webhook, is safe;
+// the gate only keeps instances from asking NOWPayments the same questions.
+type PaymentPoller struct {
+	repo        repository.Repository
+	client      *nowpayments.Client
+	gate        RunGate
+	minAge      time.Duration
+	expireAfter time.Duration
+	batchSize   int32
+}
+
+// NewPaymentPoller returns a poller for created and pending payments at
+// least minAge old, which gives their IPNs time to arrive first. Each
+// periodic run must pass gate first; a nil gate lets every instance poll.
+func NewPaymentPoller(repo repository.Repository, client *nowpayments.Client, gate RunGate, minAge time.Duration, expireAfter time.Duration, batchSize int32) *PaymentPoller {
+	return &PaymentPoller{
+		repo:        repo,
+		client:      client,
+		gate:        gate,
+		minAge:      minAge,
+		expireAfter: expireAfter,
+		batchSize:   batchSize,
+	}
+}
+
+// Run polls every stale payment once per interval until ctx is done.
+func (p *PaymentPoller) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := p.pollIfLeader(ctx, interval); err != nil && ctx.Err() == nil {
+			log.Printf("payment polling failed: %v", err)
+		}
+	}
+}
+
+// pollIfLeader runs PollAll if the gate lets this instance, and reports
+// whether it did. The gate is held for most of interval and not released,
+// so across instances at most one run starts per interval, on whichever
+// instance ticks first.
+func (p *PaymentPoller) pollIfLeader(ctx context.Context, interval time.Duration) (bool, error) {
+	if p.gate != nil {
+		leader, err := p.gate(ctx, interval-interval/10)
+		if err != nil {
+			return false, fmt.Errorf("take the polling lock: %w", err)
+		}
+		if !leader {
+			pollMetrics.Add("runs_skipped", 1)
+			return false, nil
+		}
+	}
+	_, err := p.PollAll(ctx)
+	return true, err
+}
+
+// PollAll checks every created or pending payment older than minAge,
+// batchSize at a time. A payment that cannot be checked is logged and
+// counted as failed; listing the payments failing ends the run.
+func (p *PaymentPoller) PollAll(ctx context.Context) (PaymentPollRun, error) {
+	run := PaymentPollRun{StartedAt: time.Now()}
+	createdBefore := run.StartedAt.Add(-p.minAge)
+	afterID := ""
+	for {
+		payments, err := p.repo.ListStalePaymentsAfterId(ctx, createdBefore, afterID, p.batchSize)
+		if err != nil {
+			return run, err
+		}
+		for _, payment := range payments {
+			expired, err := p.poll(ctx, payment)
+			if err != nil {
+				if ctx.Err() != nil {
+					return run, ctx.Err()
+				}
+				log.Printf("payment polling of order %s failed: %v", payment.ID, err)
+				pollMetrics.Add("failed", 1)
+				run.Failed++
+				continue
+			}
+			run.Payments++
+			if expired {
+				run.Expired++
+			}
+		}
+		if int32(len(payments)) < p.batchSize {
+			break
+		}
+		afterID = payments[len(payments)-1].ID
+	}
+	run.FinishedAt = time.Now()
+
+	pollMetrics.Add("runs", 1)
+	setMetric(pollMetrics, "last_run.payments", run.Payments)
+	setMetric(pollMetrics, "last_run.expired", run.Expired)
+	setMetric(pollMetrics, "last_run.failed", run.Failed)
+	log.Printf("payment polling checked %d payments in %v: %d expired, %d failed",
+		run.Payments, run.FinishedAt.Sub(run.StartedAt), run.Expired, run.Failed)
+	return run, nil
+}
+
+// poll feeds what NOWPayments reports about payment through
+// EnsureProcessWebhook, then expires it if nothing has been paid and it is
+// expireAfter old. It reports whether it expired the payment.
+func (p *PaymentPoller) poll(ctx context.Context, payment db.Payment) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, paymentPollTimeout)
+	defer cancel()
+
+	// STEP 1: Ask the provider and apply its answer
+	status, err := p.providerStatus(ctx, payment)
+	if err != nil {
+		return false, err
+	}
+	pollMetrics.Add("payments_checked", 1)
+	if status != nil {
+		if status.OrderID != "" && status.OrderID != payment.ID {
+			return false, fmt.Errorf("provider payment %s belongs to order %s", status.PaymentID, status.OrderID)
+		}
+		pollMetrics.Add("provider_status."+status.PaymentStatus, 1)
+		err := EnsureProcessWebhook(ctx, p.repo, status.PaymentStatus, payment.ID, status.PaymentID.String())
+		if err != nil {
+			return false, err
+		}
+		if status.PaymentStatus != "waiting" {
+			return false, nil
+		}
+	}
+
+	// STEP 2: Expire a charge nobody has paid into
+	if time.Since(payment.CreatedAt) < p.expireAfter {
+		return false, nil
+	}
+	moved, err := transitionPayment(ctx, p.repo, payment.ID, paymentExpired)
+	if err != nil {
+		return false, fmt.Errorf("failed to update payment to expired: %w", err)
+	}
+	if moved {
+		pollMetrics.Add("expired", 1)
+		log.Printf("order %s expired: nothing paid %v after the charge was created", payment.ID, p.expireAfter)
+	}
+	return moved, nil
+}
+
+// providerStatus returns what NOWPayments reports for payment, or nil when
+// no payment has been made against its invoice or there is no way to ask.
+// Without a payment id from an IPN it lists the invoice's payments, which
+// needs the account credentials, and prefers a finished one.
+func (p *PaymentPoller) providerStatus(ctx context.Context, payment db.Payment) (*nowpayments.PaymentStatus, error) {
+	if payment.ProviderPaymentID.Valid {
+		return p.client.GetPaymentStatus(ctx, payment.ProviderPaymentID.String)
+	}
+	if !payment.InvoiceID.Valid || !p.client.CanListPayments() {
+		return nil, nil
+	}
+	statuses, err := p.client.ListInvoicePayments(ctx, payment.InvoiceID.String)
+	if err != nil || len(statuses) == 0 {
+		return nil, err
+	}
+	for i := range statuses {
+		if statuses[i].PaymentStatus == "finished" {
+			return &statuses[i], nil
+		}
+	}
+	return &statuses[0], nil
+}
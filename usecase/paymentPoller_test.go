@@ -0,0 +1,302 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rakshitg600/notakto-solo/nowpayments"
+)
+
+// fakeNOWPayments serves the payment lookups the poller makes. Listing an
+// invoice's payments needs a bearer token from /auth, as the real API does.
+type fakeNOWPayments struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	payments map[string]nowpayments.PaymentStatus // by payment id
+	invoices map[string][]string                  // invoice id to payment ids, newest first
+	token    string
+	auths    int
+	lookups  map[string]int // by payment id or invoice id
+}
+
+func newFakeNOWPayments(t *testing.T) *fakeNOWPayments {
+	t.Helper()
+	f := &fakeNOWPayments{
+		payments: make(map[string]nowpayments.PaymentStatus),
+		invoices: make(map[string][]string),
+		lookups:  make(map[string]int),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /auth", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.auths++
+		f.token = fmt.Sprintf("token-%d", f.auths)
+		json.NewEncoder(w).Encode(map[string]string{"token": f.token})
+	})
+	mux.HandleFunc("GET /payment/{id}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		id := r.PathValue("id")
+		f.lookups[id]++
+		status, ok := f.payments[id]
+		if !ok {
+			http.Error(w, `{"message":"Payment not found"}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(status)
+	})
+	mux.HandleFunc("GET /payment/{$}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.token == "" || r.Header.Get("Authorization") != "Bearer "+f.token {
+			http.Error(w, `{"message":"Invalid token"}`, http.StatusUnauthorized)
+			return
+		}
+		invoiceID := r.URL.Query().Get("invoiceId")
+		f.lookups[invoiceID]++
+		data := []nowpayments.PaymentStatus{}
+		for _, id := range f.invoices[invoiceID] {
+			data = append(data, f.payments[id])
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// pay records a payment made against orderID's invoice, as createPayment
+// names it.
+func (f *fakeNOWPayments) pay(orderID string, paymentID string, status string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.payments[paymentID] = nowpayments.PaymentStatus{
+		PaymentID:     json.Number(paymentID),
+		PaymentStatus: status,
+		OrderID:       orderID,
+	}
+	invoiceID := "inv-" + orderID
+	f.invoices[invoiceID] = append([]string{paymentID}, f.invoices[invoiceID]...)
+}
+
+func (f *fakeNOWPayments) lookupCount(id string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lookups[id]
+}
+
+func (f *fakeNOWPayments) authCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.auths
+}
+
+// newTestClient returns a client for f, with account credentials when
+// canList is set.
+func newTestClient(f *fakeNOWPayments, canList bool) *nowpayments.Client {
+	client := nowpayments.NewClient("test-api-key", f.URL)
+	if canList {
+		client.SetCredentials("owner@example.com", "secret")
+	}
+	return client
+}
+
+func TestPaymentPollerAppliesProviderStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		ipn         string // status of an IPN that named payment 101 first, if any
+		payments    [][2]string
+		canList     bool
+		expireAfter time.Duration
+		wantStatus  string
+		wantCoins   int64
+		wantExpired bool
+	}{
+		{"finished by id", "waiting", [][2]string{{"101", "finished"}}, false, time.Hour, paymentConfirmed, 500, false},
+		{"still waiting", "waiting", [][2]string{{"101", "waiting"}}, false, time.Hour, paymentPending, 0, false},
+		{"failed by id", "waiting", [][2]string{{"101", "failed"}}, false, time.Hour, paymentFailed, 0, false},
+		{"finished found on the invoice", "", [][2]string{{"101", "finished"}, {"102", "waiting"}}, true, time.Hour, paymentConfirmed, 500, false},
+		{"refunded before it was credited", "", [][2]string{{"101", "refunded"}}, true, time.Hour, paymentFailed, 0, false},
+		{"no payment yet", "", nil, true, time.Hour, paymentCreated, 0, false},
+		{"abandoned", "", nil, true, time.Nanosecond, paymentExpired, 0, true},
+		{"abandoned while waiting", "waiting", [][2]string{{"101", "waiting"}}, false, time.Nanosecond, paymentExpired, 0, true},
+		{"partially paid, not expired", "waiting", [][2]string{{"101", "partially_paid"}}, false, time.Nanosecond, paymentFailed, 0, false},
+		{"unknown to an unlisted client", "", [][2]string{{"101", "finished"}}, false, time.Hour, paymentCreated, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newTestRepo(t)
+			ctx := signUp(t, repo, "player", 0)
+			createPayment(t, repo, "order-1", "player", 500)
+			np := newFakeNOWPayments(t)
+			// Registered oldest first, so the first is listed last.
+			for i := len(tt.payments) - 1; i >= 0; i-- {
+				np.pay("order-1", tt.payments[i][0], tt.payments[i][1])
+			}
+			if tt.ipn != "" {
+				if err := EnsureProcessWebhook(context.Background(), repo, tt.ipn, "order-1", "101"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			poller := NewPaymentPoller(repo, newTestClient(np, tt.canList), nil, 0, tt.expireAfter, 10)
+			run, err := poller.PollAll(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if run.Payments != 1 || run.Failed != 0 || (run.Expired == 1) != tt.wantExpired {
+				t.Fatalf("run = %+v, want 1 payment checked, expired %v", run, tt.wantExpired)
+			}
+			if p := payment(t, repo, "order-1"); p.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", p.Status, tt.wantStatus)
+			}
+			if w := wallet(t, ctx, repo); w.Coins != tt.wantCoins {
+				t.Fatalf("wallet holds %d coins, want %d", w.Coins, tt.wantCoins)
+			}
+		})
+	}
+}
+
+func TestPaymentPollerLateIPN(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := signUp(t, repo, "player", 0)
+	createPayment(t, repo, "order-1", "player", 500)
+	np := newFakeNOWPayments(t)
+	poller := NewPaymentPoller(repo, newTestClient(np, true), nil, 0, time.Nanosecond, 10)
+	if _, err := poller.PollAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if p := payment(t, repo, "order-1"); p.Status != paymentExpired {
+		t.Fatalf("status = %s, want expired", p.Status)
+	}
+
+	// The player paid after all: the IPN still credits them, and the next
+	// run leaves the settled payment alone.
+	np.pay("order-1", "101", "finished")
+	if err := EnsureProcessWebhook(context.Background(), repo, "finished", "order-1", "101"); err != nil {
+		t.Fatal(err)
+	}
+	run, err := poller.PollAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Payments != 0 || np.lookupCount("101") != 0 {
+		t.Fatalf("run = %+v with %d lookups of the settled payment, want none", run, np.lookupCount("101"))
+	}
+	if p := payment(t, repo, "order-1"); p.Status != paymentConfirmed {
+		t.Fatalf("status = %s, want confirmed", p.Status)
+	}
+	if w := wallet(t, ctx, repo); w.Coins != 500 {
+		t.Fatalf("wallet holds %d coins, want 500", w.Coins)
+	}
+}
+
+func TestPaymentPollerPagesAndReusesToken(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := signUp(t, repo, "player", 0)
+	np := newFakeNOWPayments(t)
+	orders := []string{"order-1", "order-2", "order-3", "order-4", "order-5"}
+	for i, id := range orders {
+		createPayment(t, repo, id, "player", 100)
+		np.pay(id, fmt.Sprint(101+i), "finished")
+	}
+	// Confirmed payments are not listed, so order-6 is never checked.
+	createPayment(t, repo, "order-6", "player", 100)
+	if err := EnsureProcessWebhook(context.Background(), repo, "finished", "order-6", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	poller := NewPaymentPoller(repo, newTestClient(np, true), nil, 0, time.Hour, 2)
+	run, err := poller.PollAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Payments != len(orders) || run.Failed != 0 {
+		t.Fatalf("run = %+v, want %d payments checked", run, len(orders))
+	}
+	for _, id := range orders {
+		if n := np.lookupCount("inv-" + id); n != 1 {
+			t.Errorf("invoice of %s listed %d times, want once", id, n)
+		}
+		if p := payment(t, repo, id); p.Status != paymentConfirmed {
+			t.Errorf("%s status = %s, want confirmed", id, p.Status)
+		}
+	}
+	if n := np.lookupCount("inv-order-6"); n != 0 {
+		t.Errorf("confirmed order-6 listed %d times", n)
+	}
+	if w := wallet(t, ctx, repo); w.Coins != 600 {
+		t.Errorf("wallet holds %d coins, want 600", w.Coins)
+	}
+	if n := np.authCount(); n != 1 {
+		t.Errorf("%d /auth calls for one run, want the token reused", n)
+	}
+}
+
+func TestPaymentPollerSkipsOtherOrdersPayment(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	signUp(t, repo, "player", 0)
+	createPayment(t, repo, "order-1", "player", 500)
+	np := newFakeNOWPayments(t)
+	np.pay("order-2", "101", "finished")
+	if err := EnsureProcessWebhook(context.Background(), repo, "waiting", "order-1", "101"); err != nil {
+		t.Fatal(err)
+	}
+	run, err := NewPaymentPoller(repo, newTestClient(np, false), nil, 0, time.Hour, 10).PollAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Failed != 1 || run.Payments != 0 {
+		t.Fatalf("run = %+v, want the payment counted as failed", run)
+	}
+	if p := payment(t, repo, "order-1"); p.Status != paymentPending {
+		t.Fatalf("status = %s, want pending", p.Status)
+	}
+}
+
+func TestPaymentPollerGate(t *testing.T) {
+	gateErr := errors.New("valkey down")
+	tests := []struct {
+		name       string
+		leader     bool
+		err        error
+		wantPolled bool
+	}{
+		{"leader", true, nil, true},
+		{"another instance holds the lock", false, nil, false},
+		{"lock unavailable", false, gateErr, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newTestRepo(t)
+			signUp(t, repo, "player", 0)
+			createPayment(t, repo, "order-1", "player", 500)
+			np := newFakeNOWPayments(t)
+			var gotTTL time.Duration
+			gate := func(ctx context.Context, ttl time.Duration) (bool, error) {
+				gotTTL = ttl
+				return tt.leader, tt.err
+			}
+			poller := NewPaymentPoller(repo, newTestClient(np, true), gate, 0, time.Hour, 10)
+			polled, err := poller.pollIfLeader(context.Background(), time.Minute)
+			if !errors.Is(err, tt.err) || polled != tt.wantPolled {
+				t.Fatalf("pollIfLeader = %v, %v, want %v, %v", polled, err, tt.wantPolled, tt.err)
+			}
+			if gotTTL <= 0 || gotTTL >= time.Minute {
+				t.Fatalf("lock held for %v, want less than the interval", gotTTL)
+			}
+			if listed := np.lookupCount("inv-order-1") == 1; listed != tt.wantPolled {
+				t.Fatalf("payment checked: %v, want %v", listed, tt.wantPolled)
+			}
+		})
+	}
+}
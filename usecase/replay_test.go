@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rakshitg600/notakto-solo/config"
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/game"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+// easyStrategyValue is the default mix as version, with strategy as the whole
+// of difficulty 1.
+func easyStrategyValue(t *testing.T, version string, strategy string) []byte {
+	t.Helper()
+	strategies := config.DefaultAIStrategyConfig()
+	strategies.Version = version
+	strategies.Levels = append([]config.AIDifficultyLevel{
+		{Difficulty: 1, Strategies: []config.AIStrategyWeight{{Strategy: strategy, Weight: 1}}},
+	}, strategies.Levels[1:]...)
+	value, err := json.Marshal(strategies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+// setEasyStrategy makes strategy the whole mix for difficulty 1 as version,
+// through the admin config usecase, and reloads configs.
+func setEasyStrategy(t *testing.T, repo repository.Repository, configs *ConfigCache, version string, strategy string) {
+	t.Helper()
+	admin := context.WithValue(context.Background(), contextkey.UID, "admin")
+	if _, err := EnsureAdminUpsertConfig(admin, repo, config.AIStrategiesKey, easyStrategyValue(t, version, strategy)); err != nil {
+		t.Fatal(err)
+	}
+	if err := configs.reload(context.Background(), config.AIStrategiesKey); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplaySessionFromSeedAndVersion(t *testing.T) {
+	repo, configs := newTestRepo(t)
+	setEasyStrategy(t, repo, configs, "replay-v1", "random")
+	ctx := signUp(t, repo, "player", 0)
+	sessionID := startSession(t, ctx, repo, configs, 3, 3, 1)
+
+	for turn := 0; ; turn++ {
+		row := session(t, repo, sessionID)
+		if row.Gameover.Bool {
+			break
+		}
+		if turn == 1 {
+			// The live mix changes mid-game; the session keeps its own.
+			setEasyStrategy(t, repo, configs, "replay-v2", "center")
+		}
+		boardIndex, cellIndex := firstLegalMove(t, row)
+		if _, _, _, _, _, _, err := EnsureMakeMove(ctx, repo, configs, sessionID, boardIndex, cellIndex); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recorded := session(t, repo, sessionID)
+	if recorded.StrategyVersion.String != "replay-v1" {
+		t.Fatalf("session recorded strategy version %q, want replay-v1", recorded.StrategyVersion.String)
+	}
+
+	// Replay on a fresh cache, as another instance would, so the recorded
+	// version has to come from the config history.
+	fresh, err := NewConfigCache(context.Background(), repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry, err := fresh.StrategyRegistryFor(context.Background(), repo, recorded.StrategyVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registry.Version() != "replay-v1" {
+		t.Fatalf("resolved strategy version %q, want replay-v1", registry.Version())
+	}
+	replay, err := game.New(game.Config{
+		NumberOfBoards: recorded.NumberOfBoards.Int32,
+		BoardSize:      recorded.BoardSize.Int32,
+		Difficulty:     recorded.Difficulty.Int32,
+		Seed:           recorded.Seed,
+	}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells := recorded.BoardSize.Int32 * recorded.BoardSize.Int32
+	for ply, move := range recorded.Boards {
+		if !recorded.IsAiMove[ply] {
+			if err := replay.Apply(move/cells, move%cells); err != nil {
+				t.Fatalf("ply %d: player move %d: %v", ply, move, err)
+			}
+			continue
+		}
+		got, err := replay.ApplyAI(registry)
+		if err != nil {
+			t.Fatalf("ply %d: %v", ply, err)
+		}
+		if got != move {
+			t.Fatalf("ply %d: replayed AI move %d, recorded %d", ply, got, move)
+		}
+	}
+	if !replay.IsOver() || replay.Winner() != recorded.Winner.Bool {
+		t.Fatalf("replay over=%v winner=%v, recorded winner=%v", replay.IsOver(), replay.Winner(), recorded.Winner.Bool)
+	}
+}
+
+func TestStrategyRegistryFor(t *testing.T) {
+	repo, configs := newTestRepo(t)
+	setEasyStrategy(t, repo, configs, "live-v1", "center")
+	tests := []struct {
+		name    string
+		version pgtype.Text
+		want    string
+	}{
+		{"unset", pgtype.Text{}, "live-v1"},
+		{"live", pgtype.Text{String: "live-v1", Valid: true}, "live-v1"},
+		{"built-in default", pgtype.Text{String: config.DefaultAIStrategyConfig().Version, Valid: true}, config.DefaultAIStrategyConfig().Version},
+		{"never recorded", pgtype.Text{String: "missing-v9", Valid: true}, "live-v1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, err := configs.StrategyRegistryFor(context.Background(), repo, tt.version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if registry.Version() != tt.want {
+				t.Errorf("StrategyRegistryFor(%q) = %q, want %q", tt.version.String, registry.Version(), tt.want)
+			}
+		})
+	}
+}
+
+func TestUpsertAIStrategiesVersionReuse(t *testing.T) {
+	repo, configs := newTestRepo(t)
+	setEasyStrategy(t, repo, configs, "v1", "random")
+	setEasyStrategy(t, repo, configs, "v2", "center")
+	admin := context.WithValue(context.Background(), contextkey.UID, "admin")
+	defaultVersion := config.DefaultAIStrategyConfig().Version
+
+	tests := []struct {
+		name    string
+		value   []byte
+		wantErr error
+	}{
+		{"earlier version, different levels", easyStrategyValue(t, "v1", "center"), ErrInvalidConfig},
+		{"live version, different levels", easyStrategyValue(t, "v2", "random"), ErrInvalidConfig},
+		{"built-in version, different levels", easyStrategyValue(t, defaultVersion, "center"), ErrInvalidConfig},
+		{"earlier version, same levels", easyStrategyValue(t, "v1", "random"), nil},
+		{"built-in version, same levels", easyStrategyValue(t, defaultVersion, "random"), nil},
+		{"new version", easyStrategyValue(t, "v3", "center"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := EnsureAdminValidateConfig(admin, repo, config.AIStrategiesKey, tt.value); !errors.Is(err, tt.wantErr) {
+				t.Errorf("EnsureAdminValidateConfig error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := EnsureAdminUpsertConfig(admin, repo, config.AIStrategiesKey, tt.value); !errors.Is(err, tt.wantErr) {
+				t.Errorf("EnsureAdminUpsertConfig error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+	"github.com/rakshitg600/notakto-solo/game"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+// saveTurn writes the game's move log and, once the game is over, closes the
+// session and pays out rewards.
+func saveTurn(ctx context.Context, qtx repository.Queries, sessionID string, g *game.Game) (
+	gameOver bool,
+	winner bool,
+	coinsRewarded int64,
+	xpRewarded int64,
+	err error,
+) {
+	if err := qtx.UpdateSessionState(ctx, sessionID, g.Boards(), g.IsAiMove()); err != nil {
+		return false, false, 0, 0, err
+	}
+	if !g.IsOver() {
+		return false, false, 0, 0, nil
+	}
+	winner = g.Winner()
+	if err := qtx.UpdateSessionAfterGameover(ctx, sessionID, pgtype.Bool{Bool: winner, Valid: true}); err != nil {
+		return false, false, 0, 0, err
+	}
+	coinsRewarded, xpRewarded, err = g.Rewards()
+	if err != nil {
+		return false, false, 0, 0, err
+	}
+	if winner {
+		err = qtx.UpdateWalletCoinsAndXpReward(ctx, coinsRewarded, xpRewarded, db.WalletTransactionReasonGameReward, sessionID)
+	} else {
+		err = qtx.UpdateWalletXpReward(ctx, xpRewarded, db.WalletTransactionReasonGameReward, sessionID)
+	}
+	if err != nil {
+		return false, false, 0, 0, err
+	}
+	return true, winner, coinsRewarded, xpRewarded, nil
+}
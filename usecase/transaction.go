@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+const (
+	txMaxAttempts = 5
+	txBaseBackoff = 10 * time.Millisecond
+	txMaxBackoff  = 250 * time.Millisecond
+)
+
+// txMetrics counts, per transaction name, how many runs committed, how many
+// attempts were retried and how many runs gave up while still conflicting.
+// Published through expvar under "transactions".
+var txMetrics = expvar.NewMap("transactions")
+
+// runInTx runs fn in a serializable read-write transaction via repo.InTx.
+// The whole closure is retried with jittered exponential backoff when
+// Postgres aborts it with a serialization failure (40001) or deadlock
+// (40P01), as long as the request deadline leaves room for another attempt.
+// fn must therefore be safe to re-run: read everything it needs through qtx
+// and assign its results only to variables it overwrites on every attempt.
+func runInTx(ctx context.Context, repo repository.Repository, name string, fn func(qtx repository.Queries) error) error {
+	for attempt := 1; ; attempt++ {
+		err := repo.InTx(ctx, fn)
+		if err == nil {
+			txMetrics.Add(name+".committed", 1)
+			return nil
+		}
+		if !isSerializationFailure(err) {
+			return err
+		}
+		if attempt == txMaxAttempts {
+			txMetrics.Add(name+".exhausted", 1)
+			return err
+		}
+
+		delay := txBackoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			txMetrics.Add(name+".exhausted", 1)
+			return err
+		}
+		txMetrics.Add(name+".retries", 1)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == "40001" || pgErr.Code == "40P01"
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// txBackoff returns a full-jitter delay for the given attempt.
+func txBackoff(attempt int) time.Duration {
+	ceiling := txBaseBackoff << (attempt - 1)
+	if ceiling > txMaxBackoff {
+		ceiling = txMaxBackoff
+	}
+	return time.Duration(rand.Int64N(int64(ceiling)) + 1)
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+// conflictingRepo fails the first conflicts transactions with err after fn
+// has run, as Postgres does when a serializable commit conflicts.
+type conflictingRepo struct {
+	*repository.Memory
+	conflicts int
+	err       error
+	attempts  int
+}
+
+func (r *conflictingRepo) InTx(ctx context.Context, fn func(qtx repository.Queries) error) error {
+	r.attempts++
+	return r.Memory.InTx(ctx, func(qtx repository.Queries) error {
+		if err := fn(qtx); err != nil {
+			return err
+		}
+		if r.attempts <= r.conflicts {
+			return r.err
+		}
+		return nil
+	})
+}
+
+func TestRunInTx(t *testing.T) {
+	serialization := &pgconn.PgError{Code: "40001"}
+	deadlock := &pgconn.PgError{Code: "40P01"}
+	unique := &pgconn.PgError{Code: "23505"}
+	tests := []struct {
+		name         string
+		conflicts    int
+		err          error
+		wantErr      error
+		wantAttempts int
+		wantCoins    int64
+	}{
+		{"commits first time", 0, nil, nil, 1, 900},
+		{"retries serialization failures", 2, serialization, nil, 3, 900},
+		{"retries deadlocks", 1, deadlock, nil, 2, 900},
+		{"gives up after the last attempt", txMaxAttempts, serialization, serialization, txMaxAttempts, 1000},
+		{"does not retry other errors", 1, unique, unique, 1, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memory := repository.NewMemory()
+			ctx := signUp(t, memory, "player", 1000)
+			repo := &conflictingRepo{Memory: memory, conflicts: tt.conflicts, err: tt.err}
+			runs := 0
+			err := runInTx(ctx, repo, "test", func(qtx repository.Queries) error {
+				runs++
+				return qtx.UpdateWalletReduceCoins(ctx, 100, db.WalletTransactionReasonSkipCost, "session")
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("runInTx error = %v, want %v", err, tt.wantErr)
+			}
+			if repo.attempts != tt.wantAttempts || runs != tt.wantAttempts {
+				t.Fatalf("%d attempts, fn ran %d times, want %d", repo.attempts, runs, tt.wantAttempts)
+			}
+			// Rolled-back attempts leave no trace.
+			if w := wallet(t, ctx, memory); w.Coins != tt.wantCoins {
+				t.Fatalf("wallet holds %d coins, want %d", w.Coins, tt.wantCoins)
+			}
+		})
+	}
+}
+
+func TestRunInTxCancelledContext(t *testing.T) {
+	memory := repository.NewMemory()
+	repo := &conflictingRepo{Memory: memory, conflicts: txMaxAttempts, err: &pgconn.PgError{Code: "40001"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := runInTx(ctx, repo, "test", func(qtx repository.Queries) error { return nil }); err == nil {
+		t.Fatal("runInTx succeeded on a cancelled context")
+	}
+	if repo.attempts != 1 {
+		t.Fatalf("%d attempts on a cancelled context, want 1", repo.attempts)
+	}
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rakshitg600/notakto-solo/config"
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+	"github.com/rakshitg600/notakto-solo/game"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+// newTestRepo returns an empty Memory repository and a ConfigCache over it.
+func newTestRepo(t *testing.T) (*repository.Memory, *ConfigCache) {
+	t.Helper()
+	repo := repository.NewMemory()
+	configs, err := NewConfigCache(context.Background(), repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo, configs
+}
+
+// signUp creates the player uid with a wallet holding coins and returns a
+// context authenticated as them.
+func signUp(t *testing.T, repo *repository.Memory, uid string, coins int32) context.Context {
+	t.Helper()
+	ctx := context.WithValue(context.Background(), contextkey.UID, uid)
+	if err := repo.CreatePlayer(ctx, uid, uid+"@example.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateWallet(ctx, config.SignUpConfig{InitialCoins: coins}); err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
+
+// startSession starts a game for the player in ctx and returns its id.
+func startSession(t *testing.T, ctx context.Context, repo repository.Repository, configs *ConfigCache, numberOfBoards int32, boardSize int32, difficulty int32) string {
+	t.Helper()
+	sessionID, _, _, _, _, _, _, _, _, _, err := EnsureSession(ctx, repo, configs, numberOfBoards, boardSize, difficulty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sessionID
+}
+
+// session returns the stored row for sessionID.
+func session(t *testing.T, repo repository.Repository, sessionID string) db.GetSessionStateBySessionIdRow {
+	t.Helper()
+	row, err := repo.GetSessionStateBySessionId(context.Background(), sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return row
+}
+
+// firstLegalMove returns the lowest free cell on a live board of the session.
+func firstLegalMove(t *testing.T, row db.GetSessionStateBySessionIdRow) (boardIndex int32, cellIndex int32) {
+	t.Helper()
+	g := sessionGame(t, row)
+	cells := row.BoardSize.Int32 * row.BoardSize.Int32
+	for b := range row.NumberOfBoards.Int32 {
+		for c := range cells {
+			if g.Legal(b, c) == nil {
+				return b, c
+			}
+		}
+	}
+	t.Fatalf("session %s has no legal move", row.SessionID)
+	return 0, 0
+}
+
+// sessionGame rebuilds the rules engine for a stored session.
+func sessionGame(t *testing.T, row db.GetSessionStateBySessionIdRow) *game.Game {
+	t.Helper()
+	g, err := loadGame(db.GetLatestSessionStateByPlayerIdWithLockRow(row))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// wallet returns the wallet of the player in ctx.
+func wallet(t *testing.T, ctx context.Context, repo repository.Repository) db.Wallet {
+	t.Helper()
+	w, err := repo.GetWalletByPlayerId(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// createPayment records a created charge for coins by the player uid.
+func createPayment(t *testing.T, repo repository.Repository, id string, uid string, coins int32) {
+	t.Helper()
+	if err := repo.CreatePayment(context.Background(), id, uid, "pkg", coins, 499, paymentCreated, "https://pay.example/"+id, "inv-"+id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// payment returns the stored payment id.
+func payment(t *testing.T, repo repository.Repository, id string) db.Payment {
+	t.Helper()
+	p, err := repo.GetPaymentById(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
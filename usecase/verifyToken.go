@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/identity"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+// VerifyToken returns the verified token behind idToken and the player's
+// role: the one cached with the token when the provider resolved it,
+// otherwise resolved with ResolveRole.
+func VerifyToken(ctx context.Context, provider identity.Provider, roles repository.RoleRepository, idToken string) (*identity.Token, identity.Role, error) {
+	token, err := provider.VerifyToken(ctx, idToken)
+	if err != nil {
+		return nil, "", err
+	}
+	if token.Role != "" {
+		return token, token.Role, nil
+	}
+	role, err := ResolveRole(ctx, roles, token)
+	if err != nil {
+		return nil, "", err
+	}
+	return token, role, nil
+}
+
+// ResolveRole returns the role of the player behind token. It comes from the
+// token's "role" custom claim when it names a known role, otherwise from the
+// PlayerRole table, and defaults to player. identity.CachedProvider calls it
+// once per token through CacheConfig.ResolveRole.
+func ResolveRole(ctx context.Context, roles repository.RoleRepository, token *identity.Token) (identity.Role, error) {
+	if role, ok := token.ClaimedRole(); ok {
+		return role, nil
+	}
+	granted, err := roles.GetPlayerRole(context.WithValue(ctx, contextkey.UID, token.UID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return identity.RolePlayer, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	role, ok := identity.ParseRole(granted)
+	if !ok {
+		return identity.RolePlayer, nil
+	}
+	return role, nil
+}
+
+// GetUserProfile returns the profile carried by the verified token in ctx,
+// and asks the identity provider only when the token has none.
+func GetUserProfile(ctx context.Context, provider identity.Provider) (name string, email string, photo string, err error) {
+	uid, ok := contextkey.UIDFromContext(ctx)
+	if !ok || uid == "" {
+		return "", "", "", ErrUnauthenticated
+	}
+	if token, ok := identity.TokenFromContext(ctx); ok && token.UID == uid && token.Profile != nil {
+		return token.Profile.Name, token.Profile.Email, token.Profile.PhotoURL, nil
+	}
+	profile, err := provider.GetProfile(ctx, uid)
+	if err != nil {
+		return "", "", "", err
+	}
+	return profile.Name, profile.Email, profile.PhotoURL, nil
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rakshitg600/notakto-solo/identity"
+)
+
+// staticProvider verifies every token as token.
+type staticProvider struct {
+	token *identity.Token
+}
+
+func (p staticProvider) VerifyToken(ctx context.Context, idToken string) (*identity.Token, error) {
+	return p.token, nil
+}
+
+func (p staticProvider) GetProfile(ctx context.Context, uid string) (identity.Profile, error) {
+	return identity.Profile{}, identity.ErrUserNotFound
+}
+
+// unavailableRoles fails every lookup, so a test passes only when the
+// PlayerRole table is not consulted.
+type unavailableRoles struct{}
+
+func (unavailableRoles) GetPlayerRole(ctx context.Context) (string, error) {
+	return "", errors.New("roles table queried")
+}
+
+func TestVerifyTokenRole(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	repo.SetPlayerRole("support-uid", "support")
+	repo.SetPlayerRole("legacy-uid", "moderator")
+	expires := time.Now().Add(time.Hour)
+	tests := []struct {
+		name  string
+		token identity.Token
+		want  identity.Role
+	}{
+		{"cached role", identity.Token{UID: "support-uid", Role: identity.RoleAdmin}, identity.RoleAdmin},
+		{"role claim", identity.Token{UID: "support-uid", Claims: map[string]interface{}{"role": "admin"}}, identity.RoleAdmin},
+		{"PlayerRole row", identity.Token{UID: "support-uid"}, identity.RoleSupport},
+		{"unknown PlayerRole", identity.Token{UID: "legacy-uid"}, identity.RolePlayer},
+		{"no PlayerRole row", identity.Token{UID: "someone"}, identity.RolePlayer},
+		{"unknown role claim", identity.Token{UID: "someone", Claims: map[string]interface{}{"role": "root"}}, identity.RolePlayer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.token.Expires = expires
+			token, role, err := VerifyToken(context.Background(), staticProvider{&tt.token}, repo, "raw")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token.UID != tt.token.UID || role != tt.want {
+				t.Fatalf("VerifyToken = %s, %s, want %s, %s", token.UID, role, tt.token.UID, tt.want)
+			}
+		})
+	}
+
+	// Neither a cached role nor a role claim needs the table.
+	for _, token := range []identity.Token{
+		{UID: "u1", Role: identity.RoleSupport},
+		{UID: "u1", Claims: map[string]interface{}{"role": "support"}},
+	} {
+		if _, role, err := VerifyToken(context.Background(), staticProvider{&token}, unavailableRoles{}, "raw"); err != nil || role != identity.RoleSupport {
+			t.Fatalf("VerifyToken(%+v) = %s, %v, want support without a table lookup", token, role, err)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rakshitg600/notakto-solo/contextkey"
+	"github.com/rakshitg600/notakto-solo/game"
+	"github.com/rakshitg600/notakto-solo/repository"
+)
+
+const walletReconcileTimeout = 30 * time.Second
+
+// Checks a WalletMismatch can report.
+const (
+	// CheckLedgerBalance: wallet columns (actual, coins net of debt) against
+	// the sum of the player's ledger (expected).
+	CheckLedgerBalance = "ledger_balance"
+	// CheckPurchases: purchase ledger rows (actual) against payments that
+	// were confirmed, including ones refunded since (expected).
+	CheckPurchases = "purchases"
+	// CheckSessionReward: game_reward ledger rows of one session (actual)
+	// against a replay of its move log (expected).
+	CheckSessionReward = "session_reward"
+)
+
+// reconcileMetrics counts reconciliation runs, runs left to another
+// instance, wallets checked, mismatches per check and wallets that could not
+// be checked, plus the size of the last full run. Published through expvar under "wallet_reconciliation".
+var reconcileMetrics = expvar.NewMap("wallet_reconciliation")
+
+// WalletMismatch is one check that failed for a wallet. Reference is the
+// session id for CheckSessionReward and empty otherwise.
+type WalletMismatch struct {
+	Uid           string
+	Check         string
+	Reference     string
+	ExpectedCoins int64
+	ActualCoins   int64
+	ExpectedXP    int64
+	ActualXP      int64
+	DetectedAt    time.Time
+}
+
+// WalletReconcileRun summarises one pass over the wallet table.
+type WalletReconcileRun struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Wallets    int
+	Mismatched int
+	Failed     int
+}
+
+// WalletReconciler recomputes wallets from the ledger, payments and session
+// rewards and stores the mismatches found by the last full run, updated by
+// any single-wallet checks since, where every instance reads them. Every
+// check is read-only; the gate only keeps instances from repeating each
+// other's runs.
+type WalletReconciler struct {
+	repo      repository.Repository
+	gate      RunGate
+	batchSize int32
+}
+
+// NewWalletReconciler returns a reconciler that checks batchSize wallets at
+// a time. Each periodic run must pass gate first; a nil gate lets every
+// instance run.
+func NewWalletReconciler(repo repository.Repository, gate RunGate, batchSize int32) *WalletReconciler {
+	return &WalletReconciler{
+		repo:      repo,
+		gate:      gate,
+		batchSize: batchSize,
+	}
+}
+
+// Run reconciles every wallet once per interval until ctx is done.
+func (r *WalletReconciler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := r.reconcileIfLeader(ctx, interval); err != nil && ctx.Err() == nil {
+			log.Printf("wallet reconciliation failed: %v", err)
+		}
+	}
+}
+
+// reconcileIfLeader runs ReconcileAll if the gate lets this instance, and
+// reports whether it did. Like the payment poller's, the gate is held for
+// most of interval and not released.
+func (r *WalletReconciler) reconcileIfLeader(ctx context.Context, interval time.Duration) (bool, error) {
+	if r.gate != nil {
+		leader, err := r.gate(ctx, interval-interval/10)
+		if err != nil {
+			return false, fmt.Errorf("take the reconciliation lock: %w", err)
+		}
+		if !leader {
+			reconcileMetrics.Add("runs_skipped", 1)
+			return false, nil
+		}
+	}
+	_, err := r.ReconcileAll(ctx)
+	return true, err
+}
+
+// ReconcileAll checks every wallet, batchSize uids at a time, and replaces
+// the stored mismatches with what it found, recording the run alongside. A wallet that cannot be checked
+// is logged and counted as failed; listing the wallets failing ends the run.
+func (r *WalletReconciler) ReconcileAll(ctx context.Context) (WalletReconcileRun, error) {
+	run := WalletReconcileRun{StartedAt: time.Now()}
+	found := []WalletMismatch{}
+	afterUID := ""
+	for {
+		wallets, err := r.repo.ListWalletsAfterUid(ctx, afterUID, r.batchSize)
+		if err != nil {
+			return run, err
+		}
+		for _, wallet := range wallets {
+			mismatches, err := r.check(ctx, wallet.Uid)
+			if err != nil {
+				if ctx.Err() != nil {
+					return run, ctx.Err()
+				}
+				log.Printf("wallet reconciliation of %s failed: %v", wallet.Uid, err)
+				reconcileMetrics.Add("failed", 1)
+				run.Failed++
+				continue
+			}
+			run.Wallets++
+			if len(mismatches) > 0 {
+				found = append(found, mismatches...)
+				run.Mismatched++
+			}
+		}
+		if int32(len(wallets)) < r.batchSize {
+			break
+		}
+		afterUID = wallets[len(wallets)-1].Uid
+	}
+	run.FinishedAt = time.Now()
+
+	err := runInTx(ctx, r.repo, "wallet_reconcile_all", func(qtx repository.Queries) error {
+		if err := qtx.DeleteWalletMismatches(ctx); err != nil {
+			return err
+		}
+		for _, m := range found {
+			if err := createWalletMismatch(ctx, qtx, m); err != nil {
+				return err
+			}
+		}
+		return qtx.CreateWalletReconcileRun(ctx, run.StartedAt, run.FinishedAt, int32(run.Wallets), int32(run.Mismatched), int32(run.Failed))
+	})
+	if err != nil {
+		return run, fmt.Errorf("store reconciliation run: %w", err)
+	}
+
+	reconcileMetrics.Add("runs", 1)
+	setMetric(reconcileMetrics, "last_run.wallets", run.Wallets)
+	setMetric(reconcileMetrics, "last_run.mismatched", run.Mismatched)
+	setMetric(reconcileMetrics, "last_run.failed", run.Failed)
+	log.Printf("wallet reconciliation checked %d wallets in %v: %d mismatched, %d failed",
+		run.Wallets, run.FinishedAt.Sub(run.StartedAt), run.Mismatched, run.Failed)
+	return run, nil
+}
+
+// ReconcileWallet checks uid's wallet now and replaces the stored
+// mismatches for it.
+func (r *WalletReconciler) ReconcileWallet(ctx context.Context, uid string) ([]WalletMismatch, error) {
+	mismatches, err := r.check(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	err = runInTx(ctx, r.repo, "wallet_reconcile_one", func(qtx repository.Queries) error {
+		if err := qtx.DeleteWalletMismatchesByUid(ctx, uid); err != nil {
+			return err
+		}
+		for _, m := range mismatches {
+			if err := createWalletMismatch(ctx, qtx, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mismatches, nil
+}
+
+// Mismatches returns the last full run, zero if there has been none, and
+// every stored mismatch, ordered by uid.
+func (r *WalletReconciler) Mismatches(ctx context.Context) (run WalletReconcileRun, mismatches []WalletMismatch, err error) {
+	err = r.repo.InSnapshot(ctx, func(qtx repository.Queries) error {
+		lastRun, err := qtx.GetLatestWalletReconcileRun(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if err == nil {
+			run = WalletReconcileRun{
+				StartedAt:  lastRun.StartedAt,
+				FinishedAt: lastRun.FinishedAt,
+				Wallets:    int(lastRun.Wallets),
+				Mismatched: int(lastRun.Mismatched),
+				Failed:     int(lastRun.Failed),
+			}
+		}
+		rows, err := qtx.ListWalletMismatches(ctx)
+		if err != nil {
+			return err
+		}
+		mismatches = make([]WalletMismatch, 0, len(rows))
+		for _, row := range rows {
+			mismatches = append(mismatches, WalletMismatch{
+				Uid:           row.Uid,
+				Check:         row.CheckName,
+				Reference:     row.Reference,
+				ExpectedCoins: row.ExpectedCoins,
+				ActualCoins:   row.ActualCoins,
+				ExpectedXP:    row.ExpectedXp,
+				ActualXP:      row.ActualXp,
+				DetectedAt:    row.DetectedAt,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return WalletReconcileRun{}, nil, err
+	}
+	return run, mismatches, nil
+}
+
+func createWalletMismatch(ctx context.Context, qtx repository.Queries, m WalletMismatch) error {
+	return qtx.CreateWalletMismatch(ctx, m.Uid, m.Check, m.Reference, m.ExpectedCoins, m.ActualCoins, m.ExpectedXP, m.ActualXP, m.DetectedAt)
+}
+
+// check reconciles one wallet, then logs and counts each mismatch.
+func (r *WalletReconciler) check(ctx context.Context, uid string) ([]WalletMismatch, error) {
+	ctx, cancel := context.WithTimeout(ctx, walletReconcileTimeout)
+	defer cancel()
+	mismatches, err := reconcileWallet(ctx, r.repo, uid)
+	if err != nil {
+		return nil, err
+	}
+	reconcileMetrics.Add("wallets_checked", 1)
+	for _, m := range mismatches {
+		reconcileMetrics.Add("mismatches."+m.Check, 1)
+		log.Printf("wallet_mismatch uid=%s check=%s reference=%q expected_coins=%d actual_coins=%d expected_xp=%d actual_xp=%d",
+			m.Uid, m.Check, m.Reference, m.ExpectedCoins, m.ActualCoins, m.ExpectedXP, m.ActualXP)
+	}
+	return mismatches, nil
+}
+
+// reconcileWallet compares uid's wallet with what its ledger, payments and
+// finished sessions say it should hold. All reads share one snapshot, so
+// games played meanwhile cannot produce false mismatches.
+func reconcileWallet(ctx context.Context, repo repository.Repository, uid string) (mismatches []WalletMismatch, err error) {
+	playerCtx := context.WithValue(ctx, contextkey.UID, uid)
+	err = repo.InSnapshot(playerCtx, func(qtx repository.Queries) error {
+		mismatches = nil
+		now := time.Now()
+		mismatch := func(check string, reference string, expectedCoins, actualCoins, expectedXP, actualXP int64) {
+			if expectedCoins == actualCoins && expectedXP == actualXP {
+				return
+			}
+			mismatches = append(mismatches, WalletMismatch{
+				Uid:           uid,
+				Check:         check,
+				Reference:     reference,
+				ExpectedCoins: expectedCoins,
+				ActualCoins:   actualCoins,
+				ExpectedXP:    expectedXP,
+				ActualXP:      actualXP,
+				DetectedAt:    now,
+			})
+		}
+
+		// STEP 1: Wallet columns against the ledger
+		wallet, err := qtx.GetWalletByPlayerId(playerCtx)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPlayerNotFound
+		}
+		if err != nil {
+			return err
+		}
+		totals, err := qtx.GetWalletTransactionTotals(playerCtx)
+		if err != nil {
+			return err
+		}
+		mismatch(CheckLedgerBalance, "", totals.Coins, wallet.Coins-wallet.Debt, totals.Xp, wallet.Xp)
+
+		// STEP 2: Purchase credits against credited payments
+		paid, err := qtx.GetCreditedPaymentCoinsByUid(playerCtx)
+		if err != nil {
+			return err
+		}
+		mismatch(CheckPurchases, "", paid, totals.PurchaseCoins, 0, 0)
+
+		// STEP 3: Game rewards against a replay of each finished session
+		sessions, err := qtx.ListFinishedSessionRewardsByPlayerId(playerCtx)
+		if err != nil {
+			return err
+		}
+		for _, s := range sessions {
+			g, err := game.New(game.Config{
+				NumberOfBoards: s.NumberOfBoards.Int32,
+				BoardSize:      s.BoardSize.Int32,
+				Difficulty:     s.Difficulty.Int32,
+				Seed:           s.Seed,
+			}, s.Boards, s.IsAiMove)
+			if err != nil {
+				return fmt.Errorf("session %s: %w", s.SessionID, err)
+			}
+			// A quit game is marked over without its boards being, and pays
+			// nothing.
+			var coins, xp int64
+			if g.IsOver() {
+				coins, xp, err = g.Rewards()
+				if err != nil {
+					return fmt.Errorf("session %s: %w", s.SessionID, err)
+				}
+			}
+			mismatch(CheckSessionReward, s.SessionID, coins, s.CoinsRewarded, xp, s.XpRewarded)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mismatches, nil
+}
+
+func setMetric(m *expvar.Map, name string, value int) {
+	v := new(expvar.Int)
+	v.Set(int64(value))
+	m.Set(name, v)
+}
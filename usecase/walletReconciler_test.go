@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	db "github.com/rakshitg600/notakto-solo/db/generated"
+)
+
+func TestWalletReconcilerSharesMismatches(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	ctx := signUp(t, repo, "player", 100)
+	signUp(t, repo, "other", 100)
+	// A payment confirmed without its coins being credited
+	createPayment(t, repo, "order-1", "player", 500)
+	if _, err := repo.UpdatePaymentStatus(context.Background(), "order-1", paymentConfirmed, []string{paymentCreated}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two instances over the same database
+	first := NewWalletReconciler(repo, nil, 1)
+	second := NewWalletReconciler(repo, nil, 1)
+
+	run, err := first.ReconcileAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Wallets != 2 || run.Mismatched != 1 || run.Failed != 0 {
+		t.Fatalf("run = %+v, want 2 wallets, 1 mismatched", run)
+	}
+	stored, mismatches, err := second.Mismatches(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Wallets != run.Wallets || stored.Mismatched != run.Mismatched || !stored.FinishedAt.Equal(run.FinishedAt) {
+		t.Fatalf("stored run = %+v, want %+v", stored, run)
+	}
+	if len(mismatches) != 1 || mismatches[0].Uid != "player" || mismatches[0].Check != CheckPurchases ||
+		mismatches[0].ExpectedCoins != 500 || mismatches[0].ActualCoins != 0 {
+		t.Fatalf("mismatches = %+v, want the uncredited purchase", mismatches)
+	}
+
+	// Crediting the payment and rechecking the wallet on the other instance
+	// clears it for both
+	if err := repo.CreditWalletCoins(ctx, "player", 500, db.WalletTransactionReasonPurchase, "order-1"); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := second.ReconcileWallet(context.Background(), "player"); err != nil || len(found) != 0 {
+		t.Fatalf("ReconcileWallet = %+v, %v, want no mismatches", found, err)
+	}
+	if _, mismatches, err := first.Mismatches(context.Background()); err != nil || len(mismatches) != 0 {
+		t.Fatalf("Mismatches = %+v, %v, want none", mismatches, err)
+	}
+}
+
+func TestWalletReconcilerNoRunYet(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	run, mismatches, err := NewWalletReconciler(repo, nil, 10).Mismatches(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run != (WalletReconcileRun{}) || len(mismatches) != 0 {
+		t.Fatalf("Mismatches = %+v, %+v, want a zero run and none", run, mismatches)
+	}
+}
+
+func TestWalletReconcilerGate(t *testing.T) {
+	gateErr := errors.New("valkey down")
+	tests := []struct {
+		name    string
+		leader  bool
+		err     error
+		wantRan bool
+	}{
+		{"leader", true, nil, true},
+		{"another instance holds the lock", false, nil, false},
+		{"lock unavailable", false, gateErr, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newTestRepo(t)
+			signUp(t, repo, "player", 100)
+			var gotTTL time.Duration
+			gate := func(ctx context.Context, ttl time.Duration) (bool, error) {
+				gotTTL = ttl
+				return tt.leader, tt.err
+			}
+			reconciler := NewWalletReconciler(repo, gate, 10)
+			ran, err := reconciler.reconcileIfLeader(context.Background(), time.Minute)
+			if !errors.Is(err, tt.err) || ran != tt.wantRan {
+				t.Fatalf("reconcileIfLeader = %v, %v, want %v, %v", ran, err, tt.wantRan, tt.err)
+			}
+			if gotTTL <= 0 || gotTTL >= time.Minute {
+				t.Fatalf("lock held for %v, want less than the interval", gotTTL)
+			}
+			run, _, err := reconciler.Mismatches(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if recorded := run.Wallets == 1; recorded != tt.wantRan {
+				t.Fatalf("run recorded: %v, want %v", recorded, tt.wantRan)
+			}
+		})
+	}
+}